@@ -43,6 +43,9 @@ type Client struct {
 	// HistoryClient has a Tendermint connection.
 	historyClient rpcclient.HistoryClient
 
+	// MempoolClient has a Tendermint connection.
+	mempoolClient rpcclient.MempoolClient
+
 	// SignClient has a Tendermint connection in batch mode.
 	signClient rpcclient.SignClient
 
@@ -66,6 +69,7 @@ func NewClient(endpoint *url.URL, timeout time.Duration) (*Client, error) {
 	return &Client{
 		statusClient:      client,
 		historyClient:     client,
+		mempoolClient:     client,
 		signClient:        batchClient,
 		signClientTrigger: batchClient.Send,
 	}, nil
@@ -144,6 +148,32 @@ func (c *Client) Follow(out chan<- Block, offset int64, quit <-chan struct{}) (h
 	}
 }
 
+// LatestHeight gets the most recent block height from the node.
+func (c *Client) LatestHeight() (int64, error) {
+	status, err := c.statusClient.Status()
+	if err != nil {
+		return 0, fmt.Errorf("Tendermint RPC status unavailable: %w", err)
+	}
+	return status.SyncInfo.LatestBlockHeight, nil
+}
+
+// MempoolLimit is the maximum number of unconfirmed transactions per request.
+const mempoolLimit = 100
+
+// MempoolTransactions gets the unconfirmed transactions from the node, in
+// their raw encoding.
+func (c *Client) MempoolTransactions() ([][]byte, error) {
+	result, err := c.mempoolClient.UnconfirmedTxs(mempoolLimit)
+	if err != nil {
+		return nil, fmt.Errorf("Tendermint RPC unconfirmed_txs: %w", err)
+	}
+	txs := make([][]byte, len(result.Txs))
+	for i, tx := range result.Txs {
+		txs[i] = tx
+	}
+	return txs, nil
+}
+
 // FetchBlocks resolves n blocks into batch, starting at the offset (height).
 func (c *Client) fetchBlocks(batch []Block, offset int64) (n int, err error) {
 	last := offset + int64(len(batch)-1)
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
 // BaseURL defines the REST root.
@@ -14,9 +15,12 @@ var BaseURL string
 var Client http.Client
 
 type NodeAccount struct {
-	NodeAddr string `json:"node_address"`
-	Status   string `json:"status"`
-	Bond     int64  `json:"bond,string"`
+	NodeAddr    string `json:"node_address"`
+	Status      string `json:"status"`
+	Bond        int64  `json:"bond,string"`
+	Version     string `json:"version"`
+	IPAddr      string `json:"ip_address"`
+	SlashPoints int64  `json:"slash_points,string"`
 }
 
 func NodeAccountsLookup() ([]*NodeAccount, error) {
@@ -33,3 +37,180 @@ func NodeAccountsLookup() ([]*NodeAccount, error) {
 	}
 	return accounts, nil
 }
+
+type Network struct {
+	TotalReserve int64 `json:"total_reserve,string"`
+}
+
+func NetworkLookup() (*Network, error) {
+	resp, err := Client.Get(BaseURL + "/network")
+	if err != nil {
+		return nil, fmt.Errorf("network unavailable from REST on %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("network REST HTTP status %q, want 2xx", resp.Status)
+	}
+	var network Network
+	if err := json.NewDecoder(resp.Body).Decode(&network); err != nil {
+		return nil, fmt.Errorf("network irresolvable from REST on %w", err)
+	}
+	return &network, nil
+}
+
+type Constants struct {
+	Int64Values map[string]int64 `json:"int_64_values"`
+}
+
+func ConstantsLookup() (*Constants, error) {
+	resp, err := Client.Get(BaseURL + "/constants")
+	if err != nil {
+		return nil, fmt.Errorf("constants unavailable from REST on %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("constants REST HTTP status %q, want 2xx", resp.Status)
+	}
+	var constants Constants
+	if err := json.NewDecoder(resp.Body).Decode(&constants); err != nil {
+		return nil, fmt.Errorf("constants irresolvable from REST on %w", err)
+	}
+	return &constants, nil
+}
+
+type Vault struct {
+	PubKey    string `json:"pub_key"`
+	Status    string `json:"status"`
+	Addresses []struct {
+		Chain   string `json:"chain"`
+		Address string `json:"address"`
+	} `json:"addresses"`
+	Coins []struct {
+		Asset  string `json:"asset"`
+		Amount int64  `json:"amount,string"`
+	} `json:"coins"`
+}
+
+func AsgardVaultsLookup() ([]*Vault, error) {
+	resp, err := Client.Get(BaseURL + "/vaults/asgard")
+	if err != nil {
+		return nil, fmt.Errorf("asgard vaults unavailable from REST on %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("asgard vaults REST HTTP status %q, want 2xx", resp.Status)
+	}
+	var vaults []*Vault
+	if err := json.NewDecoder(resp.Body).Decode(&vaults); err != nil {
+		return nil, fmt.Errorf("asgard vaults irresolvable from REST on %w", err)
+	}
+	return vaults, nil
+}
+
+type InboundAddress struct {
+	Chain   string `json:"chain"`
+	PubKey  string `json:"pub_key"`
+	Address string `json:"address"`
+	Halted  bool   `json:"halted"`
+	GasRate int64  `json:"gas_rate,string"`
+}
+
+func InboundAddressesLookup() ([]*InboundAddress, error) {
+	resp, err := Client.Get(BaseURL + "/inbound_addresses")
+	if err != nil {
+		return nil, fmt.Errorf("inbound addresses unavailable from REST on %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("inbound addresses REST HTTP status %q, want 2xx", resp.Status)
+	}
+	var addrs []*InboundAddress
+	if err := json.NewDecoder(resp.Body).Decode(&addrs); err != nil {
+		return nil, fmt.Errorf("inbound addresses irresolvable from REST on %w", err)
+	}
+	return addrs, nil
+}
+
+type PendingLiquidity struct {
+	RuneAddr      string `json:"rune_address"`
+	AssetAddr     string `json:"asset_address"`
+	PendingRune   int64  `json:"pending_rune,string"`
+	PendingAsset  int64  `json:"pending_asset,string"`
+	LastAddHeight int64  `json:"last_add_height"`
+}
+
+// PendingLiquidityLookup gets the unmatched asymmetric deposits of pool.
+func PendingLiquidityLookup(pool string) ([]*PendingLiquidity, error) {
+	resp, err := Client.Get(BaseURL + "/pool/" + url.PathEscape(pool) + "/liquidity_providers")
+	if err != nil {
+		return nil, fmt.Errorf("liquidity providers unavailable from REST on %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("liquidity providers REST HTTP status %q, want 2xx", resp.Status)
+	}
+	var providers []*PendingLiquidity
+	if err := json.NewDecoder(resp.Body).Decode(&providers); err != nil {
+		return nil, fmt.Errorf("liquidity providers irresolvable from REST on %w", err)
+	}
+
+	pending := providers[:0]
+	for _, p := range providers {
+		if p.PendingRune != 0 || p.PendingAsset != 0 {
+			pending = append(pending, p)
+		}
+	}
+	return pending, nil
+}
+
+type LastChainHeight struct {
+	Chain          string `json:"chain"`
+	LastObservedIn int64  `json:"lastobservedin,string"`
+	LastSignedOut  int64  `json:"lastsignedout,string"`
+	Thorchain      int64  `json:"thorchain,string"`
+}
+
+func LastChainHeightsLookup() ([]*LastChainHeight, error) {
+	resp, err := Client.Get(BaseURL + "/lastblock")
+	if err != nil {
+		return nil, fmt.Errorf("last chain heights unavailable from REST on %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("last chain heights REST HTTP status %q, want 2xx", resp.Status)
+	}
+	var heights []*LastChainHeight
+	if err := json.NewDecoder(resp.Body).Decode(&heights); err != nil {
+		return nil, fmt.Errorf("last chain heights irresolvable from REST on %w", err)
+	}
+	return heights, nil
+}
+
+type Outbound struct {
+	Chain     string `json:"chain"`
+	ToAddress string `json:"to_address"`
+	Coin      struct {
+		Asset  string `json:"asset"`
+		Amount int64  `json:"amount,string"`
+	} `json:"coin"`
+	Memo   string `json:"memo"`
+	InHash string `json:"in_hash"`
+}
+
+// PendingOutboundsLookup gets the scheduled outbound transactions which are
+// not signed yet.
+func PendingOutboundsLookup() ([]*Outbound, error) {
+	resp, err := Client.Get(BaseURL + "/queue/outbound")
+	if err != nil {
+		return nil, fmt.Errorf("outbound queue unavailable from REST on %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("outbound queue REST HTTP status %q, want 2xx", resp.Status)
+	}
+	var outbounds []*Outbound
+	if err := json.NewDecoder(resp.Body).Decode(&outbounds); err != nil {
+		return nil, fmt.Errorf("outbound queue irresolvable from REST on %w", err)
+	}
+	return outbounds, nil
+}
@@ -5,12 +5,14 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"syscall"
@@ -27,9 +29,17 @@ import (
 	"gitlab.com/thorchain/midgard/internal/timeseries/stat"
 )
 
+// Version is set at build time, e.g., with -ldflags "-X main.version=2.0.0".
+var version = "develop"
+
 func main() {
 	log.SetFlags(log.Ldate | log.Ltime | log.LUTC)
-	log.Print("daemon launch as ", strings.Join(os.Args, " "))
+	log.Printf("daemon version %s launch as %s", version, strings.Join(os.Args, " "))
+
+	if len(os.Args) > 1 && os.Args[1] == "prune" {
+		prune(os.Args[2:])
+		return
+	}
 
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
@@ -50,13 +60,28 @@ func main() {
 
 	// apply configuration
 	SetupDatabase(&c)
+	if c.SnapshotInterval > 1 {
+		timeseries.SnapshotInterval = c.SnapshotInterval
+		log.Printf("depth snapshots every %d blocks", c.SnapshotInterval)
+	}
 	blocks := SetupBlockchain(&c)
 	if c.ListenPort == 0 {
 		c.ListenPort = 8080
 		log.Printf("default HTTP server listen port to %d", c.ListenPort)
 	}
+	if len(c.AllowedOrigins) == 0 {
+		c.AllowedOrigins = []string{"*"}
+		log.Printf("default CORS allowed origins to %q", c.AllowedOrigins)
+	}
+	if len(c.AllowedHeaders) == 0 {
+		c.AllowedHeaders = []string{"Content-Type"}
+		log.Printf("default CORS allowed headers to %q", c.AllowedHeaders)
+	}
+	if len(c.UpgradeHistoryKeys) != 0 {
+		api.UpgradeHistoryKeys = c.UpgradeHistoryKeys
+	}
 	srv := &http.Server{
-		Handler:      api.CORS(api.Handler),
+		Handler:      api.CORS(api.Versioned(api.Handler, version), c.AllowedOrigins, c.AllowedHeaders),
 		Addr:         fmt.Sprintf(":%d", c.ListenPort),
 		ReadTimeout:  c.ReadTimeout.WithDefault(2 * time.Second),
 		WriteTimeout: c.WriteTimeout.WithDefault(2 * time.Second),
@@ -71,7 +96,7 @@ func main() {
 
 	// launch blockchain reading
 	go func() {
-		m := event.Demux{Listener: timeseries.EventListener}
+		m := event.Demux{Listener: timeseries.EventListener, Metrics: event.StdRecorderMetrics}
 		for block := range blocks {
 			m.Block(block)
 			err := timeseries.CommitBlock(block.Height, block.Time, block.Hash)
@@ -80,11 +105,31 @@ func main() {
 				signals <- syscall.SIGABRT
 				return
 			}
+			stat.StdPoolStakesCache.Invalidate(block.Time)
 		}
 		log.Print("timeseries feed stopped")
 		signals <- syscall.SIGABRT
 	}()
 
+	// launch view maintenance
+	go func() {
+		for range time.Tick(time.Hour) {
+			if err := timeseries.RefreshPoolMemberCounts(); err != nil {
+				log.Print("pool member counts refresh: ", err)
+			}
+			if err := timeseries.RefreshPoolFirstSwaps(); err != nil {
+				log.Print("pool first swaps refresh: ", err)
+			}
+		}
+	}()
+	go func() {
+		for range time.Tick(time.Minute) {
+			if err := timeseries.RefreshStakerCount(); err != nil {
+				log.Print("staker count refresh: ", err)
+			}
+		}
+	}()
+
 	signal := <-signals
 	timeout := c.ShutdownTimeout.WithDefault(10 * time.Millisecond)
 	log.Print("HTTP shutdown initiated with timeout in ", timeout)
@@ -96,6 +141,52 @@ func main() {
 	log.Fatal("exit on signal ", signal)
 }
 
+// Prune is a maintenance command, intended for a (nightly) cron job.
+func prune(args []string) {
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	keepResolution := flags.String("keep-resolution", "1h", "time bucket size of the depth history beyond the retention period")
+	retainAfter := flags.String("retain-after", "30d", "retention period of the depth history in full detail")
+	flags.Parse(args)
+
+	var c Config
+	switch flags.NArg() {
+	case 0:
+		break // refer to defaults
+	case 1:
+		c = *MustLoadConfigFile(flags.Arg(0))
+	default:
+		log.Fatal("one optional configuration file argument only")
+	}
+
+	resolution, err := parseDays(*keepResolution)
+	if err != nil {
+		log.Fatal("exit on malformed keep resolution: ", err)
+	}
+	retention, err := parseDays(*retainAfter)
+	if err != nil {
+		log.Fatal("exit on malformed retention: ", err)
+	}
+
+	SetupDatabase(&c)
+	n, err := timeseries.PruneAggregateStates(context.Background(), resolution, time.Now().Add(-retention))
+	if err != nil {
+		log.Fatal("exit on prune: ", err)
+	}
+	log.Printf("pruned %d aggregate states with a %s resolution beyond %s", n, resolution, retention)
+}
+
+// ParseDays is time.ParseDuration with support for a "d" suffix.
+func parseDays(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("malformed number of days %q: %w", s, err)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
 func SetupDatabase(c *Config) {
 	db, err := sql.Open("pgx", fmt.Sprintf("user=%s dbname=%s sslmode=%s password=%s host=%s port=%d", c.TimeScale.UserName, c.TimeScale.Database, c.TimeScale.Sslmode, c.TimeScale.Password, c.TimeScale.Host, c.TimeScale.Port))
 	if err != nil {
@@ -138,6 +229,7 @@ func SetupBlockchain(c *Config) <-chan chain.Block {
 		// error check does not include network connectivity
 		log.Fatal("exit on Tendermint RPC client instantiation: ", err)
 	}
+	api.MempoolTransactions = client.MempoolTransactions
 
 	// fetch current position (from commit log)
 	offset, _, _, err := timeseries.Setup()
@@ -154,6 +246,21 @@ func SetupBlockchain(c *Config) <-chan chain.Block {
 	api.InSync = func() bool {
 		return time.Since(lastNoData.Load().(time.Time)) < 2*c.ThorChain.LastChainBackoff.WithDefault(7*time.Second)
 	}
+	api.InDepth = func() api.HealthStatus {
+		var nodeHeight int64
+		status := api.RunHealthCheck("tendermint", func(context.Context) error {
+			var err error
+			nodeHeight, err = client.LatestHeight()
+			return err
+		})
+		lag := api.HealthCheck{Name: "block lag"}
+		if status.Err != nil {
+			lag.Err = errors.New("node height unknown")
+		} else if height, _, _ := timeseries.LastBlock(); nodeHeight-height >= 10 {
+			lag.Err = fmt.Errorf("%d blocks behind node", nodeHeight-height)
+		}
+		return api.HealthStatus{Checks: []api.HealthCheck{status, lag}}
+	}
 
 	// launch read routine
 	ch := make(chan chain.Block, 99)
@@ -203,6 +310,16 @@ type Config struct {
 	ReadTimeout     Duration `json:"read_timeout"`
 	WriteTimeout    Duration `json:"write_timeout"`
 
+	// CORS allowlists, with "*" for any origin
+	AllowedOrigins []string `json:"allowed_origins"`
+	AllowedHeaders []string `json:"allowed_headers"`
+
+	// mimir names reported as protocol upgrades, with defaults when empty
+	UpgradeHistoryKeys []string `json:"upgrade_history_keys"`
+
+	// number of blocks between depth history writes
+	SnapshotInterval int64 `json:"snapshot_interval"`
+
 	TimeScale struct {
 		Host     string `json:"host"`
 		Port     int    `json:"port"`
@@ -1,4 +1,5 @@
 CREATE EXTENSION IF NOT EXISTS timescaledb CASCADE;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
 
 CREATE TABLE block_log (
 	height			BIGINT NOT NULL,
@@ -8,11 +9,12 @@ CREATE TABLE block_log (
 	PRIMARY KEY (height)
 );
 
+CREATE INDEX ON block_log (timestamp);
+
 
 -- Sparse table for depths.
 -- Not every height/pool pair is filled. For missing values, use the latest existing height for a pool.
 -- Asset and Rune are filled together, so it's not needed to look back for them separately.
--- NOT IMPLEMENTED YET
 -- TODO(acsaba): block_log.agg_state is 100x bigger than everything else combined in the database.
 --     When this table is implemented remove block_log.agg_state, and use this instead.
 CREATE TABLE aggregate_states (
@@ -23,6 +25,24 @@ CREATE TABLE aggregate_states (
 	PRIMARY KEY (height, pool)
 );
 
+CREATE INDEX ON aggregate_states (pool, height DESC);
+
+-- Pool identifiers from poolIdMapperr.
+CREATE TABLE pool_id_map (
+	pool_id			INT NOT NULL,
+	pool			VARCHAR(60) NOT NULL UNIQUE,
+	PRIMARY KEY (pool_id)
+);
+
+-- Same as aggregate_states, with pools identified by poolIdMapperr.
+CREATE TABLE aggregate_id_states (
+	height			BIGINT NOT NULL,
+	pool_id			INT NOT NULL,
+	asset_E8		BIGINT NOT NULL,
+	rune_E8			BIGINT NOT NULL,
+	PRIMARY KEY (height, pool_id)
+);
+
 
 CREATE TABLE active_vault_events (
 	add_asgard_addr		VARCHAR(90) NOT NULL,
@@ -156,6 +176,9 @@ CREATE TABLE outbound_events (
 
 SELECT create_hypertable('outbound_events', 'block_timestamp', chunk_time_interval => 86400000000000);
 
+-- withdrawals per unstake
+CREATE INDEX ON outbound_events (in_tx);
+
 
 CREATE TABLE pool_events (
 	asset			VARCHAR(60) NOT NULL,
@@ -270,6 +293,11 @@ CREATE TABLE stake_events (
 
 SELECT create_hypertable('stake_events', 'block_timestamp', chunk_time_interval => 86400000000000);
 
+-- pool search with LIKE patterns
+CREATE INDEX ON stake_events USING GIN (LOWER(pool) gin_trgm_ops);
+-- stake listing per pool
+CREATE INDEX ON stake_events (pool, block_timestamp DESC);
+
 
 CREATE TABLE swap_events (
 	tx			CHAR(64) NOT NULL,
@@ -289,6 +317,29 @@ CREATE TABLE swap_events (
 
 SELECT create_hypertable('swap_events', 'block_timestamp', chunk_time_interval => 86400000000000);
 
+-- recent swaps per pool
+CREATE INDEX ON swap_events (pool, block_timestamp DESC);
+
+
+CREATE TABLE synthetic_burn_events (
+	asset			VARCHAR(60) NOT NULL,
+	E8			BIGINT NOT NULL,
+	reason			TEXT NOT NULL,
+	block_timestamp		BIGINT NOT NULL
+);
+
+SELECT create_hypertable('synthetic_burn_events', 'block_timestamp', chunk_time_interval => 86400000000000);
+
+
+CREATE TABLE synthetic_mint_events (
+	asset			VARCHAR(60) NOT NULL,
+	E8			BIGINT NOT NULL,
+	reason			TEXT NOT NULL,
+	block_timestamp		BIGINT NOT NULL
+);
+
+SELECT create_hypertable('synthetic_mint_events', 'block_timestamp', chunk_time_interval => 86400000000000);
+
 
 CREATE TABLE transfer_events (
 	from_addr		VARCHAR(90) NOT NULL,
@@ -317,6 +368,9 @@ CREATE TABLE unstake_events (
 
 SELECT create_hypertable('unstake_events', 'block_timestamp', chunk_time_interval => 86400000000000);
 
+-- unstake listing per pool
+CREATE INDEX ON unstake_events (pool, block_timestamp DESC);
+
 
 CREATE TABLE update_node_account_status_events (
 	node_addr		VARCHAR(90) NOT NULL,
@@ -336,3 +390,48 @@ CREATE TABLE validator_request_leave_events (
 );
 
 SELECT create_hypertable('validator_request_leave_events', 'block_timestamp', chunk_time_interval => 86400000000000);
+
+
+-- Member count per pool at the end of each hour with a change. Addresses
+-- count as a member while their net stake units are positive. The view is
+-- too expensive for live queries; refresh it periodically instead.
+CREATE MATERIALIZED VIEW pool_member_counts AS
+WITH hourly AS (
+	SELECT pool, addr, time_bucket(3600000000000, block_timestamp) AS bucket, SUM(units) AS units
+	FROM (
+		SELECT pool, rune_addr AS addr, stake_units AS units, block_timestamp FROM stake_events
+		UNION ALL
+		SELECT pool, from_addr, -stake_units, block_timestamp FROM unstake_events
+	) AS changes
+	GROUP BY pool, addr, bucket
+), running AS (
+	SELECT pool, addr, bucket, SUM(units) OVER (PARTITION BY pool, addr ORDER BY bucket) AS units
+	FROM hourly
+), transitions AS (
+	SELECT pool, bucket, CASE
+		WHEN units > 0 AND COALESCE(LAG(units) OVER w, 0) <= 0 THEN 1
+		WHEN units <= 0 AND COALESCE(LAG(units) OVER w, 0) > 0 THEN -1
+		ELSE 0 END AS delta
+	FROM running
+	WINDOW w AS (PARTITION BY pool, addr ORDER BY bucket)
+)
+SELECT pool, bucket, SUM(SUM(delta)) OVER (PARTITION BY pool ORDER BY bucket) AS count
+FROM transitions
+GROUP BY pool, bucket;
+
+CREATE UNIQUE INDEX ON pool_member_counts (pool, bucket);
+
+-- First swap of each address per pool. The view is too expensive for live
+-- queries; refresh it periodically instead.
+CREATE MATERIALIZED VIEW pool_first_swaps AS
+SELECT pool, from_addr AS addr, MIN(block_timestamp) AS first_swap
+FROM swap_events
+GROUP BY pool, from_addr;
+
+CREATE UNIQUE INDEX ON pool_first_swaps (pool, addr);
+CREATE INDEX ON pool_first_swaps (pool, first_swap);
+
+-- Number of distinct addresses which staked. The view is too expensive for
+-- live queries; refresh it periodically instead.
+CREATE MATERIALIZED VIEW staker_count AS
+SELECT COUNT(DISTINCT rune_addr) AS count FROM stake_events;
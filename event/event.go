@@ -437,6 +437,44 @@ func (e *Message) LoadTendermint(attrs []kv.Pair) error {
 	return nil
 }
 
+// MintBurn defines the "mint_burn" event type.
+type MintBurn struct {
+	Supply []byte // "mint" or "burn"
+	Asset  []byte // upper case, with a slash separator for synthetics
+	E8     int64  // quantity times 100 M
+	Reason []byte
+}
+
+// LoadTendermint adopts the attributes.
+func (e *MintBurn) LoadTendermint(attrs []kv.Pair) error {
+	*e = MintBurn{}
+
+	for _, attr := range attrs {
+		var err error
+		switch string(attr.Key) {
+		case "supply":
+			e.Supply = attr.Value
+		case "denom":
+			e.Asset = bytes.ToUpper(attr.Value)
+		case "amount":
+			e.E8, err = strconv.ParseInt(string(attr.Value), 10, 64)
+			if err != nil {
+				return fmt.Errorf("malformed amount: %w", err)
+			}
+		case "reason":
+			e.Reason = attr.Value
+
+		default:
+			log.Printf("unknown mint_burn event attribute %q=%q", attr.Key, attr.Value)
+		}
+	}
+
+	return nil
+}
+
+// IsSynth returns whether the asset is a synthetic, i.e., "BTC/BTC".
+func (e *MintBurn) IsSynth() bool { return bytes.IndexByte(e.Asset, '/') > 0 }
+
 // NewNode defines the "new_node" event type.
 type NewNode struct {
 	NodeAddr []byte // THOR address
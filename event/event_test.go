@@ -3,6 +3,7 @@ package event
 import (
 	"testing"
 
+	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/libs/kv"
 )
 
@@ -111,3 +112,45 @@ func toAttrs(m map[string]string) []kv.Pair {
 	}
 	return a
 }
+
+func TestMintBurn(t *testing.T) {
+	var event MintBurn
+	err := event.LoadTendermint(toAttrs(map[string]string{
+		"supply": "mint",
+		"denom":  "btc/btc",
+		"amount": "1000",
+		"reason": "swap",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(event.Asset) != "BTC/BTC" || event.E8 != 1000 {
+		t.Errorf("got %q %d, want BTC/BTC 1000", event.Asset, event.E8)
+	}
+	if !event.IsSynth() {
+		t.Error("BTC/BTC not a synth")
+	}
+}
+
+func TestDemuxMetrics(t *testing.T) {
+	m := StdRecorderMetrics
+	d := Demux{Listener: nil, Metrics: m}
+
+	unknownsBefore := m.UnknownEventTypesTotal.Get()
+	if err := d.event(abci.Event{Type: "no_such_type"}, new(Metadata)); err != errEventType {
+		t.Fatalf("got error %v, want %v", err, errEventType)
+	}
+	if got := m.UnknownEventTypesTotal.Get() - unknownsBefore; got != 1 {
+		t.Errorf("unknown event type count increased by %d, want 1", got)
+	}
+
+	parseErrorsBefore := m.ParseErrorsTotal("swap").Get()
+	malformed := abci.Event{Type: "swap", Attributes: toAttrs(map[string]string{"trade_slip": "many"})}
+	if err := d.event(malformed, new(Metadata)); err == nil {
+		t.Fatal("swap with malformed trade_slip got no error")
+	}
+	if got := m.ParseErrorsTotal("swap").Get() - parseErrorsBefore; got != 1 {
+		t.Errorf("swap parse error count increased by %d, want 1", got)
+	}
+}
@@ -29,6 +29,28 @@ var (
 	PoolRewardsTotal = metrics.MustCounter("midgard_pool_rewards_total", "Number of asset amounts on rewards events seen.")
 )
 
+// RecorderMetrics tracks the throughput of a Demux.
+type RecorderMetrics struct {
+	EventsTotal            func(eventType string) *metrics.Counter
+	BlockDuration          *metrics.Histogram
+	ParseErrorsTotal       func(eventType string) *metrics.Counter
+	UnknownEventTypesTotal *metrics.Counter
+}
+
+// StdRecorderMetrics is the registered instance. Metric names are global,
+// hence there can be only one.
+var StdRecorderMetrics = &RecorderMetrics{
+	EventsTotal:            metrics.Must1LabelCounter("midgard_recorder_events_total", "type"),
+	BlockDuration:          metrics.MustHistogram("midgard_recorder_block_duration_seconds", "Amount of time spend on a block, including the listener.", 0.001, 0.01, 0.1, 1),
+	ParseErrorsTotal:       metrics.Must1LabelCounter("midgard_recorder_parse_errors_total", "event_type"),
+	UnknownEventTypesTotal: metrics.MustCounter("midgard_recorder_unknown_event_type_total", "Number of events discarded due to an unknown type."),
+}
+
+func init() {
+	metrics.MustHelp("midgard_recorder_events_total", "Number of events passed to the listener.")
+	metrics.MustHelp("midgard_recorder_parse_errors_total", "Number of events skipped due to malformed content.")
+}
+
 // Metadata has metadata for a block (from the chain).
 type Metadata struct {
 	BlockHeight    int64     // Tendermint sequence identifier
@@ -46,6 +68,7 @@ type Listener interface {
 	OnGas(*Gas, *Metadata)
 	OnInactiveVault(*InactiveVault, *Metadata)
 	OnMessage(*Message, *Metadata)
+	OnMintBurn(*MintBurn, *Metadata)
 	OnNewNode(*NewNode, *Metadata)
 	OnOutbound(*Outbound, *Metadata)
 	OnPool(*Pool, *Metadata)
@@ -71,6 +94,9 @@ type Demux struct {
 	// Implementations MAY NOT retain any of the events provided.
 	Listener
 
+	// Metrics is optional. Nil disables the recording.
+	Metrics *RecorderMetrics
+
 	// prevent memory allocation
 	reuse struct {
 		ActiveVault
@@ -82,6 +108,7 @@ type Demux struct {
 		Gas
 		InactiveVault
 		Message
+		MintBurn
 		NewNode
 		Outbound
 		Pool
@@ -105,6 +132,9 @@ type Demux struct {
 // Block invokes Listener for each transaction event in block.
 func (d *Demux) Block(block chain.Block) {
 	defer BlockProcTime.AddSince(time.Now())
+	if d.Metrics != nil {
+		defer d.Metrics.BlockDuration.AddSince(time.Now())
+	}
 
 	m := Metadata{
 		BlockHeight:    block.Height,
@@ -153,8 +183,20 @@ var errEventType = errors.New("unknown event type")
 
 // Block notifies Listener for the transaction event.
 // Errors do not include the event type in the message.
-func (d *Demux) event(event abci.Event, meta *Metadata) error {
+func (d *Demux) event(event abci.Event, meta *Metadata) (err error) {
 	defer EventProcTime(event.Type).AddSince(time.Now())
+	if m := d.Metrics; m != nil {
+		defer func() {
+			switch err {
+			case nil:
+				m.EventsTotal(event.Type).Add(1)
+			case errEventType:
+				m.UnknownEventTypesTotal.Add(1)
+			default:
+				m.ParseErrorsTotal(event.Type).Add(1)
+			}
+		}()
+	}
 
 	attrs := event.Attributes
 	AttrPerEvent.Add(float64(len(attrs)))
@@ -205,6 +247,11 @@ func (d *Demux) event(event abci.Event, meta *Metadata) error {
 			return err
 		}
 		d.Listener.OnMessage(&d.reuse.Message, meta)
+	case "mint_burn":
+		if err := d.reuse.MintBurn.LoadTendermint(attrs); err != nil {
+			return err
+		}
+		d.Listener.OnMintBurn(&d.reuse.MintBurn, meta)
 	case "new_node":
 		if err := d.reuse.NewNode.LoadTendermint(attrs); err != nil {
 			return err
@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/pascaldekloe/metrics"
 	thunder "github.com/samsarahq/thunder/graphql"
 
 	"gitlab.com/thorchain/midgard/internal/graphql"
+	"gitlab.com/thorchain/midgard/internal/timeseries"
 )
 
 // Handler serves the entire API.
@@ -29,15 +32,101 @@ func init() {
 
 	// version 1
 	router.HandlerFunc(http.MethodGet, "/v1/assets", serveV1Assets)
+	router.HandlerFunc(http.MethodGet, "/v1/blocks/range", serveV1BlockRange)
+	router.HandlerFunc(http.MethodGet, "/v1/gas/rates", serveV1GasRates)
 	router.HandlerFunc(http.MethodGet, "/v1/health", serveV1Health)
 	router.HandlerFunc(http.MethodGet, "/v1/network", serveV1Network)
+	router.HandlerFunc(http.MethodGet, "/v1/network/active_users", serveV1ActiveUsers)
+	router.HandlerFunc(http.MethodGet, "/v1/network/tvl", serveV1TVL)
+	router.HandlerFunc(http.MethodGet, "/v1/network/emission", serveV1Emission)
+	router.HandlerFunc(http.MethodGet, "/v1/network/block_rewards/history", serveV1BlockRewardHistory)
+	router.HandlerFunc(http.MethodGet, "/v1/network/churn/schedule", serveV1ChurnSchedule)
+	router.HandlerFunc(http.MethodGet, "/v1/network/circulating_supply", serveV1CirculatingSupply)
+	router.HandlerFunc(http.MethodGet, "/v1/network/validators", serveV1Validators)
+	router.HandlerFunc(http.MethodGet, "/v1/network/version", serveV1NetworkVersion)
+	router.HandlerFunc(http.MethodGet, "/v1/network/fee_schedule", serveV1FeeSchedule)
+	router.HandlerFunc(http.MethodGet, "/v1/network/gas_spending", serveV1GasSpending)
+	router.HandlerFunc(http.MethodGet, "/v1/network/health/deep", serveV1DeepHealth)
+	router.HandlerFunc(http.MethodGet, "/v1/network/insurance_fund", serveV1InsuranceFund)
+	router.HandlerFunc(http.MethodGet, "/v1/network/last_chain_heights", serveV1LastChainHeights)
+	router.HandlerFunc(http.MethodGet, "/v1/network/pool_activation_queue", serveV1PoolActivationQueue)
+	router.HandlerFunc(http.MethodGet, "/v1/network/pool_bonds", serveV1PoolBonds)
+	router.HandlerFunc(http.MethodGet, "/v1/network/nodes/bond_history/:addr", serveV1NodeBondHistory)
+	router.HandlerFunc(http.MethodGet, "/v1/network/nodes/performance", serveV1NodePerformance)
+	router.HandlerFunc(http.MethodGet, "/v1/network/nodes/stake_distribution", serveV1NodeStakeDistribution)
+	router.HandlerFunc(http.MethodGet, "/v1/network/node_rotation_rate", serveV1NodeRotationRate)
+	router.HandlerFunc(http.MethodGet, "/v1/network/security_ratio", serveV1SecurityRatio)
+	router.HandlerFunc(http.MethodGet, "/v1/network/slash_history", serveV1NetworkSlashHistory)
+	router.HandlerFunc(http.MethodGet, "/v1/network/asgard_vaults", serveV1AsgardVaults)
+	router.HandlerFunc(http.MethodGet, "/v1/network/asgard_vaults/:chain", serveV1AsgardVaultsChain)
+	router.HandlerFunc(http.MethodGet, "/v1/network/solvency", serveV1Solvency)
+	router.HandlerFunc(http.MethodGet, "/v1/network/upgrade_history", serveV1UpgradeHistory)
+	router.HandlerFunc(http.MethodGet, "/v1/network/upgrade_votes", serveV1UpgradeVotes)
+	router.HandlerFunc(http.MethodGet, "/v1/network/treasury", serveV1Treasury)
+	router.HandlerFunc(http.MethodGet, "/v1/network/wealth_distribution", serveV1WealthDistribution)
+	router.HandlerFunc(http.MethodGet, "/v1/quote/swap", serveV1QuoteSwap)
+	router.HandlerFunc(http.MethodGet, "/v1/quote/stake", serveV1QuoteStake)
+	router.HandlerFunc(http.MethodGet, "/v1/quote/unstake", serveV1QuoteUnstake)
 	router.HandlerFunc(http.MethodGet, "/v1/nodes", serveV1Nodes)
+	router.HandlerFunc(http.MethodGet, "/v1/nodes/key/:pubkey", serveV1NodeByPubKey)
 	router.HandlerFunc(http.MethodGet, "/v1/pools", serveV1Pools)
 	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset", serveV1PoolsAsset)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/alerts", serveV1PoolAlerts)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/apy_history", serveV1APYHistory)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/arbitrage", serveV1PoolArbitrage)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/balance_checks", serveV1PoolBalanceCheck)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/concentration", serveV1LPConcentration)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/correlation_matrix", serveV1PoolCorrelationMatrix)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/correlations", serveV1PoolCorrelations)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/address_clustering", serveV1LPClusters)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/coverage", serveV1PoolCoverage)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/depth/at", serveV1PoolDepthAt)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/depth/moving_average", serveV1DepthMA)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/depth/predict", serveV1PoolDepthPredict)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/depth/trend", serveV1DepthTrend)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/dominant_direction", serveV1DominantDirection)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/exit_impact", serveV1ExitImpact)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/fee_comparison", serveV1FeeComparison)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/fees/history", serveV1PoolFeeHistory)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/full_history", serveV1PoolsAssetFullHistory)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/gas_history", serveV1PoolGasHistory)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/large_transactions", serveV1LargeTransactions)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/txs", serveV1PoolTxs)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/turnover", serveV1PoolTurnover)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/transactions/count", serveV1PoolTxCounts)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/market_depth", serveV1MarketDepth)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/members/count", serveV1PoolMemberCount)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/price/impact", serveV1PriceImpact)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/rebalancing", serveV1PoolRebalancing)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/retention", serveV1LPRetention)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/roi", serveV1PoolROI)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/routing", serveV1PoolRouting)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/stability", serveV1PoolStability)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/stake_events", serveV1PoolStakeEvents)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/swap_size_distribution", serveV1SwapSizeDistribution)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/swaps/recent", serveV1RecentSwaps)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/synthetic", serveV1SyntheticAsset)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/synthetic/burns_and_mints", serveV1SynthBurnsMints)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/unique_traders", serveV1UniqueTraders)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/unstake_events", serveV1PoolUnstakeEvents)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/volume_weighted_price", serveV1VWAP)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/yield", serveV1PoolYield)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/impermanent_loss_protection", serveV1ILPStatus)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/income_statement", serveV1IncomeStatement)
+	router.HandlerFunc(http.MethodGet, "/v1/pools/:asset/pending_liquidity", serveV1PendingLiquidity)
 	router.HandlerFunc(http.MethodGet, "/v1/stakers", serveV1Stakers)
 	router.HandlerFunc(http.MethodGet, "/v1/stakers/:addr", serveV1StakersAddr)
+	router.HandlerFunc(http.MethodGet, "/v1/stakers/:addr/history", serveV1StakerHistory)
+	router.HandlerFunc(http.MethodGet, "/v1/stakers/:addr/pnl", serveV1StakerPnL)
+	router.HandlerFunc(http.MethodGet, "/v1/stakers/:addr/positions", serveV1StakerPositions)
+	router.HandlerFunc(http.MethodGet, "/v1/stakers/:addr/rewards", serveV1StakerRewards)
+	router.HandlerFunc(http.MethodGet, "/v1/stakers/:addr/simulation", serveV1StakingSimulation)
+	router.HandlerFunc(http.MethodGet, "/v1/stakers/:addr/transactions", serveV1StakerTransactions)
 	router.HandlerFunc(http.MethodGet, "/v1/stats", serveV1Stats)
 	router.HandlerFunc(http.MethodGet, "/v1/swagger.json", serveV1SwaggerJSON)
+	router.HandlerFunc(http.MethodGet, "/v1/swaps/address/:addr", serveV1SwapsByAddr)
+	router.HandlerFunc(http.MethodGet, "/v1/swaps/aggregate", serveV1SwapAggregate)
+	router.HandlerFunc(http.MethodGet, "/v1/swaps/slippage/distribution", serveV1SlippageDistribution)
 
 	// version 2 with GraphQL
 	router.HandlerFunc(http.MethodGet, "/v2", serveV2)
@@ -85,10 +174,51 @@ func serveV2(w http.ResponseWriter, r *http.Request) {
 `, r.Host)
 }
 
-// CORS returns a Handler which applies CORS on h.
-func CORS(h http.Handler) http.Handler {
+// CORS returns a Handler which applies CORS on h. The wildcard "*" in
+// allowedOrigins permits any origin. Other origins are echoed back only when
+// present in allowedOrigins. Preflight requests are served without h.
+func CORS(h http.Handler, allowedOrigins, allowedHeaders []string) http.Handler {
+	var wildcard bool
+	origins := make(map[string]bool, len(allowedOrigins))
+	for _, o := range allowedOrigins {
+		if o == "*" {
+			wildcard = true
+		}
+		origins[o] = true
+	}
+	headers := strings.Join(allowedHeaders, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wildcard {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			// response differs per origin
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); origin != "" && origins[origin] {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		if headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Versioned returns a Handler which labels each response from h with the
+// daemon version and the last block height. Clients can detect staleness
+// with the height, without parsing the body.
+func Versioned(h http.Handler, version string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		height, _, _ := timeseries.LastBlock()
+		w.Header().Set("X-Midgard-Version", version)
+		w.Header().Set("X-Midgard-Chain-Height", strconv.FormatInt(height, 10))
 		h.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var corsTestHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusTeapot)
+})
+
+func TestCORSPreflight(t *testing.T) {
+	h := CORS(corsTestHandler, []string{"https://example.com"}, []string{"Content-Type"})
+
+	req := httptest.NewRequest(http.MethodOptions, "/v1/pools", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusNoContent {
+		t.Errorf("got HTTP status %d, want %d", resp.Code, http.StatusNoContent)
+	}
+	if got := resp.Header().Get("Access-Control-Allow-Origin"); got != "https://example.com" {
+		t.Errorf("got allow origin %q, want https://example.com", got)
+	}
+	if got := resp.Header().Get("Access-Control-Allow-Methods"); got == "" {
+		t.Error("no allow methods")
+	}
+	if got := resp.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type" {
+		t.Errorf("got allow headers %q, want Content-Type", got)
+	}
+}
+
+func TestCORSOriginMatch(t *testing.T) {
+	h := CORS(corsTestHandler, []string{"https://a.example.com", "https://b.example.com"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/pools", nil)
+	req.Header.Set("Origin", "https://b.example.com")
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusTeapot {
+		t.Errorf("got HTTP status %d, want %d from handler", resp.Code, http.StatusTeapot)
+	}
+	if got := resp.Header().Get("Access-Control-Allow-Origin"); got != "https://b.example.com" {
+		t.Errorf("got allow origin %q, want https://b.example.com", got)
+	}
+	if got := resp.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("got vary %q, want Origin", got)
+	}
+}
+
+func TestCORSOriginMismatch(t *testing.T) {
+	h := CORS(corsTestHandler, []string{"https://example.com"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/pools", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if got, ok := resp.Header()["Access-Control-Allow-Origin"]; ok {
+		t.Errorf("got allow origin %q, want none", got)
+	}
+}
+
+func TestCORSWildcard(t *testing.T) {
+	h := CORS(corsTestHandler, []string{"*"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/pools", nil)
+	req.Header.Set("Origin", "https://example.com")
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if got := resp.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("got allow origin %q, want *", got)
+	}
+}
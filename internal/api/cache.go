@@ -0,0 +1,33 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// TimedCache memoizes a value for a fixed duration.
+type timedCache struct {
+	TTL time.Duration
+
+	mutex  sync.Mutex
+	value  interface{}
+	expiry time.Time
+}
+
+// Get returns the cached value, or the result of f when expired. Errors from f
+// are not cached.
+func (c *timedCache) get(f func() (interface{}, error)) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	if now.Before(c.expiry) {
+		return c.value, nil
+	}
+	v, err := f()
+	if err != nil {
+		return nil, err
+	}
+	c.value, c.expiry = v, now.Add(c.TTL)
+	return v, nil
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimedCache(t *testing.T) {
+	c := timedCache{TTL: time.Hour}
+	var calls int
+	f := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		got, err := c.get(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != 1 {
+			t.Errorf("get %d got %v, want 1", i, got)
+		}
+	}
+
+	c.expiry = time.Time{}
+	if got, err := c.get(f); err != nil || got != 2 {
+		t.Errorf("expired get got %v and %v, want 2", got, err)
+	}
+}
+
+func TestTimedCacheError(t *testing.T) {
+	c := timedCache{TTL: time.Hour}
+	want := errors.New("test error")
+	if _, err := c.get(func() (interface{}, error) { return nil, want }); err != want {
+		t.Errorf("got error %v, want %v", err, want)
+	}
+	// errors are not cached
+	if got, err := c.get(func() (interface{}, error) { return 42, nil }); err != nil || got != 42 {
+		t.Errorf("got %v and %v, want 42", got, err)
+	}
+}
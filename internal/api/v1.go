@@ -9,11 +9,15 @@ import (
 	"math/big"
 	"net/http"
 	"path"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"golang.org/x/sync/errgroup"
+
 	"gitlab.com/thorchain/midgard/chain/notinchain"
 	"gitlab.com/thorchain/midgard/internal/timeseries"
 	"gitlab.com/thorchain/midgard/internal/timeseries/stat"
@@ -24,6 +28,13 @@ import (
 // InSync returns whether the entire blockchain is processed.
 var InSync func() bool
 
+// InDepth runs the health checks on the blockchain client. Nil omits them.
+var InDepth func() HealthStatus
+
+// MempoolTransactions returns the unconfirmed transactions in their raw
+// encoding. Nil disables mempool inspection.
+var MempoolTransactions func() ([][]byte, error)
+
 func serveV1Assets(w http.ResponseWriter, r *http.Request) {
 	assets, err := assetParam(r)
 	if err != nil {
@@ -63,6 +74,116 @@ func serveV1Health(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// HealthCheck is the outcome of a component check.
+type HealthCheck struct {
+	Name    string
+	Latency time.Duration
+	Err     error // nil for OK
+}
+
+// HealthStatus is a set of checks.
+type HealthStatus struct {
+	Checks []HealthCheck
+}
+
+// HealthCheckTimeout is the maximum duration of a check.
+const HealthCheckTimeout = 5 * time.Second
+
+// RunHealthCheck executes f within HealthCheckTimeout. Functions which do not
+// honor the context are abandoned on timeout.
+func RunHealthCheck(name string, f func(ctx context.Context) error) HealthCheck {
+	ctx, cancel := context.WithTimeout(context.Background(), HealthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- f(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+		break
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	return HealthCheck{Name: name, Latency: time.Since(start), Err: err}
+}
+
+// LaunchTime is the process start, approximately.
+var launchTime = time.Now()
+
+// LastBlockMaxAge is the maximum duration since the last block commit.
+const lastBlockMaxAge = 30 * time.Second
+
+func serveV1DeepHealth(w http.ResponseWriter, r *http.Request) {
+	var wg sync.WaitGroup
+	var database HealthCheck
+	var client HealthStatus
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		database = RunHealthCheck("database", func(ctx context.Context) error {
+			rows, err := timeseries.DBQuery(ctx, "SELECT 1")
+			if err != nil {
+				return err
+			}
+			return rows.Close()
+		})
+	}()
+	if InDepth != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client = InDepth()
+		}()
+	}
+	wg.Wait()
+
+	checks := append([]HealthCheck{database}, client.Checks...)
+	_, timestamp, _ := timeseries.LastBlock()
+	freshness := HealthCheck{Name: "last block"}
+	if age := time.Since(timestamp); age > lastBlockMaxAge {
+		freshness.Err = fmt.Errorf("last block committed %s ago, exceeds %s", age.Round(time.Second), lastBlockMaxAge)
+	}
+	checks = append(checks, freshness)
+
+	status := healthStatusOf(checks)
+	array := make([]map[string]interface{}, len(checks))
+	for i, c := range checks {
+		m := map[string]interface{}{
+			"name":      c.Name,
+			"ok":        c.Err == nil,
+			"latencyMs": c.Latency.Milliseconds(),
+		}
+		if c.Err != nil {
+			m["error"] = c.Err.Error()
+		}
+		array[i] = m
+	}
+	if status != "ok" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	respJSON(w, map[string]interface{}{
+		"status":        status,
+		"checks":        array,
+		"uptimeSeconds": int64(time.Since(launchTime).Seconds()),
+	})
+}
+
+// HealthStatusOf summarizes checks with the database first. Without the
+// database nothing can be served.
+func healthStatusOf(checks []HealthCheck) string {
+	if len(checks) == 0 || checks[0].Err != nil {
+		return "down"
+	}
+	for _, c := range checks[1:] {
+		if c.Err != nil {
+			return "degraded"
+		}
+	}
+	return "ok"
+}
+
 func serveV1Network(w http.ResponseWriter, r *http.Request) {
 	_, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
 
@@ -180,6 +301,57 @@ func serveV1Nodes(w http.ResponseWriter, r *http.Request) {
 	respJSON(w, array)
 }
 
+func serveV1NodeByPubKey(w http.ResponseWriter, r *http.Request) {
+	key := path.Base(r.URL.Path)
+
+	secpAddrs, edAddrs, err := timeseries.NodesSecpAndEd(r.Context(), time.Now())
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	// Bech32 encodings of Amino have a type prefix.
+	var addr string
+	var ok bool
+	switch {
+	case strings.Contains(key, "1addwnpep"):
+		addr, ok = secpAddrs[key]
+	case strings.Contains(key, "1zcjduepq"):
+		addr, ok = edAddrs[key]
+	default:
+		addr, ok = secpAddrs[key]
+		if !ok {
+			addr, ok = edAddrs[key]
+		}
+	}
+	if !ok {
+		http.Error(w, fmt.Sprintf("public key %q not registered", key), http.StatusNotFound)
+		return
+	}
+
+	statusPerNode, err := timeseries.StatusPerNode(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	m := map[string]interface{}{
+		"nodeAddr": addr,
+		"status":   statusPerNode[addr],
+	}
+	for key, a := range secpAddrs {
+		if a == addr {
+			m["secp256k1"] = key
+		}
+	}
+	for key, a := range edAddrs {
+		if a == addr {
+			m["ed25519"] = key
+		}
+	}
+	respJSON(w, m)
+}
+
 func serveV1Pools(w http.ResponseWriter, r *http.Request) {
 	pools, err := timeseries.Pools(r.Context(), time.Time{})
 	if err != nil {
@@ -191,9 +363,39 @@ func serveV1Pools(w http.ResponseWriter, r *http.Request) {
 
 func serveV1PoolsAsset(w http.ResponseWriter, r *http.Request) {
 	asset := path.Base(r.URL.Path)
-	if asset == "detail" {
+	switch asset {
+	case "detail":
 		serveV1PoolsDetail(w, r)
 		return
+	case "all":
+		serveV1AllPoolDetails(w, r)
+		return
+	case "inactive":
+		serveV1InactivePools(w, r)
+		return
+	case "search":
+		serveV1PoolSearch(w, r)
+		return
+	case "comparison":
+		serveV1PoolComparison(w, r)
+		return
+	case "balance_checks":
+		serveV1PoolBalanceChecks(w, r)
+		return
+	case "aggregated":
+		serveV1PoolsAggregated(w, r)
+		return
+	case "top_gainers":
+		serveV1TopGainers(w, r)
+		return
+	case "top_losers":
+		serveV1TopLosers(w, r)
+		return
+	}
+	asset, err := normalizeAsset(asset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
@@ -385,6 +587,52 @@ func poolsAsset(ctx context.Context, asset string, height int64, assetE8DepthPer
 	return m, nil
 }
 
+// ServeV1AllPoolDetails responds with the details of each pool, like the
+// detail endpoint does per pool, with an optional selection of the fields.
+func serveV1AllPoolDetails(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var fields []string
+	if list := strings.Join(r.URL.Query()["fields"], ","); list != "" {
+		fields = strings.Split(list, ",")
+	}
+
+	pools, err := timeseries.Pools(ctx, time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	window := stat.Window{Since: time.Unix(0, 0), Until: timestamp}
+
+	array := make([]map[string]interface{}, len(pools))
+	err = forEachParallel(ctx, len(pools), 10, func(ctx context.Context, i int) error {
+		m, err := poolsAsset(ctx, pools[i], -1, assetE8DepthPerPool, runeE8DepthPerPool, window)
+		if err != nil {
+			return err
+		}
+		if fields != nil {
+			selection := make(map[string]interface{}, len(fields))
+			for _, name := range fields {
+				if v, ok := m[name]; ok {
+					selection[name] = v
+				}
+			}
+			m = selection
+		}
+		array[i] = m
+		return nil
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	respJSON(w, array)
+}
+
 func serveV1Stakers(w http.ResponseWriter, r *http.Request) {
 	addrs, err := timeseries.StakeAddrs(r.Context(), time.Time{})
 	if err != nil {
@@ -396,6 +644,11 @@ func serveV1Stakers(w http.ResponseWriter, r *http.Request) {
 
 func serveV1StakersAddr(w http.ResponseWriter, r *http.Request) {
 	addr := path.Base(r.URL.Path)
+	// the router can't have /v1/stakers/count next to /v1/stakers/:addr
+	if addr == "count" {
+		serveV1StakerCount(w, r)
+		return
+	}
 	pools, err := stat.AllPoolStakesAddrLookup(r.Context(), addr, stat.Window{Until: time.Now()})
 	if err != nil {
 		respError(w, r, err)
@@ -492,20 +745,70 @@ func serveV1Stats(w http.ResponseWriter, r *http.Request) {
 	*/
 }
 
+// ForEachParallel invokes f for each index in [0, n), with up to max
+// routines at a time. The context passed to f is cancelled on the first
+// error, which is also the return.
+func forEachParallel(ctx context.Context, n, max int, f func(ctx context.Context, i int) error) error {
+	g, groupCtx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, max)
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-groupCtx.Done():
+			if err := g.Wait(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+
+		i := i
+		g.Go(func() error {
+			defer func() { <-sem }()
+			return f(groupCtx, i)
+		})
+	}
+	return g.Wait()
+}
+
 const assetListMax = 10
 
 func assetParam(r *http.Request) ([]string, error) {
-	list := strings.Join(r.URL.Query()["asset"], ",")
+	return assetListParam(r, "asset")
+}
+
+// AssetListParam parses the comma separated entries from all occurrences of
+// the query parameter name.
+func assetListParam(r *http.Request, name string) ([]string, error) {
+	list := strings.Join(r.URL.Query()[name], ",")
 	if list == "" {
-		return nil, errors.New("asset query parameter required")
+		return nil, fmt.Errorf("%s query parameter required", name)
 	}
 	assets := strings.SplitN(list, ",", assetListMax+1)
 	if len(assets) > assetListMax {
-		return nil, errors.New("too many entries in asset query parameter")
+		return nil, fmt.Errorf("too many entries in %s query parameter", name)
+	}
+	for i, s := range assets {
+		asset, err := normalizeAsset(s)
+		if err != nil {
+			return nil, err
+		}
+		assets[i] = asset
 	}
 	return assets, nil
 }
 
+var assetPattern = regexp.MustCompile(`^[A-Z0-9]{1,8}\.[A-Z0-9]+(-[A-Z0-9]+)?$`)
+
+// NormalizeAsset returns the canonical (upper case) form of a pool asset
+// identifier in the CHAIN.SYMBOL[-CONTRACT] notation.
+func normalizeAsset(s string) (string, error) {
+	asset := strings.ToUpper(s)
+	if !assetPattern.MatchString(asset) {
+		return "", fmt.Errorf("malformed asset %q, want CHAIN.SYMBOL[-CONTRACT]", s)
+	}
+	return asset, nil
+}
+
 // Return the value of the height url parameter.
 // If height parameter is missing or it's -1 it returns the height of the latest block.
 func heightParam(r *http.Request) (int64, error) {
@@ -529,6 +832,116 @@ func heightParam(r *http.Request) (int64, error) {
 	return height, nil
 }
 
+// WindowParam returns the time period from the from and to URL parameters,
+// which are both optional (in Unix seconds). Missing bounds apply def.
+func windowParam(r *http.Request, def stat.Window) (stat.Window, error) {
+	w := def
+	q := r.URL.Query()
+	if s := q.Get("from"); s != "" {
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return w, fmt.Errorf("couldn't parse from parameter as Unix seconds: %w", err)
+		}
+		w.Since = time.Unix(sec, 0)
+	}
+	if s := q.Get("to"); s != "" {
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return w, fmt.Errorf("couldn't parse to parameter as Unix seconds: %w", err)
+		}
+		w.Until = time.Unix(sec, 0)
+	}
+	if !w.Since.Before(w.Until) {
+		return w, fmt.Errorf("from %d not before to %d", w.Since.Unix(), w.Until.Unix())
+	}
+	return w, nil
+}
+
+// Intervals are the accepted values of the interval URL parameter.
+var intervals = map[string]time.Duration{
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"4h": 4 * time.Hour,
+	"1d": 24 * time.Hour,
+	"1w": 7 * 24 * time.Hour,
+}
+
+// IntervalParam returns the bucket size from the interval URL parameter.
+// If interval parameter is missing it returns def.
+func intervalParam(r *http.Request, def time.Duration) (time.Duration, error) {
+	s := r.URL.Query().Get("interval")
+	if s == "" {
+		return def, nil
+	}
+	d, ok := intervals[s]
+	if !ok {
+		return 0, fmt.Errorf("unknown interval %q", s)
+	}
+	return d, nil
+}
+
+// PageParams returns the limit and offset URL parameters, which are both
+// optional. The limit defaults to def, and it can not exceed max.
+func pageParams(r *http.Request, def, max int) (limit, offset int, err error) {
+	q := r.URL.Query()
+	limit = def
+	if s := q.Get("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil {
+			return 0, 0, fmt.Errorf("couldn't parse limit parameter as int: %w", err)
+		}
+		if limit < 1 || limit > max {
+			return 0, 0, fmt.Errorf("limit parameter is out of bounds [1, %d]: %d", max, limit)
+		}
+	}
+	if s := q.Get("offset"); s != "" {
+		offset, err = strconv.Atoi(s)
+		if err != nil {
+			return 0, 0, fmt.Errorf("couldn't parse offset parameter as int: %w", err)
+		}
+		if offset < 0 {
+			return 0, 0, fmt.Errorf("negative offset parameter: %d", offset)
+		}
+	}
+	return limit, offset, nil
+}
+
+// HeightRangeParams returns the block heights from the fromHeight and toHeight
+// URL parameters, inclusive on both ends. Both are optional. The range ends at
+// the last block by default, and it spans def blocks by default. The range can
+// not exceed max blocks.
+func heightRangeParams(r *http.Request, def, max int64) (fromHeight, toHeight int64, err error) {
+	lastHeight, _, _ := timeseries.LastBlock()
+	q := r.URL.Query()
+	toHeight = lastHeight
+	if s := q.Get("toHeight"); s != "" {
+		toHeight, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("couldn't parse toHeight parameter as int: %w", err)
+		}
+		if toHeight <= 0 || lastHeight < toHeight {
+			return 0, 0, fmt.Errorf("toHeight parameter is out of bounds: %d", toHeight)
+		}
+	}
+	fromHeight = toHeight - def + 1
+	if fromHeight < 1 {
+		fromHeight = 1
+	}
+	if s := q.Get("fromHeight"); s != "" {
+		fromHeight, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("couldn't parse fromHeight parameter as int: %w", err)
+		}
+		if fromHeight <= 0 || toHeight < fromHeight {
+			return 0, 0, fmt.Errorf("fromHeight parameter is out of bounds: %d", fromHeight)
+		}
+	}
+	if toHeight-fromHeight >= max {
+		return 0, 0, fmt.Errorf("height range exceeds %d blocks", max)
+	}
+	return fromHeight, toHeight, nil
+}
+
 func respJSON(w http.ResponseWriter, body interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+
+	"gitlab.com/thorchain/midgard/internal/timeseries"
+	"gitlab.com/thorchain/midgard/internal/timeseries/stat"
+)
+
+func TestNormalizeAsset(t *testing.T) {
+	for _, s := range []string{"bnb.bnb", "BNB.bnb", "BNB.BNB", "Bnb.Bnb"} {
+		got, err := normalizeAsset(s)
+		if err != nil {
+			t.Errorf("%q got error: %s", s, err)
+		} else if got != "BNB.BNB" {
+			t.Errorf("%q got %q, want BNB.BNB", s, got)
+		}
+	}
+
+	got, err := normalizeAsset("bnb.rune-67c")
+	if err != nil || got != "BNB.RUNE-67C" {
+		t.Errorf("got %q and %v, want BNB.RUNE-67C", got, err)
+	}
+
+	for _, s := range []string{"", "NOTASSET", "BNB.", ".BNB", "BNB.BNB.BNB", "BNB.RUNE-", "BNB.RUNE 67C"} {
+		if got, err := normalizeAsset(s); err == nil {
+			t.Errorf("%q got %q, want error", s, got)
+		}
+	}
+}
+
+func TestPoolsAssetMalformed(t *testing.T) {
+	resp := httptest.NewRecorder()
+	Handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/v1/pools/NOTASSET", nil))
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("got HTTP status %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+}
+
+func TestForEachParallel(t *testing.T) {
+	const n = 100
+	f := func(i int) int { return i * i }
+
+	sequential := make([]int, n)
+	for i := range sequential {
+		sequential[i] = f(i)
+	}
+
+	concurrent := make([]int, n)
+	err := forEachParallel(context.Background(), n, 10, func(_ context.Context, i int) error {
+		concurrent[i] = f(i)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(concurrent, sequential) {
+		t.Errorf("got %v, want %v", concurrent, sequential)
+	}
+}
+
+func TestForEachParallelError(t *testing.T) {
+	want := errors.New("test error")
+	got := forEachParallel(context.Background(), 100, 10, func(ctx context.Context, i int) error {
+		switch {
+		case i < 42:
+			return nil
+		case i == 42:
+			return want
+		default:
+			<-ctx.Done()
+			return nil
+		}
+	})
+	if got != want {
+		t.Errorf("got error %v, want %v", got, want)
+	}
+}
+
+func TestPoolsAssetParallel(t *testing.T) {
+	// no transaction, as it can't run queries concurrently
+	db, err := sql.Open("pgx", "user=midgard password=password host=localhost port=5432 sslmode=disable dbname=midgard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Skip("database unavailable:", err)
+	}
+	timeseries.DBQuery = db.QueryContext
+	stat.DBQuery = db.QueryContext
+	if _, _, _, err := timeseries.Setup(); err != nil {
+		t.Fatal("package setup:", err)
+	}
+
+	ctx := context.Background()
+	pools, err := timeseries.Pools(ctx, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	window := stat.Window{Since: time.Unix(0, 0), Until: timestamp}
+
+	sequential := make([]map[string]interface{}, len(pools))
+	for i, pool := range pools {
+		sequential[i], err = poolsAsset(ctx, pool, -1, assetE8DepthPerPool, runeE8DepthPerPool, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	concurrent := make([]map[string]interface{}, len(pools))
+	err = forEachParallel(ctx, len(pools), 10, func(ctx context.Context, i int) error {
+		var err error
+		concurrent[i], err = poolsAsset(ctx, pools[i], -1, assetE8DepthPerPool, runeE8DepthPerPool, window)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(concurrent, sequential) {
+		t.Errorf("got %v", concurrent)
+		t.Errorf("want %v", sequential)
+	}
+}
+
+func TestRunHealthCheck(t *testing.T) {
+	if got := RunHealthCheck("pass", func(context.Context) error { return nil }); got.Err != nil || got.Name != "pass" {
+		t.Errorf("passing check got %+v", got)
+	}
+	fail := errors.New("unavailable")
+	if got := RunHealthCheck("fail", func(context.Context) error { return fail }); got.Err != fail {
+		t.Errorf("failing check got error %v, want %v", got.Err, fail)
+	}
+}
+
+func TestHealthStatusOf(t *testing.T) {
+	fail := errors.New("unavailable")
+	tests := []struct {
+		checks []HealthCheck
+		want   string
+	}{
+		{nil, "down"},
+		{[]HealthCheck{{Name: "database"}}, "ok"},
+		{[]HealthCheck{{Name: "database"}, {Name: "tendermint"}}, "ok"},
+		{[]HealthCheck{{Name: "database"}, {Name: "tendermint", Err: fail}}, "degraded"},
+		{[]HealthCheck{{Name: "database", Err: fail}, {Name: "tendermint"}}, "down"},
+	}
+	for _, test := range tests {
+		if got := healthStatusOf(test.checks); got != test.want {
+			t.Errorf("%+v got %q, want %q", test.checks, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"gitlab.com/thorchain/midgard/internal/timeseries"
+)
+
+// BlockRangeMax is the maximum number of blocks in a height range.
+const blockRangeMax = 1000
+
+func serveV1BlockRange(w http.ResponseWriter, r *http.Request) {
+	limit, _, err := pageParams(r, 50, blockRangeMax)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lastHeight, _, _ := timeseries.LastBlock()
+	from, to, err := blockRangeParams(r.URL.Query(), lastHeight, int64(limit))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	blocks, err := timeseries.BlockRangeSummary(r.Context(), from, to, limit)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	array := make([]map[string]interface{}, len(blocks))
+	for i, b := range blocks {
+		array[i] = map[string]interface{}{
+			"height":       intStr(b.Height),
+			"timestamp":    b.Timestamp.Unix(),
+			"hash":         fmt.Sprintf("%X", b.Hash),
+			"txCount":      intStr(b.TxCount),
+			"swapCount":    intStr(b.SwapCount),
+			"stakeCount":   intStr(b.StakeCount),
+			"unstakeCount": intStr(b.UnstakeCount),
+		}
+	}
+	respJSON(w, array)
+}
+
+// BlockRangeParams gets the from and to heights, inclusive on both ends, with
+// fromHeight and toHeight as alternative names. The range ends at lastHeight by
+// default, and it spans limit blocks by default.
+func blockRangeParams(q url.Values, lastHeight, limit int64) (from, to int64, err error) {
+	height := func(name, alt string, def int64) (int64, error) {
+		s := q.Get(name)
+		if s == "" {
+			name, s = alt, q.Get(alt)
+		}
+		if s == "" {
+			return def, nil
+		}
+		h, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s parameter %q is not a height", name, s)
+		}
+		return h, nil
+	}
+
+	to, err = height("to", "toHeight", lastHeight)
+	if err != nil {
+		return 0, 0, err
+	}
+	from, err = height("from", "fromHeight", to-limit+1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if from > to {
+		return 0, 0, fmt.Errorf("from height %d exceeds to height %d", from, to)
+	}
+	if to-from >= blockRangeMax {
+		return 0, 0, fmt.Errorf("range of %d blocks exceeds the maximum of %d", to-from+1, blockRangeMax)
+	}
+	return from, to, nil
+}
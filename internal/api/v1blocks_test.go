@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestBlockRangeParams(t *testing.T) {
+	tests := []struct {
+		query    string
+		from, to int64
+	}{
+		{"", 951, 1000},
+		{"from=10&to=20", 10, 20},
+		{"fromHeight=10&toHeight=20", 10, 20},
+		{"to=100", 51, 100},
+		{"from=990", 990, 1000},
+	}
+	for _, test := range tests {
+		q, err := url.ParseQuery(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from, to, err := blockRangeParams(q, 1000, 50)
+		if err != nil {
+			t.Errorf("%q got error: %s", test.query, err)
+		} else if from != test.from || to != test.to {
+			t.Errorf("%q got range [%d, %d], want [%d, %d]", test.query, from, to, test.from, test.to)
+		}
+	}
+
+	for _, query := range []string{"from=20&to=10", "from=0&to=1000", "to=x"} {
+		q, err := url.ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := blockRangeParams(q, 1000, 50); err == nil {
+			t.Errorf("%q got no error", query)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"sort"
+	"time"
+
+	"gitlab.com/thorchain/midgard/chain/notinchain"
+	"gitlab.com/thorchain/midgard/internal/timeseries"
+	"gitlab.com/thorchain/midgard/internal/timeseries/stat"
+)
+
+// GasRateUnits are the units in which THORNode reports gas rates per chain.
+var gasRateUnits = map[string]string{
+	"BCH":  "satsperbyte",
+	"BNB":  "bnb",
+	"BTC":  "satsperbyte",
+	"ETH":  "gwei",
+	"LTC":  "satsperbyte",
+	"DOGE": "satsperbyte",
+}
+
+func serveV1GasRates(w http.ResponseWriter, r *http.Request) {
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-24 * time.Hour), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	addrs, err := notinchain.InboundAddressesLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Chain < addrs[j].Chain })
+
+	history := make([][]timeseries.GasRate, len(addrs))
+	err = forEachParallel(r.Context(), len(addrs), 4, func(ctx context.Context, i int) error {
+		var err error
+		history[i], err = timeseries.GasRateHistory(ctx, addrs[i].Chain, window.Since, window.Until)
+		return err
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	chains := make([]interface{}, len(addrs))
+	for i, a := range addrs {
+		changes := make([]interface{}, len(history[i]))
+		for j, g := range history[i] {
+			changes[j] = map[string]interface{}{
+				"asset":   g.Asset,
+				"time":    g.Timestamp.Unix(),
+				"avgFee":  intStr(g.AvgE8),
+				"txCount": intStr(g.TxCount),
+			}
+		}
+		chains[i] = map[string]interface{}{
+			"chain":        a.Chain,
+			"asset":        a.Chain + "." + a.Chain,
+			"gasRate":      intStr(a.GasRate),
+			"gasRateUnits": gasRateUnits[a.Chain],
+			"history":      changes,
+		}
+	}
+
+	respJSON(w, map[string]interface{}{
+		"chains": chains,
+	})
+}
+
+func serveV1PoolGasHistory(w http.ResponseWriter, r *http.Request) {
+	pool, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := stat.PoolGasBucketsLookup(r.Context(), pool, interval, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	fromRune, err := stat.PoolSwapsFromRuneLookup(r.Context(), pool, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	toRune, err := stat.PoolSwapsToRuneLookup(r.Context(), pool, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	feesE8 := fromRune.LiqFeeInRuneE8Total + toRune.LiqFeeInRuneE8Total
+
+	var gasRuneE8, txCount int64
+	history := make([]interface{}, len(buckets))
+	for i, b := range buckets {
+		gasRuneE8 += b.RuneE8
+		txCount += b.TxCount
+		history[i] = map[string]interface{}{
+			"time":             b.Time.Unix(),
+			"gasAsset":         pool,
+			"gasE8":            intStr(b.AssetE8),
+			"gasValueRune":     intStr(b.RuneE8),
+			"transactionCount": intStr(b.TxCount),
+		}
+	}
+
+	m := map[string]interface{}{
+		"totalGasValueRune": intStr(gasRuneE8),
+		"history":           history,
+	}
+	if txCount != 0 {
+		m["averageGasPerTx"] = ratIntStr(big.NewRat(gasRuneE8, txCount))
+	}
+	if feesE8 != 0 {
+		m["gasToFeesRatio"] = ratFloatStr(big.NewRat(gasRuneE8, feesE8))
+	}
+	respJSON(w, m)
+}
@@ -0,0 +1,1680 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/thorchain/midgard/chain/notinchain"
+	"gitlab.com/thorchain/midgard/event"
+	"gitlab.com/thorchain/midgard/internal/timeseries"
+	"gitlab.com/thorchain/midgard/internal/timeseries/stat"
+)
+
+func serveV1TVL(w http.ResponseWriter, r *http.Request) {
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := stat.PoolDepthsBucketsLookup(r.Context(), interval, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	history := make([]map[string]interface{}, len(buckets))
+	for i, b := range buckets {
+		runeTVL, assetTVL := tvlInRune(b.AssetE8PerPool, b.RuneE8PerPool)
+		history[i] = map[string]interface{}{
+			"time":           b.Time.Unix(),
+			"runeTVL":        intStr(runeTVL),
+			"assetTVLInRune": intStr(assetTVL),
+		}
+	}
+
+	runeTVL, assetTVL := tvlInRune(assetE8DepthPerPool, runeE8DepthPerPool)
+	respJSON(w, map[string]interface{}{
+		"currentRuneTVL":        intStr(runeTVL),
+		"currentAssetTVLInRune": intStr(assetTVL),
+		"totalTVLInRune":        intStr(runeTVL + assetTVL),
+		"history":               history,
+	})
+}
+
+// TvlInRune sums the depths of all pools. Asset depths are valued at their
+// respective pool price, which is the RUNE depth divided by the asset depth, so
+// the asset side of each pool is worth exactly its RUNE depth.
+func tvlInRune(assetE8DepthPerPool, runeE8DepthPerPool map[string]int64) (runeE8, assetE8InRune int64) {
+	for pool, runeDepth := range runeE8DepthPerPool {
+		runeE8 += runeDepth
+		if assetE8DepthPerPool[pool] != 0 {
+			assetE8InRune += runeDepth
+		}
+	}
+	return runeE8, assetE8InRune
+}
+
+// PoolValueInRune returns the value of a pool with runeDepth. Both sides are
+// of equal value at the pool price.
+func poolValueInRune(runeDepth int64) int64 {
+	return 2 * runeDepth
+}
+
+// Emission defaults, in case of absence in the Mimir.
+const (
+	defaultEmissionCurve = 6
+	defaultBlocksPerYear = 6311390
+)
+
+func serveV1Emission(w http.ResponseWriter, r *http.Request) {
+	mimir, err := timeseries.Mimir(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	emissionCurve := mimirInt(mimir, "EmissionCurve", defaultEmissionCurve)
+	blocksPerYear := mimirInt(mimir, "BlocksPerYear", defaultBlocksPerYear)
+	if emissionCurve <= 0 || blocksPerYear <= 0 {
+		respError(w, r, fmt.Errorf("unusable emission curve %d with %d blocks per year", emissionCurve, blocksPerYear))
+		return
+	}
+
+	network, err := notinchain.NetworkLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	_, _, timestamp := timeseries.AssetAndRuneDepths()
+	window := stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp}
+	buckets, err := stat.RewardsBucketsLookup(r.Context(), 24*time.Hour, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	history := make([]map[string]interface{}, len(buckets))
+	for i, b := range buckets {
+		history[i] = map[string]interface{}{
+			"time":       b.Time.Unix(),
+			"bondReward": intStr(b.BondE8),
+			"poolReward": intStr(b.PoolE8),
+			"emission":   intStr(b.BondE8 + b.PoolE8),
+		}
+	}
+
+	// reserve ÷ emission curve ÷ blocks per year
+	annualEmission := big.NewRat(network.TotalReserve, emissionCurve)
+	blockReward := new(big.Rat).Quo(annualEmission, big.NewRat(blocksPerYear, 1))
+	dailyEmission := new(big.Rat).Quo(annualEmission, big.NewRat(365, 1))
+	// The reserve reduces with each block reward.
+	halvingBlocks := int64(math.Ln2 * float64(emissionCurve) * float64(blocksPerYear))
+
+	respJSON(w, map[string]interface{}{
+		"blockReward":    ratIntStr(blockReward),
+		"dailyEmission":  ratIntStr(dailyEmission),
+		"annualEmission": ratIntStr(annualEmission),
+		"reserveBalance": intStr(network.TotalReserve),
+		"emissionRate":   ratFloatStr(big.NewRat(1, emissionCurve)),
+		"halvingBlocks":  intStr(halvingBlocks),
+		"history":        history,
+	})
+}
+
+// MimirInt returns the numeric value of a Mimir key, matched case-insensitive.
+// Absent or malformed values default to def.
+func mimirInt(mimir map[string]string, key string, def int64) int64 {
+	for k, v := range mimir {
+		if strings.EqualFold(k, key) {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return def
+			}
+			return n
+		}
+	}
+	return def
+}
+
+// ValidatorsLimit is the maximum number of nodes per status.
+const validatorsLimit = 200
+
+func serveV1Validators(w http.ResponseWriter, r *http.Request) {
+	nodes, err := notinchain.NodeAccountsLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	statusPerNode, err := timeseries.StatusPerNode(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	var active, standby []*notinchain.NodeAccount
+	for _, node := range nodes {
+		status := node.Status
+		if status == "" {
+			status = statusPerNode[node.NodeAddr]
+		}
+		switch status {
+		case "active":
+			active = append(active, node)
+		case "standby":
+			standby = append(standby, node)
+		}
+	}
+
+	byBondDesc := func(nodes []*notinchain.NodeAccount) {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return nodes[i].Bond > nodes[j].Bond
+		})
+	}
+	byBondDesc(active)
+	byBondDesc(standby)
+
+	m := map[string]interface{}{
+		"active":  validatorsJSON(active, "active"),
+		"standby": validatorsJSON(standby, "standby"),
+	}
+	var totalActiveBond, totalStandbyBond int64
+	for _, node := range active {
+		totalActiveBond += node.Bond
+	}
+	for _, node := range standby {
+		totalStandbyBond += node.Bond
+	}
+	m["totalActiveBond"] = intStr(totalActiveBond)
+	m["totalStandbyBond"] = intStr(totalStandbyBond)
+	if len(active) != 0 {
+		m["maxActiveBond"] = intStr(active[0].Bond)
+		m["minActiveBond"] = intStr(active[len(active)-1].Bond)
+	}
+	respJSON(w, m)
+}
+
+// ValidatorsJSON returns the first validatorsLimit nodes.
+func validatorsJSON(nodes []*notinchain.NodeAccount, status string) []interface{} {
+	if len(nodes) > validatorsLimit {
+		nodes = nodes[:validatorsLimit]
+	}
+
+	array := make([]interface{}, len(nodes))
+	for i, node := range nodes {
+		array[i] = map[string]interface{}{
+			"nodeAddr":  node.NodeAddr,
+			"bond":      intStr(node.Bond),
+			"status":    status,
+			"version":   node.Version,
+			"ipAddress": node.IPAddr,
+		}
+	}
+	return array
+}
+
+// FeeScheduleKeys are the swap fee parameters, each with the response field.
+var feeScheduleKeys = []struct {
+	Key, Field string
+}{
+	{"LiquidityFeeBasisPoints", "liquidityFeeBasisPoints"},
+	{"OutboundTransactionFee", "outboundTransactionFee"},
+	{"MinimumSwapAmount", "minimumSwapAmount"},
+}
+
+// Values change at most once per block.
+var feeScheduleCache = timedCache{TTL: 6 * time.Second}
+
+func serveV1FeeSchedule(w http.ResponseWriter, r *http.Request) {
+	m, err := feeScheduleCache.get(func() (interface{}, error) {
+		return feeSchedule(r.Context())
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	respJSON(w, m)
+}
+
+func feeSchedule(ctx context.Context) (map[string]interface{}, error) {
+	mimir, err := timeseries.MimirEntries(ctx, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	constants, err := notinchain.ConstantsLookup()
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{}, len(feeScheduleKeys))
+NextKey:
+	for _, k := range feeScheduleKeys {
+		// Mimir overrides the constants
+		for key, e := range mimir {
+			if strings.EqualFold(key, k.Key) {
+				m[k.Field] = map[string]interface{}{
+					"value":  e.Value,
+					"source": "mimir",
+					"height": intStr(e.Height),
+				}
+				continue NextKey
+			}
+		}
+		for key, v := range constants.Int64Values {
+			if strings.EqualFold(key, k.Key) {
+				m[k.Field] = map[string]interface{}{
+					"value":  intStr(v),
+					"source": "constants",
+				}
+				continue NextKey
+			}
+		}
+	}
+	return m, nil
+}
+
+func serveV1BlockRewardHistory(w http.ResponseWriter, r *http.Request) {
+	_, timestamp, _ := timeseries.LastBlock()
+	until := timestamp.Add(1)
+	window, err := windowParam(r, stat.Window{Since: until.Add(-30 * 24 * time.Hour), Until: until})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := stat.RewardsBucketsLookup(r.Context(), interval, window)
+	if err != nil {
+		if errors.Is(err, stat.ErrNoTable) {
+			http.Error(w, "block rewards are not recorded (yet): "+err.Error(), http.StatusNotImplemented)
+			return
+		}
+		respError(w, r, err)
+		return
+	}
+
+	// The reserve balance is reconstructed backwards from the current one.
+	network, err := notinchain.NetworkLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	var changeAfter int64
+	if window.Until.Before(until) {
+		changeAfter, err = stat.ReserveChangeLookup(r.Context(), stat.Window{Since: window.Until, Until: until})
+		if err != nil {
+			respError(w, r, err)
+			return
+		}
+	}
+	reserve := network.TotalReserve - changeAfter
+
+	history := make([]map[string]interface{}, len(buckets))
+	for i := len(buckets) - 1; i >= 0; i-- {
+		b := buckets[i]
+		history[i] = map[string]interface{}{
+			"time":        b.Time.Unix(),
+			"totalReward": intStr(b.BondE8 + b.PoolE8),
+			"bondReward":  intStr(b.BondE8),
+			"stakeReward": intStr(b.PoolE8),
+			"reserve":     intStr(reserve),
+		}
+		reserve -= b.ReserveInE8 - b.BondE8 - b.PoolE8
+	}
+	respJSON(w, history)
+}
+
+// SolvencyAlertThreshold is the maximum discrepancy ratio tolerated.
+var solvencyAlertThreshold = big.NewRat(1, 1000)
+
+func serveV1Solvency(w http.ResponseWriter, r *http.Request) {
+	vaults, err := notinchain.AsgardVaultsLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	type vaultAddr struct {
+		Chain, Addr string
+		Reported    map[string]int64
+	}
+	var addrs []vaultAddr
+	for _, v := range vaults {
+		for _, a := range v.Addresses {
+			reported := make(map[string]int64)
+			for _, c := range v.Coins {
+				if strings.HasPrefix(c.Asset, a.Chain+".") {
+					reported[c.Asset] += c.Amount
+				}
+			}
+			addrs = append(addrs, vaultAddr{a.Chain, a.Address, reported})
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		if addrs[i].Chain != addrs[j].Chain {
+			return addrs[i].Chain < addrs[j].Chain
+		}
+		return addrs[i].Addr < addrs[j].Addr
+	})
+
+	tracked := make([]map[string]int64, len(addrs))
+	err = forEachParallel(r.Context(), len(addrs), 4, func(ctx context.Context, i int) error {
+		var err error
+		tracked[i], err = timeseries.VaultBalance(ctx, addrs[i].Chain, addrs[i].Addr)
+		return err
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	solvent := true
+	chains := make([]interface{}, len(addrs))
+	for i, a := range addrs {
+		assets := make(map[string]struct{})
+		for asset := range a.Reported {
+			assets[asset] = struct{}{}
+		}
+		for asset := range tracked[i] {
+			assets[asset] = struct{}{}
+		}
+		sortedAssets := make([]string, 0, len(assets))
+		for asset := range assets {
+			sortedAssets = append(sortedAssets, asset)
+		}
+		sort.Strings(sortedAssets)
+
+		balances := make([]interface{}, len(sortedAssets))
+		for j, asset := range sortedAssets {
+			discrepancy := balanceDiscrepancy(tracked[i][asset], a.Reported[asset])
+			alert := discrepancy.Cmp(solvencyAlertThreshold) > 0
+			if alert {
+				solvent = false
+			}
+			balances[j] = map[string]interface{}{
+				"asset":           asset,
+				"midgardBalance":  intStr(tracked[i][asset]),
+				"reportedBalance": intStr(a.Reported[asset]),
+				"discrepancy":     ratFloatStr(discrepancy),
+				"alert":           alert,
+			}
+		}
+		chains[i] = map[string]interface{}{
+			"chain":        a.Chain,
+			"vaultAddress": a.Addr,
+			"balances":     balances,
+		}
+	}
+
+	respJSON(w, map[string]interface{}{
+		"solvent": solvent,
+		"chains":  chains,
+	})
+}
+
+// BalanceDiscrepancy returns the difference relative to the reported balance.
+func balanceDiscrepancy(tracked, reported int64) *big.Rat {
+	diff := tracked - reported
+	if diff < 0 {
+		diff = -diff
+	}
+	switch {
+	case diff == 0:
+		return new(big.Rat)
+	case reported == 0:
+		return big.NewRat(1, 1)
+	}
+	return big.NewRat(diff, reported)
+}
+
+// Vaults change on churn only.
+var asgardVaultsCache = timedCache{TTL: 60 * time.Second}
+
+type asgardVaults struct {
+	LastChurnHeight int64
+	Vaults          []*notinchain.Vault
+}
+
+func serveV1AsgardVaults(w http.ResponseWriter, r *http.Request) {
+	v, err := asgardVaultsCache.get(func() (interface{}, error) {
+		return asgardVaultsLookup(r.Context())
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	vaults := v.(*asgardVaults)
+
+	respJSON(w, map[string]interface{}{
+		"lastChurnHeight": intStr(vaults.LastChurnHeight),
+		"vaults":          asgardVaultsJSON(vaults.Vaults, ""),
+	})
+}
+
+func serveV1AsgardVaultsChain(w http.ResponseWriter, r *http.Request) {
+	chain := strings.ToUpper(path.Base(r.URL.Path))
+
+	v, err := asgardVaultsCache.get(func() (interface{}, error) {
+		return asgardVaultsLookup(r.Context())
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	vaults := v.(*asgardVaults)
+
+	array := asgardVaultsJSON(vaults.Vaults, chain)
+	if len(array) == 0 {
+		http.Error(w, fmt.Sprintf("no asgard vault for chain %q", chain), http.StatusNotFound)
+		return
+	}
+	respJSON(w, map[string]interface{}{
+		"lastChurnHeight": intStr(vaults.LastChurnHeight),
+		"vaults":          array,
+	})
+}
+
+func asgardVaultsLookup(ctx context.Context) (*asgardVaults, error) {
+	vaults, err := notinchain.AsgardVaultsLookup()
+	if err != nil {
+		return nil, err
+	}
+	height, err := timeseries.LastChurnHeight(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &asgardVaults{LastChurnHeight: height, Vaults: vaults}, nil
+}
+
+// AsgardVaultsJSON lists an entry per vault address, optionally limited to
+// chain.
+func asgardVaultsJSON(vaults []*notinchain.Vault, chain string) []interface{} {
+	array := make([]interface{}, 0, len(vaults))
+	for _, v := range vaults {
+		for _, a := range v.Addresses {
+			if chain != "" && a.Chain != chain {
+				continue
+			}
+			coins := make([]interface{}, 0, len(v.Coins))
+			for _, c := range v.Coins {
+				if strings.HasPrefix(c.Asset, a.Chain+".") {
+					coins = append(coins, map[string]interface{}{
+						"asset":  c.Asset,
+						"amount": intStr(c.Amount),
+					})
+				}
+			}
+			array = append(array, map[string]interface{}{
+				"chain":   a.Chain,
+				"address": a.Address,
+				"pubKey":  v.PubKey,
+				"coins":   coins,
+				"status":  v.Status,
+			})
+		}
+	}
+	return array
+}
+
+// MaxRuneSupplyE8 is the fixed amount of RUNE in existence.
+const maxRuneSupplyE8 = 500_000_000 * 100_000_000
+
+func serveV1CirculatingSupply(w http.ResponseWriter, r *http.Request) {
+	nodes, err := notinchain.NodeAccountsLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	var totalBonded int64
+	for _, node := range nodes {
+		switch node.Status {
+		case "active", "standby":
+			totalBonded += node.Bond
+		}
+	}
+
+	_, timestamp, _ := timeseries.LastBlock()
+	reserve, err := stat.ReserveChangeLookup(r.Context(), stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)})
+	if err != nil {
+		if errors.Is(err, stat.ErrNoTable) {
+			http.Error(w, "reserve changes are not recorded (yet): "+err.Error(), http.StatusNotImplemented)
+			return
+		}
+		respError(w, r, err)
+		return
+	}
+
+	_, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	var totalPooled int64
+	for _, depth := range runeE8DepthPerPool {
+		totalPooled += depth
+	}
+
+	respJSON(w, map[string]interface{}{
+		"maxSupply":         intStr(maxRuneSupplyE8),
+		"totalBonded":       intStr(totalBonded),
+		"totalPooled":       intStr(totalPooled),
+		"reserveBalance":    intStr(reserve),
+		"circulatingSupply": intStr(maxRuneSupplyE8 - totalBonded - reserve),
+	})
+}
+
+// ObservedHeightChanges has the first sighting of the last observed height per
+// chain. Observations are not available as events, so the sightings only go
+// back to the first request since launch.
+var observedHeightChanges = struct {
+	sync.Mutex
+	m map[string]observedHeight
+}{m: make(map[string]observedHeight)}
+
+type observedHeight struct {
+	Height int64
+	Since  time.Time
+}
+
+// ObservedSince returns the time at which height was first seen for chain.
+func observedSince(chain string, height int64, now time.Time) time.Time {
+	observedHeightChanges.Lock()
+	defer observedHeightChanges.Unlock()
+
+	last, ok := observedHeightChanges.m[chain]
+	if !ok || last.Height != height {
+		last = observedHeight{Height: height, Since: now}
+		observedHeightChanges.m[chain] = last
+	}
+	return last.Since
+}
+
+func serveV1LastChainHeights(w http.ResponseWriter, r *http.Request) {
+	heights, err := notinchain.LastChainHeightsLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i].Chain < heights[j].Chain })
+
+	now := time.Now()
+	chains := make([]interface{}, len(heights))
+	for i, h := range heights {
+		chains[i] = map[string]interface{}{
+			"chain":              h.Chain,
+			"lastObservedHeight": intStr(h.LastObservedIn),
+			"lastSignedHeight":   intStr(h.LastSignedOut),
+			"thorchainHeight":    intStr(h.Thorchain),
+			"observedSince":      observedSince(h.Chain, h.LastObservedIn, now).Unix(),
+		}
+	}
+	respJSON(w, map[string]interface{}{
+		"chains": chains,
+	})
+}
+
+func serveV1NetworkVersion(w http.ResponseWriter, r *http.Request) {
+	nodes, err := notinchain.NodeAccountsLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	type versionCount struct {
+		Version               string
+		ActiveNum, StandbyNum int
+	}
+	counts := make(map[string]*versionCount)
+	var activeNum int
+	for _, node := range nodes {
+		c, ok := counts[node.Version]
+		if !ok {
+			c = &versionCount{Version: node.Version}
+			counts[node.Version] = c
+		}
+		switch node.Status {
+		case "active":
+			c.ActiveNum++
+			activeNum++
+		case "standby":
+			c.StandbyNum++
+		}
+	}
+	sorted := make([]*versionCount, 0, len(counts))
+	for _, c := range counts {
+		sorted = append(sorted, c)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return compareVersions(sorted[i].Version, sorted[j].Version) > 0
+	})
+
+	// latest version with active nodes
+	var current *versionCount
+	for _, c := range sorted {
+		if c.ActiveNum != 0 {
+			current = c
+			break
+		}
+	}
+
+	versions := make([]interface{}, len(sorted))
+	for i, c := range sorted {
+		versions[i] = map[string]interface{}{
+			"version":      c.Version,
+			"activeCount":  intStr(int64(c.ActiveNum)),
+			"standbyCount": intStr(int64(c.StandbyNum)),
+		}
+	}
+	m := map[string]interface{}{
+		"upgradeNeeded": false,
+		"versions":      versions,
+	}
+	if current != nil {
+		m["current"] = current.Version
+		// consensus needs 2/3 of the active nodes
+		m["upgradeNeeded"] = current.ActiveNum*3 < activeNum*2
+	}
+	respJSON(w, m)
+}
+
+// CompareVersions orders semantic versions numerically, with an optional "v"
+// prefix. The result is 0 if a == b, -1 if a < b, and +1 if a > b.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var an, bn int64
+		if i < len(as) {
+			an, _ = strconv.ParseInt(as[i], 10, 64)
+		}
+		if i < len(bs) {
+			bn, _ = strconv.ParseInt(bs[i], 10, 64)
+		}
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func serveV1Treasury(w http.ResponseWriter, r *http.Request) {
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+
+	reserve, err := stat.ReserveBalance(r.Context(), timestamp)
+	if err != nil {
+		if errors.Is(err, stat.ErrNoTable) {
+			http.Error(w, "reserve changes are not recorded (yet): "+err.Error(), http.StatusNotImplemented)
+			return
+		}
+		respError(w, r, err)
+		return
+	}
+
+	nodes, err := notinchain.NodeAccountsLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	var bonded int64
+	for _, node := range nodes {
+		if node.Status == "active" {
+			bonded += node.Bond
+		}
+	}
+
+	vaults, err := notinchain.AsgardVaultsLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	amountPerAsset := make(map[string]int64)
+	for _, v := range vaults {
+		for _, c := range v.Coins {
+			amountPerAsset[c.Asset] += c.Amount
+		}
+	}
+	assets := make([]string, 0, len(amountPerAsset))
+	for asset := range amountPerAsset {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+
+	totalValue := big.NewRat(reserve+bonded, 1)
+	balances := make([]interface{}, len(assets))
+	for i, asset := range assets {
+		amount := amountPerAsset[asset]
+		m := map[string]interface{}{
+			"asset":  asset,
+			"amount": intStr(amount),
+		}
+		// value at the pool price
+		var value *big.Rat
+		if event.IsRune([]byte(asset)) {
+			value = big.NewRat(amount, 1)
+		} else if assetDepth := assetE8DepthPerPool[asset]; assetDepth != 0 {
+			value = big.NewRat(amount, 1)
+			value.Mul(value, big.NewRat(runeE8DepthPerPool[asset], assetDepth))
+		}
+		if value != nil {
+			m["valueRune"] = ratIntStr(value)
+			totalValue.Add(totalValue, value)
+		}
+		balances[i] = m
+	}
+
+	resp := map[string]interface{}{
+		"reserveBalance": intStr(reserve),
+		"asgardBalances": balances,
+		"bondedRune":     intStr(bonded),
+		"totalValueRune": ratIntStr(totalValue),
+	}
+
+	if r.URL.Query().Get("history") == "true" {
+		// daily over the last 90 days
+		window := stat.Window{Since: timestamp.Add(-90 * 24 * time.Hour).Truncate(24 * time.Hour), Until: timestamp}
+		balance, err := stat.ReserveBalance(r.Context(), window.Since.Add(-1))
+		if err != nil {
+			respError(w, r, err)
+			return
+		}
+		buckets, err := stat.RewardsBucketsLookup(r.Context(), 24*time.Hour, window)
+		if err != nil {
+			respError(w, r, err)
+			return
+		}
+		history := make([]interface{}, len(buckets))
+		for i, b := range buckets {
+			balance += b.ReserveInE8 - b.BondE8 - b.PoolE8
+			history[i] = map[string]interface{}{
+				"time":           b.Time.Unix(),
+				"reserveBalance": intStr(balance),
+			}
+		}
+		resp["history"] = history
+	}
+
+	respJSON(w, resp)
+}
+
+// SecureBondRatio is the minimum of bonded RUNE per pooled RUNE.
+var secureBondRatio = big.NewRat(2, 1)
+
+func serveV1SecurityRatio(w http.ResponseWriter, r *http.Request) {
+	nodes, err := notinchain.NodeAccountsLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	var bonded int64
+	for _, node := range nodes {
+		if node.Status == "active" {
+			bonded += node.Bond
+		}
+	}
+	_, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	var pooled int64
+	for _, depth := range runeE8DepthPerPool {
+		pooled += depth
+	}
+
+	// daily over the last 30 days
+	window := stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp}
+	bonds, err := stat.NetBondBucketsLookup(r.Context(), 24*time.Hour, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	depths, err := stat.PoolDepthsBucketsLookup(r.Context(), 24*time.Hour, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	history := make([]interface{}, len(bonds))
+	for i, b := range bonds {
+		var pooled int64
+		if i < len(depths) {
+			for _, depth := range depths[i].RuneE8PerPool {
+				pooled += depth
+			}
+		}
+		m := securityRatioJSON(b.E8, pooled)
+		m["time"] = b.Time.Unix()
+		history[i] = m
+	}
+
+	m := securityRatioJSON(bonded, pooled)
+	m["targetRatio"] = ratFloatStr(secureBondRatio)
+	m["history"] = history
+	respJSON(w, m)
+}
+
+func securityRatioJSON(bondedE8, pooledE8 int64) map[string]interface{} {
+	m := map[string]interface{}{
+		"totalBondedRune": intStr(bondedE8),
+		"totalPooledRune": intStr(pooledE8),
+		"isSecure":        true,
+	}
+	if pooledE8 != 0 {
+		ratio := big.NewRat(bondedE8, pooledE8)
+		m["securityRatio"] = ratFloatStr(ratio)
+		m["isSecure"] = ratio.Cmp(secureBondRatio) >= 0
+	}
+	return m
+}
+
+var nodeAccountsCache = timedCache{TTL: 30 * time.Second}
+
+func serveV1UpgradeVotes(w http.ResponseWriter, r *http.Request) {
+	v, err := nodeAccountsCache.get(func() (interface{}, error) {
+		return notinchain.NodeAccountsLookup()
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	votes := countUpgradeVotes(v.([]*notinchain.NodeAccount))
+
+	m := map[string]interface{}{
+		"currentVersion":      votes.Current,
+		"proposedVersion":     votes.Proposed,
+		"votesNeeded":         intStr(int64(votes.Needed)),
+		"votesReceived":       intStr(int64(votes.Received)),
+		"activeNodesUpgraded": intStr(int64(votes.Received)),
+		"activeNodesTotal":    intStr(int64(votes.ActiveNum)),
+	}
+	if votes.Needed != 0 {
+		m["votingProgress"] = ratFloatStr(big.NewRat(int64(votes.Received), int64(votes.Needed)))
+	}
+	respJSON(w, m)
+}
+
+// UpgradeVotes is the adoption of the latest version by the active nodes.
+type upgradeVotes struct {
+	Current   string // second highest version, if any
+	Proposed  string // highest version
+	Needed    int    // two thirds of the active nodes
+	Received  int    // active nodes on the proposed version
+	ActiveNum int
+}
+
+func countUpgradeVotes(nodes []*notinchain.NodeAccount) upgradeVotes {
+	var votes upgradeVotes
+	numPerVersion := make(map[string]int)
+	for _, node := range nodes {
+		if node.Status == "active" {
+			numPerVersion[node.Version]++
+			votes.ActiveNum++
+		}
+	}
+	versions := make([]string, 0, len(numPerVersion))
+	for v := range numPerVersion {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) > 0
+	})
+
+	switch len(versions) {
+	case 0:
+		break
+	case 1:
+		votes.Current = versions[0]
+		votes.Proposed = versions[0]
+	default:
+		votes.Current = versions[1]
+		votes.Proposed = versions[0]
+	}
+	votes.Received = numPerVersion[votes.Proposed]
+	votes.Needed = (votes.ActiveNum*2 + 2) / 3
+	return votes
+}
+
+// Pool activation defaults, in case of absence in the Mimir.
+const (
+	defaultNewPoolCycle     = 50000
+	defaultMinimumPoolDepth = 10000 * 1e8
+)
+
+func serveV1PoolActivationQueue(w http.ResponseWriter, r *http.Request) {
+	mimir, err := timeseries.Mimir(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	cycle := mimirInt(mimir, "NewPoolCycle", defaultNewPoolCycle)
+	minimumDepth := mimirInt(mimir, "MinimumPoolDepth", defaultMinimumPoolDepth)
+	if cycle <= 0 || minimumDepth <= 0 {
+		respError(w, r, fmt.Errorf("unusable new pool cycle %d with minimum pool depth %d", cycle, minimumDepth))
+		return
+	}
+
+	lastActivation, err := timeseries.LastPoolActivationHeight(r.Context())
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	queued, err := timeseries.PoolsByStatus(r.Context(), "bootstrap", time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	height, _, _ := timeseries.LastBlock()
+	next := nextCycleHeight(lastActivation, cycle, height)
+
+	_, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	// deepest first, as that is the order of activation
+	sort.SliceStable(queued, func(i, j int) bool {
+		return runeE8DepthPerPool[queued[i].Pool] > runeE8DepthPerPool[queued[j].Pool]
+	})
+	pools := make([]map[string]interface{}, len(queued))
+	for i, c := range queued {
+		runeDepth := runeE8DepthPerPool[c.Pool]
+		pools[i] = map[string]interface{}{
+			"asset":           c.Pool,
+			"bootstrapSince":  c.Timestamp.Unix(),
+			"runeDepth":       intStr(runeDepth),
+			"activationScore": ratFloatStr(big.NewRat(runeDepth, minimumDepth)),
+			"eligible":        runeDepth >= minimumDepth,
+		}
+	}
+
+	respJSON(w, map[string]interface{}{
+		"nextActivationHeight":    intStr(next),
+		"blocksUntilActivation":   intStr(next - height),
+		"poolActivationCountdown": next - height,
+		"queuedPools":             pools,
+	})
+}
+
+// NextCycleHeight returns the first height after current at which a cycle
+// ends, such as pool activation or churn. Cycles count from last.
+func nextCycleHeight(last, cycle, current int64) int64 {
+	if current < last {
+		return last + cycle
+	}
+	return last + ((current-last)/cycle+1)*cycle
+}
+
+// ServeV1PoolBonds serves the active bond allocated to each pool, pro rata to
+// the pool's share of the total value locked. A pool with less than twice its
+// value in bond is under-secured.
+func serveV1PoolBonds(w http.ResponseWriter, r *http.Request) {
+	v, err := nodeAccountsCache.get(func() (interface{}, error) {
+		return notinchain.NodeAccountsLookup()
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	var activeBond int64
+	for _, node := range v.([]*notinchain.NodeAccount) {
+		if node.Status == "active" {
+			activeBond += node.Bond
+		}
+	}
+
+	_, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	pools := make([]string, 0, len(runeE8DepthPerPool))
+	var totalTVL int64
+	for pool, runeDepth := range runeE8DepthPerPool {
+		if runeDepth <= 0 {
+			continue
+		}
+		pools = append(pools, pool)
+		totalTVL += poolValueInRune(runeDepth)
+	}
+	// largest first
+	sort.Slice(pools, func(i, j int) bool {
+		return runeE8DepthPerPool[pools[i]] > runeE8DepthPerPool[pools[j]]
+	})
+
+	array := make([]interface{}, len(pools))
+	for i, pool := range pools {
+		tvl := 2 * runeE8DepthPerPool[pool]
+		// total active bond × (pool TVL ÷ total TVL)
+		implied := big.NewRat(activeBond, 1)
+		implied.Mul(implied, big.NewRat(tvl, totalTVL))
+		ratio := new(big.Rat).Quo(implied, big.NewRat(tvl, 1))
+		array[i] = map[string]interface{}{
+			"asset":           pool,
+			"tvlRune":         intStr(tvl),
+			"impliedBondRune": ratIntStr(implied),
+			"bondToTVLRatio":  ratFloatStr(ratio),
+			"isSecure":        ratio.Cmp(secureBondRatio) >= 0,
+		}
+	}
+	respJSON(w, array)
+}
+
+// ServeV1ActiveUsers serves exact distinct address counts. TimescaleDB has no
+// HyperLogLog without the toolkit extension, and the windows are bounded by
+// the bucket limit.
+func serveV1ActiveUsers(w http.ResponseWriter, r *http.Request) {
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := stat.ActiveUsersBucketsLookup(r.Context(), interval, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	array := make([]map[string]interface{}, len(buckets))
+	for i, b := range buckets {
+		array[i] = map[string]interface{}{
+			"time":            b.Time.Unix(),
+			"uniqueSwappers":  intStr(b.Swappers),
+			"uniqueStakers":   intStr(b.Stakers),
+			"uniqueUnstakers": intStr(b.Unstakers),
+			"totalUnique":     intStr(b.Total),
+		}
+	}
+	respJSON(w, array)
+}
+
+// UpgradeHistoryKeys are the mimir names reported as protocol upgrades.
+var UpgradeHistoryKeys = []string{
+	"EMISSIONCURVE",
+	"FULLILPPROTECTION",
+	"MINIMUMBONDINRUNE",
+}
+
+func serveV1UpgradeHistory(w http.ResponseWriter, r *http.Request) {
+	keys := make([]string, len(UpgradeHistoryKeys))
+	for i, k := range UpgradeHistoryKeys {
+		keys[i] = strings.ToUpper(k)
+	}
+	changes, err := timeseries.MimirChanges(r.Context(), keys)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	array := make([]interface{}, len(changes))
+	for i, c := range changes {
+		var oldValue interface{}
+		if c.OldValue != "" {
+			oldValue = c.OldValue
+		}
+		array[i] = map[string]interface{}{
+			"height":    intStr(c.Height),
+			"timestamp": c.Timestamp.Unix(),
+			"key":       c.Key,
+			"oldValue":  oldValue,
+			"newValue":  c.NewValue,
+		}
+	}
+	respJSON(w, array)
+}
+
+func serveV1GasSpending(w http.ResponseWriter, r *http.Request) {
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	spending, err := stat.GasSpendingLookup(r.Context(), window, interval)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	var total int64
+	array := make([]interface{}, len(spending))
+	for i, g := range spending {
+		total += g.RuneE8
+		array[i] = map[string]interface{}{
+			"time":         g.Time.Unix(),
+			"chain":        g.Chain,
+			"gasAsset":     g.GasAsset,
+			"gasAmount":    intStr(g.AssetE8),
+			"gasValueRune": intStr(g.RuneE8),
+			"txCount":      intStr(g.TxCount),
+		}
+	}
+	respJSON(w, map[string]interface{}{
+		"buckets":           array,
+		"totalGasValueRune": intStr(total),
+	})
+}
+
+// Churn defaults, in case of absence in both the Mimir and the constants.
+const (
+	defaultChurnInterval     = 43200
+	defaultMinimumBondInRune = 1_000_000 * 100_000_000
+)
+
+// ChurnBlockTimeSample is the number of recent blocks for the block time.
+const churnBlockTimeSample = 1000
+
+func serveV1ChurnSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	mimir, err := timeseries.Mimir(ctx, time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	constants, err := notinchain.ConstantsLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	interval := mimirInt(mimir, "ChurnInterval", constantInt(constants, "ChurnInterval", defaultChurnInterval))
+	minimumBond := mimirInt(mimir, "MinimumBondInRune", constantInt(constants, "MinimumBondInRune", defaultMinimumBondInRune))
+	if interval <= 0 {
+		respError(w, r, fmt.Errorf("unusable churn interval %d", interval))
+		return
+	}
+
+	lastChurn, err := timeseries.LastChurnHeight(ctx)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	blockTime, err := timeseries.AverageBlockTime(ctx, churnBlockTimeSample)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	v, err := nodeAccountsCache.get(func() (interface{}, error) {
+		return notinchain.NodeAccountsLookup()
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	leave, join := churnEligibility(v.([]*notinchain.NodeAccount), minimumBond)
+
+	height, timestamp, _ := timeseries.LastBlock()
+	next := nextCycleHeight(lastChurn, interval, height)
+	m := map[string]interface{}{
+		"nextChurnHeight":      intStr(next),
+		"churnInterval":        intStr(interval),
+		"nodesEligibleToLeave": intStr(int64(leave)),
+		"nodesEligibleToJoin":  intStr(int64(join)),
+	}
+	if blockTime > 0 {
+		churnTime := time.Duration(interval) * blockTime
+		m["nextChurnEstimatedTime"] = timestamp.Add(time.Duration(next-height) * blockTime).Unix()
+		m["averageChurnInterval"] = intStr(int64(churnTime / time.Second))
+		m["churnsPerYear"] = strconv.FormatFloat(float64(365*24*time.Hour)/float64(churnTime), 'f', -1, 64)
+	}
+	respJSON(w, m)
+}
+
+// ConstantInt returns the constant value of key, or def when absent.
+func constantInt(constants *notinchain.Constants, key string, def int64) int64 {
+	for k, v := range constants.Int64Values {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return def
+}
+
+// ChurnEligibility counts the active nodes with a bond below the minimum,
+// which leave on churn, and the standby nodes with a bond of at least the
+// minimum, which may join.
+func churnEligibility(nodes []*notinchain.NodeAccount, minimumBond int64) (leave, join int) {
+	for _, node := range nodes {
+		switch node.Status {
+		case "active":
+			if node.Bond < minimumBond {
+				leave++
+			}
+		case "standby":
+			if node.Bond >= minimumBond {
+				join++
+			}
+		}
+	}
+	return leave, join
+}
+
+// The distribution is expensive to calculate and it changes slowly.
+var wealthDistributionCache = timedCache{TTL: time.Hour}
+
+func serveV1WealthDistribution(w http.ResponseWriter, r *http.Request) {
+	v, err := wealthDistributionCache.get(func() (interface{}, error) {
+		return stat.RuneStakingDistribution(r.Context())
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	d := v.(*stat.WealthDistribution)
+
+	deciles := make([]interface{}, len(d.Deciles))
+	for i, b := range d.Deciles {
+		m := map[string]interface{}{
+			"percentile":   fmt.Sprintf("%d-%d%%", i*10, (i+1)*10),
+			"minRune":      intStr(b.MinE8),
+			"maxRune":      intStr(b.MaxE8),
+			"totalRune":    intStr(b.TotalE8),
+			"addressCount": intStr(b.Count),
+		}
+		if d.TotalE8 != 0 {
+			m["shareOfTotal"] = ratFloatStr(big.NewRat(b.TotalE8, d.TotalE8))
+		}
+		deciles[i] = m
+	}
+	respJSON(w, map[string]interface{}{
+		"buckets":         deciles,
+		"giniCoefficient": strconv.FormatFloat(d.Gini, 'f', -1, 64),
+	})
+}
+
+func serveV1NodePerformance(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "active" && status != "standby" {
+		http.Error(w, fmt.Sprintf("unknown status %q, want active or standby", status), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v, err := nodeAccountsCache.get(func() (interface{}, error) {
+		return notinchain.NodeAccountsLookup()
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	nodes := v.([]*notinchain.NodeAccount)
+	if status != "" {
+		var selection []*notinchain.NodeAccount
+		for _, node := range nodes {
+			if node.Status == status {
+				selection = append(selection, node)
+			}
+		}
+		nodes = selection
+	}
+
+	durations, err := stat.NodeActiveDurations(r.Context(), window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	respJSON(w, nodePerformance(nodes, durations, window.Until.Sub(window.Since)))
+}
+
+// NodePerformance ranks nodes by their performance score, as the uptime
+// percentage discounted by the slash points relative to the worst node. Block
+// production is not tracked, as validator rewards are not emitted per node.
+func nodePerformance(nodes []*notinchain.NodeAccount, active map[string]time.Duration, period time.Duration) []interface{} {
+	var maxSlashPoints int64
+	for _, node := range nodes {
+		if node.SlashPoints > maxSlashPoints {
+			maxSlashPoints = node.SlashPoints
+		}
+	}
+
+	type entry struct {
+		node   *notinchain.NodeAccount
+		uptime float64
+		score  float64
+	}
+	entries := make([]entry, len(nodes))
+	for i, node := range nodes {
+		e := entry{node: node}
+		if period > 0 {
+			e.uptime = float64(active[node.NodeAddr]) / float64(period) * 100
+		}
+		e.score = e.uptime
+		if maxSlashPoints > 0 {
+			e.score *= 1 - float64(node.SlashPoints)/float64(maxSlashPoints)
+		}
+		entries[i] = e
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].score > entries[j].score
+	})
+
+	array := make([]interface{}, len(entries))
+	for i, e := range entries {
+		array[i] = map[string]interface{}{
+			"nodeAddr":         e.node.NodeAddr,
+			"status":           e.node.Status,
+			"uptimePercent":    strconv.FormatFloat(e.uptime, 'f', -1, 64),
+			"slashPoints":      intStr(e.node.SlashPoints),
+			"performanceScore": strconv.FormatFloat(e.score, 'f', -1, 64),
+		}
+	}
+	return array
+}
+
+func serveV1NodeBondHistory(w http.ResponseWriter, r *http.Request) {
+	node := path.Base(r.URL.Path)
+
+	changes, err := timeseries.BondHistoryLookup(r.Context(), node, time.Unix(0, 0), time.Now())
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	if len(changes) == 0 {
+		statuses, err := timeseries.StatusPerNode(r.Context(), time.Time{})
+		if err != nil {
+			respError(w, r, err)
+			return
+		}
+		if _, ok := statuses[node]; !ok {
+			http.Error(w, fmt.Sprintf("node %q not found", node), http.StatusNotFound)
+			return
+		}
+	}
+
+	history, bondedE8, unbondedE8 := bondHistory(changes)
+	respJSON(w, map[string]interface{}{
+		"history":       history,
+		"totalBonded":   intStr(bondedE8),
+		"totalUnbonded": intStr(unbondedE8),
+		"netBond":       intStr(bondedE8 - unbondedE8),
+	})
+}
+
+// BondHistory returns the changes with a running total, together with the sum
+// of bonds paid and the sum of bonds returned or lost to costs.
+func bondHistory(changes []timeseries.BondChange) (history []interface{}, bondedE8, unbondedE8 int64) {
+	history = make([]interface{}, len(changes))
+	var cumulativeE8 int64
+	for i, c := range changes {
+		switch c.Type {
+		case "bond_returned", "bond_cost":
+			unbondedE8 += c.E8
+			cumulativeE8 -= c.E8
+		default:
+			bondedE8 += c.E8
+			cumulativeE8 += c.E8
+		}
+		history[i] = map[string]interface{}{
+			"height":         intStr(c.Height),
+			"timestamp":      c.Timestamp.Unix(),
+			"type":           c.Type,
+			"amount":         intStr(c.E8),
+			"cumulativeBond": intStr(cumulativeE8),
+		}
+	}
+	return history, bondedE8, unbondedE8
+}
+
+// Portion of the reserve available for impermanent loss protection, in basis
+// points, in case of absence in the Mimir.
+const defaultILPReserveFractionBP = 10000
+
+var insuranceFundCache = timedCache{TTL: 10 * time.Minute}
+
+func serveV1InsuranceFund(w http.ResponseWriter, r *http.Request) {
+	v, err := insuranceFundCache.get(func() (interface{}, error) {
+		return insuranceFund(r.Context())
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	respJSON(w, v)
+}
+
+// InsuranceFund compares the impermanent loss protection owed to all
+// liquidity providers, would they leave now, with the reserve set aside.
+func insuranceFund(ctx context.Context) (map[string]interface{}, error) {
+	mimir, err := timeseries.Mimir(ctx, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	fullDays := mimirInt(mimir, "FullILPProtection", defaultFullILPProtection)
+	fractionBP := mimirInt(mimir, "ILPReserveFraction", defaultILPReserveFractionBP)
+
+	network, err := notinchain.NetworkLookup()
+	if err != nil {
+		return nil, err
+	}
+
+	pools, err := timeseries.Pools(ctx, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	obligations := make([]*big.Rat, len(pools))
+	err = forEachParallel(ctx, len(pools), 4, func(ctx context.Context, i int) error {
+		var err error
+		asset := pools[i]
+		obligations[i], err = poolILPObligation(ctx, asset, fullDays, assetE8DepthPerPool[asset], runeE8DepthPerPool[asset], timestamp)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	obligation := new(big.Rat)
+	for _, o := range obligations {
+		obligation.Add(obligation, o)
+	}
+	capacity := new(big.Rat).Mul(big.NewRat(network.TotalReserve, 1), big.NewRat(fractionBP, 10000))
+
+	m := map[string]interface{}{
+		"totalObligation": ratIntStr(obligation),
+		"reserveCapacity": ratIntStr(capacity),
+		"isSufficient":    capacity.Cmp(obligation) >= 0,
+	}
+	if obligation.Sign() != 0 {
+		m["coverageRatio"] = ratFloatStr(new(big.Rat).Quo(capacity, obligation))
+	}
+	return m, nil
+}
+
+// PoolILPObligation sums the protected impermanent loss of all liquidity
+// providers in pool, in RUNE.
+func poolILPObligation(ctx context.Context, asset string, fullDays, assetDepth, runeDepth int64, timestamp time.Time) (*big.Rat, error) {
+	positions, err := stat.PoolLPPositionsLookup(ctx, asset, 0)
+	if err != nil {
+		return nil, err
+	}
+	poolUnits, err := poolUnitsLookup(ctx, asset, stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)})
+	if err != nil {
+		return nil, err
+	}
+
+	sum := new(big.Rat)
+	for i := range positions {
+		p := &positions[i]
+		holdDays := int64(timestamp.Sub(p.FirstStake) / (24 * time.Hour))
+		lossE8 := ilInRune(&p.StakerPoolPosition, poolUnits, runeDepth, assetDepth)
+		sum.Add(sum, lossE8.Mul(lossE8, ilpCoverage(holdDays, fullDays)))
+	}
+	return sum, nil
+}
+
+func serveV1NetworkSlashHistory(w http.ResponseWriter, r *http.Request) {
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := stat.NetworkSlashHistory(r.Context(), window, interval)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	respJSON(w, slashHistory(buckets))
+}
+
+// SlashHistory formats the buckets with a running total of the RUNE slashed.
+// The slash events have no node nor reason; the pools take their place.
+func slashHistory(buckets []stat.SlashBucket) []interface{} {
+	var cumulativeE8 int64
+	history := make([]interface{}, len(buckets))
+	for i, b := range buckets {
+		cumulativeE8 += b.RuneE8
+		pools := make([]string, 0, len(b.CountPerPool))
+		for pool := range b.CountPerPool {
+			pools = append(pools, pool)
+		}
+		sort.Strings(pools)
+		slashedPools := make([]interface{}, len(pools))
+		for j, pool := range pools {
+			slashedPools[j] = map[string]interface{}{
+				"pool":  pool,
+				"count": intStr(b.CountPerPool[pool]),
+			}
+		}
+		history[i] = map[string]interface{}{
+			"time":                  b.Time.Unix(),
+			"totalSlashes":          intStr(b.SlashCount),
+			"totalRuneSlashed":      intStr(b.RuneE8),
+			"cumulativeRuneSlashed": intStr(cumulativeE8),
+			"poolsSlashed":          intStr(int64(len(pools))),
+			"slashedPools":          slashedPools,
+		}
+	}
+	return history
+}
+
+func serveV1NodeRotationRate(w http.ResponseWriter, r *http.Request) {
+	days := int64(30)
+	if s := r.URL.Query().Get("window"); s != "" {
+		var ok bool
+		days, ok = longWindowDays[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown window %q, want 30d, 90d or 365d", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, timestamp, _ := timeseries.LastBlock()
+	window := stat.Window{Since: timestamp.Add(-time.Duration(days) * 24 * time.Hour), Until: timestamp.Add(1)}
+	rotation, err := stat.NodeRotationLookup(r.Context(), window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	respJSON(w, nodeRotationRate(rotation))
+}
+
+// NodeRotationRate reports the changes relative to the active set. The stable
+// node percentage is the part of the initial active set which is still active
+// at the end.
+func nodeRotationRate(rotation *stat.NodeRotation) map[string]interface{} {
+	var avgAdded, avgRemoved, rotationRate, stable float64
+	if rotation.Churns != 0 {
+		avgAdded = float64(rotation.NodesAdded) / float64(rotation.Churns)
+		avgRemoved = float64(rotation.NodesRemoved) / float64(rotation.Churns)
+	}
+	if rotation.AvgActiveNodes != 0 {
+		rotationRate = float64(rotation.NodesAdded+rotation.NodesRemoved) / rotation.AvgActiveNodes * 100
+	}
+	if rotation.StartActive != 0 {
+		stable = float64(rotation.StableNodes) / float64(rotation.StartActive) * 100
+	}
+	return map[string]interface{}{
+		"churnCount":              intStr(int64(rotation.Churns)),
+		"avgNodesAddedPerChurn":   strconv.FormatFloat(avgAdded, 'f', -1, 64),
+		"avgNodesRemovedPerChurn": strconv.FormatFloat(avgRemoved, 'f', -1, 64),
+		"rotationRatePercent":     strconv.FormatFloat(rotationRate, 'f', -1, 64),
+		"stableNodePercent":       strconv.FormatFloat(stable, 'f', -1, 64),
+	}
+}
+
+func serveV1NodeStakeDistribution(w http.ResponseWriter, r *http.Request) {
+	statusPerNode, err := timeseries.StatusPerNode(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	positions, err := stat.NodeOperatorLPOverlap(r.Context())
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	assetE8DepthPerPool, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	respJSON(w, nodeStakeDistribution(positions, statusPerNode, assetE8DepthPerPool, runeE8DepthPerPool))
+}
+
+// NodeStakeDistribution reports the positions of the active node operators.
+// Positions count once, even when the address operates multiple nodes. The
+// positions are valued at both sides of the pool.
+func nodeStakeDistribution(positions []stat.NodeOperatorPosition, statusPerNode map[string]string, assetE8DepthPerPool, runeE8DepthPerPool map[string]int64) map[string]interface{} {
+	var activeCount int64
+	for _, status := range statusPerNode {
+		if strings.EqualFold(status, "active") {
+			activeCount++
+		}
+	}
+
+	type position struct{ addr, pool string }
+	counted := make(map[position]bool)
+	lpNodes := make(map[string]bool)
+	operatorTVL := new(big.Rat)
+	for _, p := range positions {
+		if !strings.EqualFold(statusPerNode[p.Node], "active") {
+			continue
+		}
+		lpNodes[p.Node] = true
+		if counted[position{p.Addr, p.Pool}] || p.PoolUnits <= 0 {
+			continue
+		}
+		counted[position{p.Addr, p.Pool}] = true
+		value := big.NewRat(p.Units, p.PoolUnits)
+		operatorTVL.Add(operatorTVL, value.Mul(value, big.NewRat(2*runeE8DepthPerPool[p.Pool], 1)))
+	}
+
+	m := map[string]interface{}{
+		"nodesThatAreAlsoLPs": intStr(int64(len(lpNodes))),
+		"totalActiveNodes":    intStr(activeCount),
+		"nodeOperatorTVL":     ratIntStr(operatorTVL),
+	}
+	if activeCount != 0 {
+		m["overlapPercent"] = ratFloatStr(big.NewRat(int64(len(lpNodes))*100, activeCount))
+	}
+	runeE8, assetE8InRune := tvlInRune(assetE8DepthPerPool, runeE8DepthPerPool)
+	if total := runeE8 + assetE8InRune; total != 0 {
+		m["nodeOperatorPoolShare"] = ratFloatStr(new(big.Rat).Quo(operatorTVL, big.NewRat(total, 1)))
+	}
+	return m
+}
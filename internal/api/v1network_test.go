@@ -0,0 +1,186 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/thorchain/midgard/chain/notinchain"
+	"gitlab.com/thorchain/midgard/internal/timeseries"
+	"gitlab.com/thorchain/midgard/internal/timeseries/stat"
+)
+
+func TestTvlInRune(t *testing.T) {
+	assetE8DepthPerPool := map[string]int64{"BNB.BNB": 100, "BTC.BTC": 2, "BNB.EMPTY-000": 0}
+	runeE8DepthPerPool := map[string]int64{"BNB.BNB": 300, "BTC.BTC": 5000, "BNB.EMPTY-000": 7}
+	runeE8, assetE8InRune := tvlInRune(assetE8DepthPerPool, runeE8DepthPerPool)
+	if runeE8 != 5307 || assetE8InRune != 5300 {
+		t.Errorf("got %d RUNE and %d asset in RUNE, want 5307 and 5300", runeE8, assetE8InRune)
+	}
+}
+
+func TestSlashHistory(t *testing.T) {
+	buckets := []stat.SlashBucket{
+		{Time: time.Unix(0, 0), SlashCount: 3, RuneE8: 8e8, CountPerPool: map[string]int64{"ETH.ETH": 1, "BTC.BTC": 2}},
+		{Time: time.Unix(86400, 0), CountPerPool: map[string]int64{}},
+		{Time: time.Unix(2*86400, 0), SlashCount: 1, RuneE8: 4e8, CountPerPool: map[string]int64{"BTC.BTC": 1}},
+	}
+	got := slashHistory(buckets)
+	for i, want := range []string{"800000000", "800000000", "1200000000"} {
+		m := got[i].(map[string]interface{})
+		if s := m["cumulativeRuneSlashed"]; s != want {
+			t.Errorf("bucket %d got cumulative RUNE slashed %v, want %s", i, s, want)
+		}
+	}
+	m := got[0].(map[string]interface{})
+	if m["poolsSlashed"] != "2" {
+		t.Errorf("got %v pools slashed, want 2", m["poolsSlashed"])
+	}
+	first := m["slashedPools"].([]interface{})[0].(map[string]interface{})
+	if first["pool"] != "BTC.BTC" || first["count"] != "2" {
+		t.Errorf("got first slashed pool %v, want BTC.BTC with 2", first)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.1.0", "0.1.0", 0},
+		{"0.9.0", "0.10.0", -1},
+		{"1.0.0", "0.99.0", 1},
+		{"0.1", "0.1.1", -1},
+		{"v0.2.0", "0.1.0", 1},
+	}
+	for _, test := range tests {
+		if got := compareVersions(test.a, test.b); got != test.want {
+			t.Errorf("compareVersions(%q, %q) got %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestCountUpgradeVotes(t *testing.T) {
+	nodes := func(versions ...string) []*notinchain.NodeAccount {
+		a := make([]*notinchain.NodeAccount, len(versions))
+		for i, v := range versions {
+			a[i] = &notinchain.NodeAccount{Status: "active", Version: v}
+		}
+		return a
+	}
+
+	tests := []struct {
+		nodes []*notinchain.NodeAccount
+		want  upgradeVotes
+	}{
+		{nil, upgradeVotes{}},
+		{nodes("0.9.0", "0.9.0", "0.9.0"), upgradeVotes{"0.9.0", "0.9.0", 2, 3, 3}},
+		{nodes("0.9.0", "0.10.0", "0.9.0"), upgradeVotes{"0.9.0", "0.10.0", 2, 1, 3}},
+		{nodes("0.8.0", "0.10.0", "0.9.0", "0.10.0"), upgradeVotes{"0.9.0", "0.10.0", 3, 2, 4}},
+		{append(nodes("0.10.0"), &notinchain.NodeAccount{Status: "standby", Version: "0.11.0"}), upgradeVotes{"0.10.0", "0.10.0", 1, 1, 1}},
+	}
+	for _, test := range tests {
+		if got := countUpgradeVotes(test.nodes); got != test.want {
+			t.Errorf("got %+v, want %+v", got, test.want)
+		}
+	}
+}
+
+func TestNextCycleHeight(t *testing.T) {
+	tests := []struct{ last, cycle, current, want int64 }{
+		{0, 50000, 0, 50000},
+		{0, 50000, 49999, 50000},
+		{0, 50000, 50000, 100000},
+		{1200, 100, 1250, 1300},
+		{1200, 100, 1555, 1600},
+	}
+	for _, test := range tests {
+		got := nextCycleHeight(test.last, test.cycle, test.current)
+		if got != test.want {
+			t.Errorf("last activation %d with cycle %d at %d got %d, want %d", test.last, test.cycle, test.current, got, test.want)
+		}
+	}
+}
+
+func TestChurnEligibility(t *testing.T) {
+	nodes := []*notinchain.NodeAccount{
+		{NodeAddr: "a1", Status: "active", Bond: 50},
+		{NodeAddr: "a2", Status: "active", Bond: 100},
+		{NodeAddr: "s1", Status: "standby", Bond: 100},
+		{NodeAddr: "s2", Status: "standby", Bond: 99},
+		{NodeAddr: "s3", Status: "standby", Bond: 150},
+		{NodeAddr: "d1", Status: "disabled", Bond: 500},
+	}
+	leave, join := churnEligibility(nodes, 100)
+	if leave != 1 || join != 2 {
+		t.Errorf("got %d to leave and %d to join, want 1 and 2", leave, join)
+	}
+}
+
+func TestNodePerformance(t *testing.T) {
+	nodes := []*notinchain.NodeAccount{
+		{NodeAddr: "slashed", Status: "active", SlashPoints: 100},
+		{NodeAddr: "half", Status: "active", SlashPoints: 50},
+		{NodeAddr: "clean", Status: "active"},
+	}
+	active := map[string]time.Duration{
+		"slashed": 10 * time.Hour,
+		"half":    10 * time.Hour,
+		"clean":   5 * time.Hour,
+	}
+	got := nodePerformance(nodes, active, 10*time.Hour)
+	want := []struct{ addr, uptime, score string }{
+		{"half", "100", "50"},
+		{"clean", "50", "50"},
+		{"slashed", "100", "0"},
+	}
+	for i, w := range want {
+		m := got[i].(map[string]interface{})
+		if m["nodeAddr"] != w.addr || m["uptimePercent"] != w.uptime || m["performanceScore"] != w.score {
+			t.Errorf("%d: got %v, want node %s with uptime %s and score %s", i, m, w.addr, w.uptime, w.score)
+		}
+	}
+}
+
+func TestBondHistory(t *testing.T) {
+	changes := []timeseries.BondChange{
+		{Height: 10, Type: "bond_paid", E8: 100},
+		{Height: 20, Type: "bond_paid", E8: 50},
+		{Height: 30, Type: "bond_returned", E8: 120},
+		{Height: 40, Type: "bond_cost", E8: 5},
+		{Height: 50, Type: "bond_paid", E8: 75},
+	}
+	history, bonded, unbonded := bondHistory(changes)
+	want := []string{"100", "150", "30", "25", "100"}
+	for i, s := range want {
+		if got := history[i].(map[string]interface{})["cumulativeBond"]; got != s {
+			t.Errorf("%d: got cumulative bond %v, want %s", i, got, s)
+		}
+	}
+	if bonded != 225 || unbonded != 125 {
+		t.Errorf("got %d bonded and %d unbonded, want 225 and 125", bonded, unbonded)
+	}
+}
+
+func TestNodeStakeDistribution(t *testing.T) {
+	statusPerNode := map[string]string{"n1": "active", "n2": "Active", "n3": "standby", "n4": "active", "n5": "active"}
+	positions := []stat.NodeOperatorPosition{
+		{Node: "n1", Addr: "n1", Pool: "A.A", Units: 10, PoolUnits: 100},
+		// operator of two nodes
+		{Node: "n1", Addr: "op", Pool: "A.A", Units: 20, PoolUnits: 100},
+		{Node: "n2", Addr: "op", Pool: "A.A", Units: 20, PoolUnits: 100},
+		{Node: "n3", Addr: "n3", Pool: "A.A", Units: 50, PoolUnits: 100},
+	}
+	got := nodeStakeDistribution(positions, statusPerNode, map[string]int64{"A.A": 500}, map[string]int64{"A.A": 1000})
+	want := map[string]interface{}{
+		"nodesThatAreAlsoLPs":   "2",
+		"totalActiveNodes":      "4",
+		"overlapPercent":        "50",
+		"nodeOperatorTVL":       "600",
+		"nodeOperatorPoolShare": "0.3",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s got %v, want %v", k, got[k], v)
+		}
+	}
+}
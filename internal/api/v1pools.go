@@ -0,0 +1,2559 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/thorchain/midgard/chain/notinchain"
+	"gitlab.com/thorchain/midgard/internal/timeseries"
+	"gitlab.com/thorchain/midgard/internal/timeseries/stat"
+)
+
+// PoolPathParam returns the asset from a /v1/pools/:asset/… path.
+func poolPathParam(r *http.Request) (string, error) {
+	s := strings.TrimPrefix(r.URL.Path, "/v1/pools/")
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		s = s[:i]
+	}
+	return normalizeAsset(s)
+}
+
+// KnownPool returns whether asset has a pool, according to the depths of the
+// latest block. Caches per asset should check it first, as anything else in
+// the request would create an entry.
+func knownPool(asset string) bool {
+	assetE8DepthPerPool, _, _ := timeseries.AssetAndRuneDepths()
+	_, ok := assetE8DepthPerPool[asset]
+	return ok
+}
+
+func serveV1PoolsAssetFullHistory(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fromHeight, toHeight, err := heightRangeParams(r, 100, 1000)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	depths, err := timeseries.DepthHistory(r.Context(), asset, fromHeight, toHeight)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	array := make([]interface{}, len(depths))
+	for i, d := range depths {
+		array[i] = map[string]interface{}{
+			"height":     intStr(d.Height),
+			"time":       d.Timestamp.Unix(),
+			"assetDepth": intStr(d.AssetE8),
+			"runeDepth":  intStr(d.RuneE8),
+		}
+	}
+	respJSON(w, array)
+}
+
+func serveV1PoolTxs(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	txType := r.URL.Query().Get("type")
+	switch txType {
+	case "", "swap", "stake", "unstake":
+		break
+	default:
+		http.Error(w, "type parameter not one of swap, stake or unstake", http.StatusBadRequest)
+		return
+	}
+	window, err := windowParam(r, stat.Window{Since: time.Unix(0, 0), Until: time.Now()})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := pageParams(r, 50, 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	txs, err := stat.PoolTxListLookup(r.Context(), asset, txType, window, limit, offset)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	array := make([]interface{}, len(txs))
+	for i, tx := range txs {
+		array[i] = map[string]interface{}{
+			"type":    tx.Type,
+			"txID":    tx.TxID,
+			"address": tx.Addr,
+			"amounts": map[string]interface{}{
+				"assetE8": intStr(tx.AssetE8),
+				"runeE8":  intStr(tx.RuneE8),
+			},
+			"fee":    intStr(tx.LiqFeeInRuneE8),
+			"height": intStr(tx.Height),
+			"time":   tx.Time.Unix(),
+		}
+	}
+	respJSON(w, array)
+}
+
+func serveV1PoolMemberCount(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, err := stat.PoolMemberCountLookup(r.Context(), asset, stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	buckets, err := stat.PoolMemberCountBucketsLookup(r.Context(), asset, interval, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	history := make([]map[string]interface{}, len(buckets))
+	for i, b := range buckets {
+		history[i] = map[string]interface{}{
+			"time":  b.Time.Unix(),
+			"count": intStr(b.Count),
+		}
+	}
+	respJSON(w, map[string]interface{}{
+		"current": intStr(current),
+		"history": history,
+	})
+}
+
+// PoolROIPeriods are the windows of serveV1PoolROI, with zero for all time.
+var poolROIPeriods = []struct {
+	Name   string
+	Period time.Duration
+}{
+	{"all_time", 0},
+	{"last_year", 365 * 24 * time.Hour},
+	{"last_6_months", 182 * 24 * time.Hour},
+	{"last_3_months", 91 * 24 * time.Hour},
+	{"last_month", 30 * 24 * time.Hour},
+	{"last_week", 7 * 24 * time.Hour},
+}
+
+func serveV1PoolROI(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	until := timestamp.Add(1)
+
+	rois := make([]*stat.PoolROI, len(poolROIPeriods))
+	err = forEachParallel(r.Context(), len(poolROIPeriods), len(poolROIPeriods), func(ctx context.Context, i int) error {
+		window := stat.Window{Since: time.Unix(0, 0), Until: until}
+		if p := poolROIPeriods[i].Period; p != 0 {
+			window.Since = until.Add(-p)
+		}
+		var err error
+		rois[i], err = stat.PoolROIForWindow(ctx, asset, window)
+		return err
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	m := make(map[string]interface{}, len(poolROIPeriods))
+	for i, p := range poolROIPeriods {
+		m[p.Name] = map[string]interface{}{
+			"assetROI": strconv.FormatFloat(rois[i].AssetROI, 'f', -1, 64),
+			"runeROI":  strconv.FormatFloat(rois[i].RuneROI, 'f', -1, 64),
+			"poolROI":  strconv.FormatFloat(rois[i].PoolROI, 'f', -1, 64),
+		}
+	}
+	respJSON(w, m)
+}
+
+// Days until full impermanent loss protection, in case of absence in the Mimir.
+const defaultFullILPProtection = 100
+
+func serveV1ILPStatus(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	addr := r.URL.Query().Get("address")
+	if addr == "" {
+		http.Error(w, "need address parameter", http.StatusBadRequest)
+		return
+	}
+
+	mimir, err := timeseries.Mimir(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	fullDays := mimirInt(mimir, "FullILPProtection", defaultFullILPProtection)
+
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	window := stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)}
+	position, err := stat.StakerPoolPositionLookup(r.Context(), asset, addr, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	if position.Units <= 0 {
+		http.Error(w, fmt.Sprintf("address %q has no stake in pool %s", addr, asset), http.StatusNotFound)
+		return
+	}
+	poolUnits, err := poolUnitsLookup(r.Context(), asset, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	holdDays := int64(timestamp.Sub(position.FirstStake) / (24 * time.Hour))
+	coverage := ilpCoverage(holdDays, fullDays)
+
+	lossE8 := ilInRune(position, poolUnits, runeE8DepthPerPool[asset], assetE8DepthPerPool[asset])
+	protectedE8 := new(big.Rat).Mul(lossE8, coverage)
+
+	respJSON(w, map[string]interface{}{
+		"holdDays":                 intStr(holdDays),
+		"ilpCoveragePercent":       ratFloatStr(new(big.Rat).Mul(coverage, big.NewRat(100, 1))),
+		"estimatedProtectedAmount": ratIntStr(protectedE8),
+		"eligibleForClaim":         protectedE8.Sign() > 0,
+	})
+}
+
+// IlpCoverage returns the portion of impermanent loss protected after holdDays,
+// which grows linearly until full protection at fullDays.
+func ilpCoverage(holdDays, fullDays int64) *big.Rat {
+	if holdDays >= fullDays {
+		return big.NewRat(1, 1)
+	}
+	if holdDays <= 0 {
+		return new(big.Rat)
+	}
+	return big.NewRat(holdDays, fullDays)
+}
+
+// PoolUnitsLookup gets the stake units in pool at the end of the window.
+func poolUnitsLookup(ctx context.Context, asset string, window stat.Window) (int64, error) {
+	stakes, err := stat.PoolStakesLookup(ctx, asset, window)
+	if err != nil {
+		return 0, err
+	}
+	unstakes, err := stat.PoolUnstakesLookup(ctx, asset, window)
+	if err != nil {
+		return 0, err
+	}
+	return stakes.StakeUnitsTotal - unstakes.StakeUnitsTotal, nil
+}
+
+// IlInRune returns the value lost by staking instead of holding, in RUNE at the
+// current pool price.
+func ilInRune(p *stat.StakerPoolPosition, poolUnits, runeDepth, assetDepth int64) *big.Rat {
+	if poolUnits <= 0 || assetDepth <= 0 {
+		return new(big.Rat)
+	}
+	// staked amounts at the current price
+	held := big.NewRat(p.AssetE8, 1)
+	held.Mul(held, big.NewRat(runeDepth, assetDepth))
+	held.Add(held, big.NewRat(p.RuneE8, 1))
+	value := big.NewRat(p.Units, poolUnits)
+	value.Mul(value, big.NewRat(poolValueInRune(runeDepth), 1))
+
+	loss := held.Sub(held, value)
+	if loss.Sign() < 0 {
+		return new(big.Rat)
+	}
+	return loss
+}
+
+// ServeV1PendingLiquidity lists the asymmetric deposits which THORNode has not
+// matched yet. Deposits from blocks Midgard did not process yet are marked as
+// "syncing", as their stake events may still show up.
+func serveV1PendingLiquidity(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pending, err := notinchain.PendingLiquidityLookup(asset)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	height, _, _ := timeseries.LastBlock()
+
+	array := make([]interface{}, len(pending))
+	for i, p := range pending {
+		status := "pending"
+		if p.LastAddHeight > height {
+			status = "syncing"
+		}
+		array[i] = map[string]interface{}{
+			"runeAddress":  p.RuneAddr,
+			"assetAddress": p.AssetAddr,
+			"runeAmount":   intStr(p.PendingRune),
+			"assetAmount":  intStr(p.PendingAsset),
+			"pendingSince": intStr(p.LastAddHeight),
+			"status":       status,
+		}
+	}
+	respJSON(w, array)
+}
+
+func serveV1InactivePools(w http.ResponseWriter, r *http.Request) {
+	statuses := []string{"Enabled", "Bootstrap", "Suspended"}
+	pools := make([][]timeseries.PoolStatusChange, len(statuses))
+	err := forEachParallel(r.Context(), len(statuses), len(statuses), func(ctx context.Context, i int) error {
+		var err error
+		pools[i], err = timeseries.PoolsByStatus(ctx, statuses[i], time.Time{})
+		return err
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	inactive := append(pools[1], pools[2]...)
+	sort.Slice(inactive, func(i, j int) bool { return inactive[i].Pool < inactive[j].Pool })
+	array := make([]interface{}, len(inactive))
+	for i, c := range inactive {
+		array[i] = map[string]interface{}{
+			"asset":            c.Pool,
+			"status":           c.Status,
+			"lastStatusChange": c.Timestamp.Unix(),
+			"reason":           inactiveReason(c),
+		}
+	}
+
+	respJSON(w, map[string]interface{}{
+		"pools":          array,
+		"enabledCount":   intStr(int64(len(pools[0]))),
+		"bootstrapCount": intStr(int64(len(pools[1]))),
+		"suspendedCount": intStr(int64(len(pools[2]))),
+	})
+}
+
+// InactiveReason describes the status change which made a pool inactive.
+func inactiveReason(c timeseries.PoolStatusChange) string {
+	switch {
+	case strings.EqualFold(c.Status, "Bootstrap") && c.Previous == "":
+		return "not activated yet"
+	case strings.EqualFold(c.Status, "Bootstrap"):
+		return "returned to bootstrap from " + strings.ToLower(c.Previous)
+	case c.Previous == "":
+		return strings.ToLower(c.Status)
+	default:
+		return strings.ToLower(c.Status) + " from " + strings.ToLower(c.Previous)
+	}
+}
+
+func serveV1PoolTxCounts(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-24 * time.Hour), Until: timestamp})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	counts, err := stat.PoolTxCounts(r.Context(), asset, interval, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	array := make([]interface{}, len(counts))
+	for i, c := range counts {
+		array[i] = map[string]interface{}{
+			"time":               c.Time.Unix(),
+			"swapCount":          intStr(c.SwapNum),
+			"stakeCount":         intStr(c.StakeNum),
+			"unstakeCount":       intStr(c.UnstakeNum),
+			"totalCount":         intStr(c.SwapNum + c.StakeNum + c.UnstakeNum),
+			"uniqueAddressCount": intStr(c.AddrNum),
+		}
+	}
+	respJSON(w, array)
+}
+
+func serveV1PoolDepthAt(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s := r.URL.Query().Get("time")
+	if s == "" {
+		http.Error(w, "need time parameter", http.StatusBadRequest)
+		return
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("couldn't parse time parameter as RFC 3339: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// times beyond the last block resolve to the latest
+	height, timestamp, err := timeseries.HeightAtTime(r.Context(), t)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	if height == 0 {
+		http.Error(w, fmt.Sprintf("no block at or before %s", t.Format(time.RFC3339)), http.StatusNotFound)
+		return
+	}
+	assetE8, runeE8, err := timeseries.DepthAtHeight(r.Context(), asset, height)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	m := map[string]interface{}{
+		"asset":          asset,
+		"assetDepth":     intStr(assetE8),
+		"runeDepth":      intStr(runeE8),
+		"height":         intStr(height),
+		"blockTimestamp": timestamp.Unix(),
+	}
+	if assetE8 != 0 {
+		m["price"] = ratFloatStr(big.NewRat(runeE8, assetE8))
+	}
+	respJSON(w, m)
+}
+
+var poolYieldWindowDays = map[string]int64{"30d": 30, "90d": 90, "365d": 365}
+
+func serveV1PoolYield(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	days := int64(30)
+	if s := r.URL.Query().Get("window"); s != "" {
+		var ok bool
+		days, ok = poolYieldWindowDays[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown window %q, want 30d, 90d or 365d", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, timestamp, _ := timeseries.LastBlock()
+	window := stat.Window{Since: timestamp.Add(-time.Duration(days) * 24 * time.Hour), Until: timestamp.Add(1)}
+	y, err := stat.PoolFeeYieldLookup(r.Context(), asset, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	m := map[string]interface{}{
+		"windowDays":       intStr(days),
+		"totalFeesRune":    intStr(y.FeesRuneE8),
+		"averageDepthRune": intStr(y.AvgDepthRuneE8),
+	}
+	if y.StartDepthRuneE8 != 0 {
+		annualized := big.NewRat(y.FeesRuneE8, y.StartDepthRuneE8)
+		annualized.Mul(annualized, big.NewRat(365, days))
+		m["annualizedYield"] = ratFloatStr(annualized)
+	}
+	respJSON(w, m)
+}
+
+func serveV1PoolSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	// short patterns can't use the trigram index
+	if len(q) < 2 {
+		http.Error(w, "need q parameter with at least 2 characters", http.StatusBadRequest)
+		return
+	}
+
+	pools, err := timeseries.SearchPools(r.Context(), q, 20)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	statuses := make([]string, len(pools))
+	err = forEachParallel(r.Context(), len(pools), 4, func(ctx context.Context, i int) error {
+		var err error
+		statuses[i], err = timeseries.PoolStatus(ctx, pools[i], time.Time{})
+		return err
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	assetE8DepthPerPool, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	array := make([]interface{}, len(pools))
+	for i, pool := range pools {
+		array[i] = map[string]interface{}{
+			"asset":      pool,
+			"status":     statuses[i],
+			"assetDepth": intStr(assetE8DepthPerPool[pool]),
+			"runeDepth":  intStr(runeE8DepthPerPool[pool]),
+		}
+	}
+	respJSON(w, array)
+}
+
+// ServeV1SyntheticAsset reports on the synthetic of the pool asset, i.e., the
+// path /v1/pools/BTC.BTC/synthetic covers BTC/BTC. Synthetics can't go in
+// the path as is, due to the slash.
+func serveV1SyntheticAsset(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	synth := strings.Replace(asset, ".", "/", 1)
+
+	supply, err := stat.SyntheticSupplyLookup(r.Context(), synth)
+	if err != nil {
+		if errors.Is(err, stat.ErrNoTable) {
+			http.Error(w, "synthetics are not recorded (yet): "+err.Error(), http.StatusNotImplemented)
+			return
+		}
+		respError(w, r, err)
+		return
+	}
+	assetE8DepthPerPool, _, _ := timeseries.AssetAndRuneDepths()
+	assetDepth := assetE8DepthPerPool[asset]
+
+	m := map[string]interface{}{
+		"asset":           synth,
+		"syntheticSupply": intStr(supply),
+		"backingAssetE8":  intStr(assetDepth),
+	}
+	if assetDepth != 0 {
+		m["backingRatio"] = ratFloatStr(big.NewRat(supply, assetDepth))
+	}
+	// pool value, in asset, per synthetic
+	if supply != 0 {
+		m["collateralizationRatio"] = ratFloatStr(big.NewRat(2*assetDepth, supply))
+	}
+	respJSON(w, m)
+}
+
+// ServeV1SynthBurnsMints lists the mints and burns of the synthetic asset of
+// a pool. The daily volumes cover the 24 hours up to the end of the window.
+// Addresses and backing amounts are not available from the events.
+func serveV1SynthBurnsMints(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	synth := strings.Replace(asset, ".", "/", 1)
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := pageParams(r, 50, 200)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := stat.SynthEventsLookup(r.Context(), synth, window, limit, offset)
+	if err != nil {
+		if errors.Is(err, stat.ErrNoTable) {
+			http.Error(w, "synthetics are not recorded (yet): "+err.Error(), http.StatusNotImplemented)
+			return
+		}
+		respError(w, r, err)
+		return
+	}
+	mintE8, burnE8, err := stat.SynthVolumesLookup(r.Context(), synth, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	dailyMintE8, dailyBurnE8, err := stat.SynthVolumesLookup(r.Context(), synth, stat.Window{Since: window.Until.Add(-24 * time.Hour), Until: window.Until})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	array := make([]interface{}, len(events))
+	for i, e := range events {
+		array[i] = map[string]interface{}{
+			"type":   e.Type,
+			"amount": intStr(e.E8),
+			"reason": e.Reason,
+			"time":   e.Time.Unix(),
+		}
+	}
+	respJSON(w, map[string]interface{}{
+		"asset":           synth,
+		"events":          array,
+		"dailyMintVolume": intStr(dailyMintE8),
+		"dailyBurnVolume": intStr(dailyBurnE8),
+		"netSupplyChange": intStr(mintE8 - burnE8),
+	})
+}
+
+func serveV1PoolArbitrage(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	externalPrice, err := strconv.ParseFloat(r.URL.Query().Get("externalPrice"), 64)
+	if err != nil || externalPrice <= 0 || math.IsInf(externalPrice, 0) {
+		http.Error(w, "need externalPrice parameter as a positive number of RUNE per asset", http.StatusBadRequest)
+		return
+	}
+
+	assetE8DepthPerPool, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	assetDepth, runeDepth := assetE8DepthPerPool[asset], runeE8DepthPerPool[asset]
+	if assetDepth <= 0 || runeDepth <= 0 {
+		http.Error(w, fmt.Sprintf("pool %s has no depth", asset), http.StatusNotFound)
+		return
+	}
+	lastSwapHeight, err := stat.PoolLastSwapHeight(r.Context(), asset)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	height, _, _ := timeseries.LastBlock()
+
+	price := float64(runeDepth) / float64(assetDepth)
+	m := map[string]interface{}{
+		"midgardPrice":                 strconv.FormatFloat(price, 'f', -1, 64),
+		"externalPrice":                strconv.FormatFloat(externalPrice, 'f', -1, 64),
+		"priceDeviation":               strconv.FormatFloat(math.Abs(price-externalPrice)/externalPrice, 'f', -1, 64),
+		"estimatedArbitrageVolumeRune": strconv.FormatFloat(math.Round(arbitrageVolume(assetDepth, runeDepth, externalPrice)), 'f', -1, 64),
+	}
+	// swaps can't be told apart; any swap may correct the price
+	if lastSwapHeight != 0 {
+		m["lastArbitrageBlockAge"] = intStr(height - lastSwapHeight)
+	}
+	respJSON(w, m)
+}
+
+// ArbitrageVolume returns the amount of RUNE which moves the pool price to
+// price, without fees. The constant product k = asset × RUNE has the RUNE
+// depth at sqrt(k × price) for any price.
+func arbitrageVolume(assetDepth, runeDepth int64, price float64) float64 {
+	k := float64(assetDepth) * float64(runeDepth)
+	return math.Abs(math.Sqrt(k*price) - float64(runeDepth))
+}
+
+// ServeV1LPConcentration serves the distribution of stake units over the pool
+// members. An HHI above 2500 counts as highly concentrated, i.e., the pool is
+// vulnerable to sudden large withdrawals.
+func serveV1LPConcentration(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c, err := stat.LPConcentration(r.Context(), asset)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	respJSON(w, map[string]interface{}{
+		"hhi":             strconv.FormatFloat(c.HHI, 'f', -1, 64),
+		"lpCount":         intStr(c.LPCount),
+		"top10Share":      strconv.FormatFloat(c.Top10Share, 'f', -1, 64),
+		"top1Share":       strconv.FormatFloat(c.Top1Share, 'f', -1, 64),
+		"giniCoefficient": strconv.FormatFloat(c.Gini, 'f', -1, 64),
+	})
+}
+
+// PoolComparisonCaches has a *timedCache per pool asset.
+var poolComparisonCaches sync.Map
+
+func serveV1PoolComparison(w http.ResponseWriter, r *http.Request) {
+	assets, err := assetListParam(r, "assets")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, asset := range assets {
+		if !knownPool(asset) {
+			http.Error(w, fmt.Sprintf("pool %s not found", asset), http.StatusNotFound)
+			return
+		}
+	}
+
+	array := make([]interface{}, len(assets))
+	err = forEachParallel(r.Context(), len(assets), 4, func(ctx context.Context, i int) error {
+		c, _ := poolComparisonCaches.LoadOrStore(assets[i], &timedCache{TTL: time.Minute})
+		var err error
+		array[i], err = c.(*timedCache).get(func() (interface{}, error) {
+			return poolComparison(ctx, assets[i])
+		})
+		return err
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	respJSON(w, array)
+}
+
+// PoolComparison gets the metrics of a pool, with all values in RUNE.
+func poolComparison(ctx context.Context, asset string) (map[string]interface{}, error) {
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	assetDepth, runeDepth := assetE8DepthPerPool[asset], runeE8DepthPerPool[asset]
+	until := timestamp.Add(1)
+	day := stat.Window{Since: until.Add(-24 * time.Hour), Until: until}
+
+	volume, feesE8, err := poolVolumeLookup(ctx, asset, day, assetDepth, runeDepth)
+	if err != nil {
+		return nil, err
+	}
+	lpCount, err := stat.PoolMemberCountLookup(ctx, asset, stat.Window{Since: time.Unix(0, 0), Until: until})
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{
+		"asset":     asset,
+		"tvlRune":   intStr(poolValueInRune(runeDepth)),
+		"fees24h":   intStr(feesE8),
+		"volume24h": ratIntStr(volume),
+		"lpCount":   intStr(lpCount),
+	}
+
+	for _, days := range []int64{7, 30} {
+		window := stat.Window{Since: until.Add(-time.Duration(days) * 24 * time.Hour), Until: until}
+		y, err := stat.PoolFeeYieldLookup(ctx, asset, window)
+		if err != nil {
+			return nil, err
+		}
+		if y.StartDepthRuneE8 != 0 {
+			apy := big.NewRat(y.FeesRuneE8, y.StartDepthRuneE8)
+			apy.Mul(apy, big.NewRat(365, days))
+			m[fmt.Sprintf("apy%dd", days)] = ratFloatStr(apy)
+		}
+	}
+
+	height, _, err := timeseries.HeightAtTime(ctx, day.Since)
+	if err != nil {
+		return nil, err
+	}
+	if height != 0 && assetDepth != 0 {
+		assetBefore, runeBefore, err := timeseries.DepthAtHeight(ctx, asset, height)
+		if err != nil {
+			return nil, err
+		}
+		if assetBefore != 0 && runeBefore != 0 {
+			// current price ÷ previous price − 1
+			change := big.NewRat(runeDepth, assetDepth)
+			change.Quo(change, big.NewRat(runeBefore, assetBefore))
+			change.Sub(change, big.NewRat(1, 1))
+			m["priceChange24h"] = ratFloatStr(change)
+		}
+	}
+	return m, nil
+}
+
+// ServeV1PoolDepthPredict serves the pool depth with the pending outbounds of
+// the pool asset. Unconfirmed transactions come in the THORChain message
+// encoding, which is not available to Midgard. Only their total is included.
+func serveV1PoolDepthPredict(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	assetE8DepthPerPool, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	assetDepth, runeDepth := assetE8DepthPerPool[asset], runeE8DepthPerPool[asset]
+
+	outbounds, err := notinchain.PendingOutboundsLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	changes := make([]map[string]interface{}, 0)
+	for _, o := range outbounds {
+		if o.Coin.Asset != asset {
+			continue
+		}
+		changes = append(changes, map[string]interface{}{
+			"type":    "outbound",
+			"runeE8":  "0",
+			"assetE8": intStr(-o.Coin.Amount),
+		})
+	}
+
+	m := map[string]interface{}{
+		"currentDepth": map[string]interface{}{
+			"assetE8": intStr(assetDepth),
+			"runeE8":  intStr(runeDepth),
+		},
+		"pendingChanges": changes,
+	}
+	// The mempool transactions are not decoded, so they can't be
+	// attributed to the pool, nor can their amounts be applied.
+	if MempoolTransactions != nil {
+		txs, err := MempoolTransactions()
+		if err != nil {
+			respError(w, r, err)
+			return
+		}
+		m["mempoolTxCount"] = strconv.Itoa(len(txs))
+	}
+	respJSON(w, m)
+}
+
+func serveV1APYHistory(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	days := int64(30)
+	if s := r.URL.Query().Get("window"); s != "" {
+		var ok bool
+		days, ok = poolYieldWindowDays[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown window %q, want 30d, 90d or 365d", s), http.StatusBadRequest)
+			return
+		}
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	points, err := stat.APYHistory(r.Context(), asset, time.Duration(days)*24*time.Hour, interval, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	array := make([]map[string]interface{}, len(points))
+	for i, p := range points {
+		array[i] = map[string]interface{}{
+			"date":       p.Time.Unix(),
+			"feeAPY":     strconv.FormatFloat(p.FeeAPY, 'f', -1, 64),
+			"totalAPY":   strconv.FormatFloat(p.TotalAPY, 'f', -1, 64),
+			"ilEstimate": strconv.FormatFloat(p.ILEstimate, 'f', -1, 64),
+		}
+	}
+	respJSON(w, array)
+}
+
+func serveV1PoolFeeHistory(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := stat.PoolFeeBreakdown(r.Context(), asset, window, interval)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	array := make([]map[string]interface{}, len(buckets))
+	for i, b := range buckets {
+		array[i] = map[string]interface{}{
+			"time":                b.Time.Unix(),
+			"liquidityFeeRuneE8":  intStr(b.LiqFeeRuneE8),
+			"systemFeeRuneE8":     intStr(b.SysFeeRuneE8),
+			"liquidityFeeAssetE8": intStr(b.LiqFeeAssetE8),
+			"systemFeeAssetE8":    intStr(b.SysFeeAssetE8),
+		}
+	}
+	respJSON(w, array)
+}
+
+// PoolStabilityWindowDays are the accepted values of the window parameter.
+var poolStabilityWindowDays = map[string]int64{"7d": 7, "30d": 30, "90d": 90}
+
+// PoolStabilityMinPoints is the minimum number of depths for the statistics
+// to be meaningful.
+const poolStabilityMinPoints = 7
+
+func serveV1PoolStability(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	days := int64(30)
+	if s := r.URL.Query().Get("window"); s != "" {
+		var ok bool
+		days, ok = poolStabilityWindowDays[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown window %q, want 7d, 30d or 90d", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, timestamp, _ := timeseries.LastBlock()
+	window := stat.Window{Since: timestamp.Add(-time.Duration(days) * 24 * time.Hour), Until: timestamp.Add(1)}
+	s, err := stat.PoolDepthStatsLookup(r.Context(), asset, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	if s.Count < poolStabilityMinPoints {
+		respJSON(w, nil)
+		return
+	}
+
+	var variation float64
+	if s.MeanRuneE8 != 0 {
+		variation = s.StdDevRuneE8 / s.MeanRuneE8
+	}
+	respJSON(w, map[string]interface{}{
+		"stdDevDepthRune":        strconv.FormatFloat(s.StdDevRuneE8, 'f', -1, 64),
+		"coefficientOfVariation": strconv.FormatFloat(variation, 'f', -1, 64),
+		"minDepth":               intStr(s.MinRuneE8),
+		"maxDepth":               intStr(s.MaxRuneE8),
+		"deepDiveCount":          intStr(s.DeepDiveCount),
+	})
+}
+
+// ReportedAssetBalances sums the asgard vault coins per asset.
+func reportedAssetBalances(ctx context.Context) (map[string]int64, error) {
+	v, err := asgardVaultsCache.get(func() (interface{}, error) {
+		return asgardVaultsLookup(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[string]int64)
+	for _, vault := range v.(*asgardVaults).Vaults {
+		for _, c := range vault.Coins {
+			balances[c.Asset] += c.Amount
+		}
+	}
+	return balances, nil
+}
+
+func serveV1PoolBalanceCheck(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reported, err := reportedAssetBalances(r.Context())
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	assetE8DepthPerPool, _, _ := timeseries.AssetAndRuneDepths()
+	depth, ok := assetE8DepthPerPool[asset]
+	if !ok {
+		http.Error(w, fmt.Sprintf("pool %s not found", asset), http.StatusNotFound)
+		return
+	}
+	respJSON(w, balanceCheckJSON(asset, depth, reported[asset]))
+}
+
+func serveV1PoolBalanceChecks(w http.ResponseWriter, r *http.Request) {
+	reported, err := reportedAssetBalances(r.Context())
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	assetE8DepthPerPool, _, _ := timeseries.AssetAndRuneDepths()
+	pools := make([]string, 0, len(assetE8DepthPerPool))
+	for pool := range assetE8DepthPerPool {
+		pools = append(pools, pool)
+	}
+	sort.Strings(pools)
+
+	array := make([]interface{}, len(pools))
+	for i, pool := range pools {
+		array[i] = balanceCheckJSON(pool, assetE8DepthPerPool[pool], reported[pool])
+	}
+	respJSON(w, array)
+}
+
+// BalanceCheckJSON compares the event-derived depth to the vault balance.
+// Discrepancies within 0.1 % of the depth count as balanced.
+func balanceCheckJSON(pool string, midgardE8, reportedE8 int64) map[string]interface{} {
+	discrepancy := reportedE8 - midgardE8
+	m := map[string]interface{}{
+		"pool":                 pool,
+		"midgardAssetBalance":  intStr(midgardE8),
+		"reportedAssetBalance": intStr(reportedE8),
+		"discrepancyE8":        intStr(discrepancy),
+	}
+	abs := discrepancy
+	if abs < 0 {
+		abs = -abs
+	}
+	if midgardE8 != 0 {
+		m["discrepancyPercent"] = ratFloatStr(big.NewRat(discrepancy*100, midgardE8))
+		m["isBalanced"] = big.NewRat(abs, midgardE8).Cmp(big.NewRat(1, 1000)) <= 0
+	} else {
+		m["isBalanced"] = discrepancy == 0
+	}
+	return m
+}
+
+// DepthTrendMinPoints is the minimum number of depths for a meaningful fit.
+const depthTrendMinPoints = 10
+
+func serveV1DepthTrend(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	days := int64(30)
+	if s := r.URL.Query().Get("window"); s != "" {
+		var ok bool
+		days, ok = poolStabilityWindowDays[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown window %q, want 7d, 30d or 90d", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, timestamp, _ := timeseries.LastBlock()
+	window := stat.Window{Since: timestamp.Add(-time.Duration(days) * 24 * time.Hour), Until: timestamp.Add(1)}
+	trend, err := stat.PoolDepthTrend(r.Context(), asset, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	if trend.Count < depthTrendMinPoints {
+		http.Error(w, fmt.Sprintf("%d depth changes in window, need at least %d", trend.Count, depthTrendMinPoints), http.StatusUnprocessableEntity)
+		return
+	}
+
+	direction := "flat"
+	switch {
+	case trend.Slope > 0:
+		direction = "up"
+	case trend.Slope < 0:
+		direction = "down"
+	}
+	respJSON(w, map[string]interface{}{
+		// RUNE depth per second since the Unix epoch
+		"slope":                  strconv.FormatFloat(trend.Slope, 'f', -1, 64),
+		"intercept":              strconv.FormatFloat(trend.Intercept, 'f', -1, 64),
+		"rSquared":               strconv.FormatFloat(trend.RSquared, 'f', -1, 64),
+		"trendDirection":         direction,
+		"predictedDepthIn7Days":  intStr(int64(trend.At(timestamp.Add(7 * 24 * time.Hour)))),
+		"predictedDepthIn30Days": intStr(int64(trend.At(timestamp.Add(30 * 24 * time.Hour)))),
+	})
+}
+
+// ServeV1IncomeStatement summarises the position of an address in a pool per
+// calendar quarter, with all values in RUNE.
+func serveV1IncomeStatement(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	addr := q.Get("address")
+	if addr == "" {
+		http.Error(w, "need address parameter", http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	year := timestamp.UTC().Year()
+	if s := q.Get("year"); s != "" {
+		year, err = strconv.Atoi(s)
+		if err != nil || year < 1970 || year > 9999 {
+			http.Error(w, fmt.Sprintf("couldn't parse year parameter %q", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// quarters which did not start yet are omitted
+	var windows []stat.Window
+	for month := time.January; month <= time.October; month += 3 {
+		since := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		if since.After(timestamp) {
+			break
+		}
+		until := since.AddDate(0, 3, 0)
+		if until.After(timestamp) {
+			until = timestamp.Add(1)
+		}
+		windows = append(windows, stat.Window{Since: since, Until: until})
+	}
+
+	array := make([]interface{}, len(windows))
+	err = forEachParallel(r.Context(), len(windows), 2, func(ctx context.Context, i int) error {
+		window := windows[i]
+		opening, err := lpSnapshotAt(ctx, asset, addr, window.Since)
+		if err != nil {
+			return err
+		}
+		closing, err := lpSnapshotAt(ctx, asset, addr, window.Until)
+		if err != nil {
+			return err
+		}
+		stakes, err := stat.PoolStakesAddrLookup(ctx, asset, addr, window)
+		if err != nil {
+			return err
+		}
+		withdrawals, err := stat.PoolWithdrawalsAddrLookup(ctx, asset, addr, window)
+		if err != nil {
+			return err
+		}
+		fees, err := stat.StakerFeesLookup(ctx, addr, asset, window)
+		if err != nil {
+			return err
+		}
+
+		m := quarterIncome(opening, closing, stakes, withdrawals, fees.RuneE8)
+		m["quarter"] = fmt.Sprintf("Q%d", i+1)
+		array[i] = m
+		return nil
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	respJSON(w, array)
+}
+
+// LpSnapshot is the share of an address in a pool at a point in time.
+type lpSnapshot struct {
+	Units, PoolUnits      int64
+	AssetDepth, RuneDepth int64
+}
+
+// LpSnapshotAt gets the share of addr in pool before until.
+func lpSnapshotAt(ctx context.Context, pool, addr string, until time.Time) (lpSnapshot, error) {
+	var s lpSnapshot
+	window := stat.Window{Since: time.Unix(0, 0), Until: until}
+	p, err := stat.StakerPoolPositionLookup(ctx, pool, addr, window)
+	if err != nil {
+		return s, err
+	}
+	s.Units = p.Units
+	s.PoolUnits, err = poolUnitsLookup(ctx, pool, window)
+	if err != nil {
+		return s, err
+	}
+	height, _, err := timeseries.HeightAtTime(ctx, until.Add(-1))
+	if err != nil || height == 0 {
+		return s, err
+	}
+	s.AssetDepth, s.RuneDepth, err = timeseries.DepthAtHeight(ctx, pool, height)
+	return s, err
+}
+
+// Share returns the fraction of the pool owned.
+func (s lpSnapshot) share() *big.Rat {
+	if s.Units <= 0 || s.PoolUnits <= 0 {
+		return new(big.Rat)
+	}
+	return big.NewRat(s.Units, s.PoolUnits)
+}
+
+// Value returns the RUNE value of the share, which is twice its RUNE side.
+func (s lpSnapshot) value() *big.Rat {
+	v := s.share()
+	return v.Mul(v, big.NewRat(2*s.RuneDepth, 1))
+}
+
+// Price returns the amount of RUNE per asset, with zero for an empty pool.
+func (s lpSnapshot) price() *big.Rat {
+	if s.AssetDepth <= 0 {
+		return new(big.Rat)
+	}
+	return big.NewRat(s.RuneDepth, s.AssetDepth)
+}
+
+// QuarterIncome accounts the change in value from opening to closing. Asset
+// amounts deposited and withdrawn are valued at the closing price. Withdrawals
+// are the outbounds of unstakes. The price
+// change is the effect of the price move on the opening position when held, and
+// the impermanent loss is the difference between holding and staking that
+// position.
+func quarterIncome(opening, closing lpSnapshot, stakes *stat.PoolStakes, withdrawals *stat.PoolWithdrawals, feesE8 int64) map[string]interface{} {
+	openValue, closeValue := opening.value(), closing.value()
+	closePrice := closing.price()
+
+	deposits := big.NewRat(stakes.AssetE8Total, 1)
+	deposits.Mul(deposits, closePrice)
+	deposits.Add(deposits, big.NewRat(stakes.RuneE8Total, 1))
+	withdrawn := big.NewRat(withdrawals.AssetE8Total, 1)
+	withdrawn.Mul(withdrawn, closePrice)
+	withdrawn.Add(withdrawn, big.NewRat(withdrawals.RuneE8Total, 1))
+
+	// opening position held at the closing price
+	held := opening.share()
+	held.Mul(held, big.NewRat(opening.AssetDepth, 1))
+	held.Mul(held, closePrice)
+	held.Add(held, new(big.Rat).Quo(openValue, big.NewRat(2, 1)))
+	priceChange := new(big.Rat).Sub(held, openValue)
+
+	var il float64
+	if openPrice, _ := opening.price().Float64(); openPrice > 0 && closePrice.Sign() > 0 {
+		p, _ := closePrice.Float64()
+		h, _ := held.Float64()
+		il = stat.ImpermanentLoss(openPrice, p) * h
+	}
+
+	netPnL := new(big.Rat).Sub(closeValue, openValue)
+	netPnL.Sub(netPnL, deposits)
+	netPnL.Add(netPnL, withdrawn)
+
+	return map[string]interface{}{
+		"deposits":         ratIntStr(deposits),
+		"withdrawals":      ratIntStr(withdrawn),
+		"feesEarned":       intStr(feesE8),
+		"priceChange":      ratIntStr(priceChange),
+		"impermanentLoss":  intStr(int64(math.Round(il))),
+		"netPnL":           ratIntStr(netPnL),
+		"openingValueRune": ratIntStr(openValue),
+		"closingValueRune": ratIntStr(closeValue),
+	}
+}
+
+// UsdPools are the stable coin pools which price RUNE in USD. The deepest one
+// present applies.
+var usdPools = []string{
+	"BNB.BUSD-BD1",
+	"ETH.USDT-0XDAC17F958D2EE523A2206206994597C13D831EC7",
+}
+
+// RunePriceUSD returns the USD per RUNE, or nil when no USD pool is present.
+func runePriceUSD(assetE8DepthPerPool, runeE8DepthPerPool map[string]int64) *big.Rat {
+	var deepest string
+	for _, pool := range usdPools {
+		if assetE8DepthPerPool[pool] != 0 && runeE8DepthPerPool[pool] > runeE8DepthPerPool[deepest] {
+			deepest = pool
+		}
+	}
+	if deepest == "" {
+		return nil
+	}
+	return big.NewRat(assetE8DepthPerPool[deepest], runeE8DepthPerPool[deepest])
+}
+
+// PoolVolumeLookup gets the RUNE value swapped in pool within the window, with
+// the asset side at the price of the depths, and the liquidity fees in RUNE.
+func poolVolumeLookup(ctx context.Context, asset string, window stat.Window, assetDepth, runeDepth int64) (volume *big.Rat, feesE8 int64, err error) {
+	fromRune, err := stat.PoolSwapsFromRuneLookup(ctx, asset, window)
+	if err != nil {
+		return nil, 0, err
+	}
+	toRune, err := stat.PoolSwapsToRuneLookup(ctx, asset, window)
+	if err != nil {
+		return nil, 0, err
+	}
+	volume = big.NewRat(fromRune.RuneE8Total, 1)
+	if assetDepth != 0 {
+		price := big.NewRat(runeDepth, assetDepth)
+		volume.Add(volume, price.Mul(price, big.NewRat(toRune.AssetE8Total, 1)))
+	}
+	return volume, fromRune.LiqFeeInRuneE8Total + toRune.LiqFeeInRuneE8Total, nil
+}
+
+// Pool alert thresholds, other than the ones from Mimir.
+const (
+	alertMinDepthUSD      = 10000
+	alertMaxVolumeDecline = 0.5
+	alertMaxHHI           = 5000
+	alertMaxSlipBP        = 500
+	alertSlipSampleSize   = 100
+)
+
+// PoolAlertInput has the measurements for the pool alert rules.
+type poolAlertInput struct {
+	Status       string
+	RuneDepth    int64
+	MinimumDepth int64    // RUNE from Mimir
+	DepthUSD     *big.Rat // nil when unknown
+	Volume24h    *big.Rat
+	VolumePrev   *big.Rat // the 24 hours before
+	HHI          float64
+	SlipBPs      []int64 // most recent swaps
+}
+
+func serveV1PoolAlerts(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	var in poolAlertInput
+	in.Status, err = timeseries.PoolStatus(ctx, asset, time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	if in.Status == "" {
+		http.Error(w, fmt.Sprintf("no pool %q", asset), http.StatusNotFound)
+		return
+	}
+	mimir, err := timeseries.Mimir(ctx, time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	in.MinimumDepth = mimirInt(mimir, "MinimumPoolDepth", defaultMinimumPoolDepth)
+
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	assetDepth, runeDepth := assetE8DepthPerPool[asset], runeE8DepthPerPool[asset]
+	in.RuneDepth = runeDepth
+	if price := runePriceUSD(assetE8DepthPerPool, runeE8DepthPerPool); price != nil {
+		in.DepthUSD = price.Mul(price, big.NewRat(poolValueInRune(runeDepth), 1e8))
+	}
+
+	until := timestamp.Add(1)
+	in.Volume24h, _, err = poolVolumeLookup(ctx, asset, stat.Window{Since: until.Add(-24 * time.Hour), Until: until}, assetDepth, runeDepth)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	in.VolumePrev, _, err = poolVolumeLookup(ctx, asset, stat.Window{Since: until.Add(-48 * time.Hour), Until: until.Add(-24 * time.Hour)}, assetDepth, runeDepth)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	c, err := stat.LPConcentration(ctx, asset)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	in.HHI = c.HHI
+	swaps, err := stat.PoolRecentSwapsLookup(ctx, asset, alertSlipSampleSize)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	for _, s := range swaps {
+		in.SlipBPs = append(in.SlipBPs, s.TradeSlipBP)
+	}
+
+	respJSON(w, poolAlerts(&in))
+}
+
+// PoolAlerts evaluates the rules on in, with an empty array for no alerts.
+func poolAlerts(in *poolAlertInput) []interface{} {
+	alerts := make([]interface{}, 0)
+	alert := func(rule, severity, message string, value, threshold float64) {
+		alerts = append(alerts, map[string]interface{}{
+			"rule":      rule,
+			"severity":  severity,
+			"message":   message,
+			"value":     strconv.FormatFloat(value, 'f', -1, 64),
+			"threshold": strconv.FormatFloat(threshold, 'f', -1, 64),
+		})
+	}
+
+	if !strings.EqualFold(in.Status, "Enabled") {
+		alert("pool_status", "critical", fmt.Sprintf("pool status is %q", in.Status), 0, 0)
+	}
+	if in.DepthUSD != nil {
+		if usd, _ := in.DepthUSD.Float64(); usd < alertMinDepthUSD {
+			alert("depth_usd", "critical", "pool depth below USD threshold", usd, alertMinDepthUSD)
+		}
+	}
+	if in.RuneDepth < in.MinimumDepth {
+		alert("depth_minimum", "warning", "RUNE depth below the Mimir minimum pool depth", float64(in.RuneDepth), float64(in.MinimumDepth))
+	}
+	if in.VolumePrev.Sign() > 0 {
+		decline := new(big.Rat).Quo(in.Volume24h, in.VolumePrev)
+		decline.Sub(big.NewRat(1, 1), decline)
+		if f, _ := decline.Float64(); f > alertMaxVolumeDecline {
+			alert("volume_decline", "warning", "24h volume declined compared to the 24 hours before", f, alertMaxVolumeDecline)
+		}
+	}
+	if in.HHI > alertMaxHHI {
+		alert("concentration", "warning", "liquidity concentrated with few providers", in.HHI, alertMaxHHI)
+	}
+	if len(in.SlipBPs) != 0 {
+		var sum int64
+		for _, bp := range in.SlipBPs {
+			sum += bp
+		}
+		if avg := float64(sum) / float64(len(in.SlipBPs)); avg > alertMaxSlipBP {
+			alert("slip", "warning", fmt.Sprintf("average slip of last %d swaps too high", len(in.SlipBPs)), avg, alertMaxSlipBP)
+		}
+	}
+	return alerts
+}
+
+var poolCorrelationWindowDays = map[string]int64{"30d": 30, "90d": 90}
+
+// PoolCorrelationMinPoints is the minimum number of days for a coefficient.
+const poolCorrelationMinPoints = 3
+
+// SignificanceLevels are the thresholds reported, strictest first.
+var significanceLevels = []float64{0.001, 0.01, 0.05}
+
+// ServeV1PoolCorrelations correlates the RUNE depth of a pool with the price
+// of a reference pool, by day.
+func serveV1PoolCorrelations(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	days := int64(30)
+	if s := q.Get("window"); s != "" {
+		var ok bool
+		days, ok = poolCorrelationWindowDays[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown window %q, want 30d or 90d", s), http.StatusBadRequest)
+			return
+		}
+	}
+	reference := "BTC.BTC"
+	if s := q.Get("referenceAsset"); s != "" {
+		reference, err = normalizeAsset(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, timestamp, _ := timeseries.LastBlock()
+	window := stat.Window{Since: timestamp.Add(-time.Duration(days) * 24 * time.Hour), Until: timestamp.Add(1)}
+	c, err := stat.PoolCorrelation(r.Context(), asset, reference, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	if c.Count < poolCorrelationMinPoints {
+		http.Error(w, fmt.Sprintf("%d days with a %s price in window, need at least %d", c.Count, reference, poolCorrelationMinPoints), http.StatusUnprocessableEntity)
+		return
+	}
+
+	var level interface{} // null when not significant
+	for _, l := range significanceLevels {
+		if c.PValue < l {
+			level = strconv.FormatFloat(l, 'f', -1, 64)
+			break
+		}
+	}
+	respJSON(w, map[string]interface{}{
+		"referenceAsset":         reference,
+		"correlationCoefficient": strconv.FormatFloat(c.Coefficient, 'f', -1, 64),
+		"windowDays":             intStr(days),
+		"pValue":                 strconv.FormatFloat(c.PValue, 'f', -1, 64),
+		"significanceLevel":      level,
+	})
+}
+
+func serveV1UniqueTraders(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := stat.PoolTradersBucketsLookup(r.Context(), asset, interval, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	array := make([]map[string]interface{}, len(buckets))
+	for i, b := range buckets {
+		array[i] = map[string]interface{}{
+			"time":             b.Time.Unix(),
+			"newTraders":       intStr(b.New),
+			"returningTraders": intStr(b.Returning),
+			"totalUniqueEver":  intStr(b.Total),
+		}
+	}
+	respJSON(w, array)
+}
+
+var feeComparisonWindowDays = map[string]int64{"7d": 7, "30d": 30}
+
+func serveV1FeeComparison(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	days := int64(30)
+	if s := r.URL.Query().Get("window"); s != "" {
+		var ok bool
+		days, ok = feeComparisonWindowDays[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown window %q, want 7d or 30d", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, timestamp, _ := timeseries.LastBlock()
+	window := stat.Window{Since: timestamp.Add(-time.Duration(days) * 24 * time.Hour), Until: timestamp.Add(1)}
+	apys, err := stat.AllPoolsAPY(r.Context(), window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	apy, ok := apys[asset]
+	if !ok {
+		http.Error(w, fmt.Sprintf("no depth for pool %q at the start of the window", asset), http.StatusNotFound)
+		return
+	}
+	avg, median, rank := feeAPYRank(apys, asset)
+
+	total := len(apys)
+	respJSON(w, map[string]interface{}{
+		"pool":                 asset,
+		"poolFeeAPY":           strconv.FormatFloat(apy, 'f', -1, 64),
+		"networkAverageFeeAPY": strconv.FormatFloat(avg, 'f', -1, 64),
+		"networkMedianFeeAPY":  strconv.FormatFloat(median, 'f', -1, 64),
+		"poolRankByAPY":        intStr(int64(rank)),
+		"totalPools":           intStr(int64(total)),
+		"percentileRank":       ratFloatStr(big.NewRat(int64(total-rank)*100, int64(total))),
+	})
+}
+
+// FeeAPYRank returns the mean and the median of apys, and the position of
+// pool, with the highest yield at rank 1. Equal yields share the best rank.
+func feeAPYRank(apys map[string]float64, pool string) (avg, median float64, rank int) {
+	sorted := make([]float64, 0, len(apys))
+	for _, v := range apys {
+		sorted = append(sorted, v)
+		avg += v
+	}
+	if len(sorted) == 0 {
+		return 0, 0, 0
+	}
+	avg /= float64(len(sorted))
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+	if n := len(sorted); n%2 == 1 {
+		median = sorted[n/2]
+	} else {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	rank = 1
+	for _, v := range sorted {
+		if v > apys[pool] {
+			rank++
+		}
+	}
+	return avg, median, rank
+}
+
+func serveV1PoolRebalancing(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	if _, ok := runeE8DepthPerPool[asset]; !ok {
+		http.Error(w, fmt.Sprintf("pool %q not found", asset), http.StatusNotFound)
+		return
+	}
+	var pooled int64
+	for _, depth := range runeE8DepthPerPool {
+		pooled += depth
+	}
+
+	v, err := nodeAccountsCache.get(func() (interface{}, error) {
+		return notinchain.NodeAccountsLookup()
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	var bonded int64
+	for _, node := range v.([]*notinchain.NodeAccount) {
+		if node.Status == "active" {
+			bonded += node.Bond
+		}
+	}
+
+	mimir, err := timeseries.Mimir(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	emissionCurve := mimirInt(mimir, "EmissionCurve", defaultEmissionCurve)
+	if emissionCurve <= 0 {
+		respError(w, r, fmt.Errorf("unusable emission curve %d", emissionCurve))
+		return
+	}
+	network, err := notinchain.NetworkLookup()
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	annualEmission := big.NewRat(network.TotalReserve, emissionCurve)
+
+	share := poolShareFactor(bonded, pooled)
+	// full bond reward at 0 maps to -1; full stake reward at 1 maps to +1
+	pendulum := new(big.Rat).Sub(new(big.Rat).Mul(share, big.NewRat(2, 1)), big.NewRat(1, 1))
+
+	bondAPR, stakeAPR := new(big.Rat), new(big.Rat)
+	if bonded != 0 {
+		bondReward := new(big.Rat).Mul(annualEmission, new(big.Rat).Sub(big.NewRat(1, 1), share))
+		bondAPR.Quo(bondReward, big.NewRat(bonded, 1))
+	}
+	if pooled != 0 {
+		// liquidity is valued at both sides of the pools
+		poolReward := new(big.Rat).Mul(annualEmission, share)
+		stakeAPR.Quo(poolReward, big.NewRat(2*pooled, 1))
+	}
+	higherAPR := "bond"
+	if stakeAPR.Cmp(bondAPR) > 0 {
+		higherAPR = "stake"
+	}
+
+	m := map[string]interface{}{
+		"targetRatio":       ratFloatStr(secureBondRatio),
+		"incentivePendulum": ratFloatStr(pendulum),
+		"bondAPR":           ratFloatStr(bondAPR),
+		"stakeAPR":          ratFloatStr(stakeAPR),
+		"higherAPR":         higherAPR,
+	}
+	if pooled != 0 {
+		m["currentBondToPoolRatio"] = ratFloatStr(big.NewRat(bonded, pooled))
+	}
+	respJSON(w, m)
+}
+
+// PoolShareFactor returns the portion of the block rewards which goes to the
+// liquidity providers, as (b − s) ÷ (b + s) from the whitepaper, with b for the
+// bonded RUNE and s for the pooled RUNE. The nodes get everything once the bond
+// no longer exceeds the pooled amount. At the 2:1 target stakers get a third.
+func poolShareFactor(bondedE8, pooledE8 int64) *big.Rat {
+	if bondedE8 <= pooledE8 {
+		return new(big.Rat)
+	}
+	return big.NewRat(bondedE8-pooledE8, bondedE8+pooledE8)
+}
+
+// Withdraw percentages in the exit impact.
+var exitImpactPercents = []int64{25, 50, 75, 100}
+
+func serveV1ExitImpact(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	addr := r.URL.Query().Get("address")
+	if addr == "" {
+		http.Error(w, "need address parameter", http.StatusBadRequest)
+		return
+	}
+
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	window := stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)}
+	position, err := stat.StakerPoolPositionLookup(r.Context(), asset, addr, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	if position.Units <= 0 {
+		http.Error(w, fmt.Sprintf("address %q has no stake in pool %s", addr, asset), http.StatusNotFound)
+		return
+	}
+	poolUnits, err := poolUnitsLookup(r.Context(), asset, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	if poolUnits <= 0 {
+		http.Error(w, fmt.Sprintf("no units in pool %s", asset), http.StatusNotFound)
+		return
+	}
+
+	respJSON(w, map[string]interface{}{
+		"units":         intStr(position.Units),
+		"poolUnits":     intStr(poolUnits),
+		"shareOfPool":   ratFloatStr(big.NewRat(position.Units, poolUnits)),
+		"withdrawSteps": exitImpact(position.Units, poolUnits, assetE8DepthPerPool[asset], runeE8DepthPerPool[asset]),
+	})
+}
+
+// ExitImpact returns the effect of withdrawing each of the exitImpactPercents
+// of units. A symmetric withdrawal keeps the spot price, yet it thins the pool.
+// The price impact of any swap is inversely proportional to the depth, so the
+// priceImpactPercent is the increase of the price impact for the swaps which
+// follow. A withdrawal of the entire pool has no price impact to report.
+func exitImpact(units, poolUnits, assetDepthE8, runeDepthE8 int64) []interface{} {
+	steps := make([]interface{}, len(exitImpactPercents))
+	for i, percent := range exitImpactPercents {
+		withdrawn := big.NewRat(units*percent, poolUnits*100)
+		runeE8 := new(big.Rat).Mul(withdrawn, big.NewRat(runeDepthE8, 1))
+		assetE8 := new(big.Rat).Mul(withdrawn, big.NewRat(assetDepthE8, 1))
+		remainingRuneE8 := new(big.Rat).Sub(big.NewRat(runeDepthE8, 1), runeE8)
+
+		m := map[string]interface{}{
+			"withdrawPercent": intStr(percent),
+			"runeReceived":    ratIntStr(runeE8),
+			"assetReceived":   ratIntStr(assetE8),
+			"newPoolDepth":    ratIntStr(new(big.Rat).Mul(remainingRuneE8, big.NewRat(2, 1))),
+		}
+		if remainingRuneE8.Sign() > 0 {
+			// depth ÷ new depth − 1
+			impact := new(big.Rat).Quo(big.NewRat(runeDepthE8, 1), remainingRuneE8)
+			impact.Sub(impact, big.NewRat(1, 1))
+			m["priceImpactPercent"] = ratFloatStr(impact.Mul(impact, big.NewRat(100, 1)))
+		}
+		steps[i] = m
+	}
+	return steps
+}
+
+var poolsAggregatedCache = timedCache{TTL: 60 * time.Second}
+
+func serveV1PoolsAggregated(w http.ResponseWriter, r *http.Request) {
+	v, err := poolsAggregatedCache.get(func() (interface{}, error) {
+		return poolsAggregated(r.Context())
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	respJSON(w, v)
+}
+
+// PoolsAggregated sums the statistics of all pools.
+func poolsAggregated(ctx context.Context) (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	pools, err := timeseries.Pools(ctx, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := timeseries.PoolsByStatus(ctx, "Enabled", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	stakers, err := timeseries.StakeAddrs(ctx, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	daily := stat.Window{Since: timestamp.Add(-24 * time.Hour), Until: timestamp}
+
+	volumes := make([]*big.Rat, len(pools))
+	feesE8 := make([]int64, len(pools))
+	err = forEachParallel(ctx, len(pools), 10, func(ctx context.Context, i int) error {
+		var err error
+		asset := pools[i]
+		volumes[i], feesE8[i], err = poolVolumeLookup(ctx, asset, daily, assetE8DepthPerPool[asset], runeE8DepthPerPool[asset])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	swappers, err := stat.SwapperCountLookup(ctx, stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)})
+	if err != nil {
+		return nil, err
+	}
+	apys, err := stat.AllPoolsAPY(ctx, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp.Add(1)})
+	if err != nil {
+		return nil, err
+	}
+	avgAPY, medianAPY, _ := feeAPYRank(apys, "")
+
+	volume := new(big.Rat)
+	var totalFeesE8 int64
+	for i := range pools {
+		volume.Add(volume, volumes[i])
+		totalFeesE8 += feesE8[i]
+	}
+	runeTVL, assetTVL := tvlInRune(assetE8DepthPerPool, runeE8DepthPerPool)
+
+	return map[string]interface{}{
+		"poolCount":             intStr(int64(len(pools))),
+		"enabledPoolCount":      intStr(int64(len(enabled))),
+		"totalRuneDepth":        intStr(runeTVL),
+		"totalAssetDepthInRune": intStr(assetTVL),
+		"totalTVL":              intStr(runeTVL + assetTVL),
+		"totalVolume24h":        ratIntStr(volume),
+		"totalFees24h":          intStr(totalFeesE8),
+		"totalUniqueStakers":    intStr(int64(len(stakers))),
+		"totalUniqueSwappers":   intStr(swappers),
+		"medianPoolAPY":         strconv.FormatFloat(medianAPY, 'f', -1, 64),
+		"averagePoolAPY":        strconv.FormatFloat(avgAPY, 'f', -1, 64),
+	}, nil
+}
+
+func serveV1PoolStakeEvents(w http.ResponseWriter, r *http.Request) {
+	pool, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := pageParams(r, 50, 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := stat.PoolStakeEventList(r.Context(), pool, window, limit, offset)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	// stake events lack the asset address
+	array := make([]interface{}, len(events))
+	for i, e := range events {
+		array[i] = map[string]interface{}{
+			"txID":     e.TxID,
+			"runeAddr": e.RuneAddr,
+			"runeE8":   intStr(e.RuneE8),
+			"assetE8":  intStr(e.AssetE8),
+			"units":    intStr(e.Units),
+			"time":     e.Time.Unix(),
+		}
+	}
+	respJSON(w, array)
+}
+
+func serveV1PoolUnstakeEvents(w http.ResponseWriter, r *http.Request) {
+	pool, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := pageParams(r, 50, 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := stat.PoolUnstakeEventList(r.Context(), pool, window, limit, offset)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	array := make([]interface{}, len(events))
+	for i, e := range events {
+		m := map[string]interface{}{
+			"txID":        e.TxID,
+			"runeE8":      intStr(e.RuneE8),
+			"assetE8":     intStr(e.AssetE8),
+			"units":       intStr(e.Units),
+			"basisPoints": intStr(e.BasisPoints),
+			"time":        e.Time.Unix(),
+		}
+		// the request comes from either side
+		if e.AssetE8 != 0 {
+			m["assetAddr"] = e.FromAddr
+		} else {
+			m["runeAddr"] = e.FromAddr
+		}
+		array[i] = m
+	}
+	respJSON(w, array)
+}
+
+// Maximum number of liquidity providers in the coverage.
+const coverageMaxLPs = 500
+
+func serveV1PoolCoverage(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mimir, err := timeseries.Mimir(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	fullDays := mimirInt(mimir, "FullILPProtection", defaultFullILPProtection)
+
+	positions, err := stat.PoolLPPositionsLookup(r.Context(), asset, coverageMaxLPs)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	poolUnits, err := poolUnitsLookup(r.Context(), asset, stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	lps := make([]interface{}, len(positions))
+	coverageSum := new(big.Rat)
+	var fullCount, partialCount, noneCount int64
+	for i := range positions {
+		p := &positions[i]
+		holdDays := int64(timestamp.Sub(p.FirstStake) / (24 * time.Hour))
+		coverage := ilpCoverage(holdDays, fullDays)
+		switch {
+		case coverage.Cmp(big.NewRat(1, 1)) >= 0:
+			fullCount++
+		case coverage.Sign() > 0:
+			partialCount++
+		default:
+			noneCount++
+		}
+		coverageSum.Add(coverageSum, coverage)
+
+		lossE8 := ilInRune(&p.StakerPoolPosition, poolUnits, runeE8DepthPerPool[asset], assetE8DepthPerPool[asset])
+		lps[i] = map[string]interface{}{
+			"address":                 p.Addr,
+			"holdDays":                intStr(holdDays),
+			"coveragePercent":         ratFloatStr(new(big.Rat).Mul(coverage, big.NewRat(100, 1))),
+			"estimatedProtectionRune": ratIntStr(lossE8.Mul(lossE8, coverage)),
+		}
+	}
+
+	aggregates := map[string]interface{}{
+		"fullyProtectedCount":     intStr(fullCount),
+		"partiallyProtectedCount": intStr(partialCount),
+		"unprotectedCount":        intStr(noneCount),
+	}
+	if len(positions) != 0 {
+		avg := coverageSum.Quo(coverageSum, big.NewRat(int64(len(positions)), 1))
+		aggregates["avgCoverage"] = ratFloatStr(avg.Mul(avg, big.NewRat(100, 1)))
+	}
+	respJSON(w, map[string]interface{}{
+		"poolLPs":    lps,
+		"aggregates": aggregates,
+	})
+}
+
+// Maximum number of pool pairs in the correlation matrix.
+const correlationMatrixMaxPairs = 50
+
+func serveV1PoolCorrelationMatrix(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	metric := stat.MetricTVL
+	if s := q.Get("metric"); s != "" {
+		metric = s
+		if metric != stat.MetricTVL && metric != stat.MetricVolume && metric != stat.MetricPrice {
+			http.Error(w, fmt.Sprintf("unknown metric %q, want tvl, volume or price", s), http.StatusBadRequest)
+			return
+		}
+	}
+	days := int64(30)
+	if s := q.Get("window"); s != "" {
+		var ok bool
+		days, ok = poolCorrelationWindowDays[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown window %q, want 30d or 90d", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	enabled, err := timeseries.PoolsByStatus(r.Context(), "Enabled", time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	pools := make([]string, len(enabled))
+	var found bool
+	for i, c := range enabled {
+		pools[i] = c.Pool
+		found = found || c.Pool == asset
+	}
+	if !found {
+		http.Error(w, fmt.Sprintf("pool %q is not enabled", asset), http.StatusNotFound)
+		return
+	}
+
+	_, timestamp, _ := timeseries.LastBlock()
+	window := stat.Window{Since: timestamp.Add(-time.Duration(days) * 24 * time.Hour), Until: timestamp.Add(1)}
+	series := make([][]float64, len(pools))
+	err = forEachParallel(r.Context(), len(pools), 4, func(ctx context.Context, i int) error {
+		var err error
+		series[i], err = stat.PoolMetricTimeSeries(ctx, pools[i], metric, window)
+		return err
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	respJSON(w, map[string]interface{}{
+		"metric":     metric,
+		"windowDays": intStr(days),
+		"pairs":      correlationPairs(pools, series, correlationMatrixMaxPairs),
+	})
+}
+
+// CorrelationPairs correlates the series of each pool pair, and it returns up
+// to max pairs with the strongest correlation first. Pairs with too few points
+// in common are omitted.
+func correlationPairs(pools []string, series [][]float64, max int) []interface{} {
+	type pair struct {
+		i, j int
+		c    *stat.Correlation
+	}
+	var pairs []pair
+	for i := range pools {
+		for j := i + 1; j < len(pools); j++ {
+			c := stat.SeriesCorrelation(series[i], series[j])
+			if c.Count >= poolCorrelationMinPoints {
+				pairs = append(pairs, pair{i, j, c})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return math.Abs(pairs[a].c.Coefficient) > math.Abs(pairs[b].c.Coefficient)
+	})
+	if len(pairs) > max {
+		pairs = pairs[:max]
+	}
+
+	array := make([]interface{}, len(pairs))
+	for k, p := range pairs {
+		array[k] = map[string]interface{}{
+			"pool1":       pools[p.i],
+			"pool2":       pools[p.j],
+			"correlation": strconv.FormatFloat(p.c.Coefficient, 'f', -1, 64),
+		}
+	}
+	return array
+}
+
+// PriceMoverWindows are the accepted values of the window URL parameter.
+var priceMoverWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+func serveV1TopGainers(w http.ResponseWriter, r *http.Request) {
+	servePriceMovers(w, r, true)
+}
+
+func serveV1TopLosers(w http.ResponseWriter, r *http.Request) {
+	servePriceMovers(w, r, false)
+}
+
+// ServePriceMovers lists the pools with the largest price change, with either
+// the highest first for gainers, or the lowest first otherwise.
+func servePriceMovers(w http.ResponseWriter, r *http.Request, gainers bool) {
+	period := 24 * time.Hour
+	if s := r.URL.Query().Get("window"); s != "" {
+		var ok bool
+		period, ok = priceMoverWindows[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown window %q, want 1h, 24h or 7d", s), http.StatusBadRequest)
+			return
+		}
+	}
+	limit, _, err := pageParams(r, 10, 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pools, err := timeseries.Pools(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	window := stat.Window{Since: timestamp.Add(-period), Until: timestamp.Add(1)}
+
+	changes := make([]*stat.PriceChange, len(pools))
+	volumes := make([]*big.Rat, len(pools))
+	err = forEachParallel(r.Context(), len(pools), 10, func(ctx context.Context, i int) error {
+		var err error
+		asset := pools[i]
+		changes[i], err = stat.PoolPriceChange(ctx, asset, window)
+		if err != nil {
+			return err
+		}
+		volumes[i], _, err = poolVolumeLookup(ctx, asset, window, assetE8DepthPerPool[asset], runeE8DepthPerPool[asset])
+		return err
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	// pools without a start price have no change
+	var indices []int
+	for i, c := range changes {
+		if c.StartPrice != 0 && c.EndPrice != 0 {
+			indices = append(indices, i)
+		}
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		if gainers {
+			return changes[indices[a]].ChangePercent > changes[indices[b]].ChangePercent
+		}
+		return changes[indices[a]].ChangePercent < changes[indices[b]].ChangePercent
+	})
+	if len(indices) > limit {
+		indices = indices[:limit]
+	}
+
+	array := make([]interface{}, len(indices))
+	for k, i := range indices {
+		c := changes[i]
+		array[k] = map[string]interface{}{
+			"asset":              pools[i],
+			"priceChangePercent": strconv.FormatFloat(c.ChangePercent, 'f', -1, 64),
+			"priceStart":         strconv.FormatFloat(c.StartPrice, 'f', -1, 64),
+			"priceEnd":           strconv.FormatFloat(c.EndPrice, 'f', -1, 64),
+			"volume":             ratIntStr(volumes[i]),
+		}
+	}
+	respJSON(w, array)
+}
+
+func serveV1LargeTransactions(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s := r.URL.Query().Get("minRuneValue")
+	if s == "" {
+		http.Error(w, "need minRuneValue parameter", http.StatusBadRequest)
+		return
+	}
+	minRuneE8, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || minRuneE8 < 0 {
+		http.Error(w, fmt.Sprintf("minRuneValue parameter %q is not a non-negative integer", s), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, _, err := pageParams(r, 50, 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	txs, err := stat.LargeTransactions(r.Context(), asset, minRuneE8, window, limit)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	array := make([]interface{}, len(txs))
+	for i, tx := range txs {
+		array[i] = map[string]interface{}{
+			"type":    tx.Type,
+			"txID":    tx.TxID,
+			"address": tx.Addr,
+			"runeE8":  intStr(tx.RuneE8),
+			"assetE8": intStr(tx.AssetE8),
+			"pool":    asset,
+			"time":    tx.Time.Unix(),
+		}
+	}
+	respJSON(w, array)
+}
+
+// LongWindowDays are the accepted values of the window URL parameter for
+// long-term statistics.
+var longWindowDays = map[string]int64{"30d": 30, "90d": 90, "365d": 365}
+
+func serveV1PoolTurnover(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	days := int64(30)
+	if s := r.URL.Query().Get("window"); s != "" {
+		var ok bool
+		days, ok = longWindowDays[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown window %q, want 30d, 90d or 365d", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, timestamp, _ := timeseries.LastBlock()
+	window := stat.Window{Since: timestamp.Add(-time.Duration(days) * 24 * time.Hour), Until: timestamp.Add(1)}
+	t, err := stat.PoolTurnoverLookup(r.Context(), asset, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	m := map[string]interface{}{
+		"newCapitalInRuneE8":  intStr(t.NewCapitalRuneE8),
+		"exitedCapitalRuneE8": intStr(t.ExitedCapitalRuneE8),
+		"averageHoldingDays":  strconv.FormatFloat(t.AvgHoldingDays, 'f', -1, 64),
+		"windowDays":          intStr(days),
+	}
+	if t.AvgDepthRuneE8 != 0 {
+		m["turnoverRate"] = strconv.FormatFloat(float64(t.ExitedCapitalRuneE8)/t.AvgDepthRuneE8, 'f', -1, 64)
+	}
+	respJSON(w, m)
+}
+
+// DirectionWindows are the accepted values of the window URL parameter.
+var directionWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// DominantDirectionCaches has a *timedCache per pool asset and window.
+var dominantDirectionCaches sync.Map
+
+func serveV1DominantDirection(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	windowName := r.URL.Query().Get("window")
+	if windowName == "" {
+		windowName = "24h"
+	}
+	period, ok := directionWindows[windowName]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown window %q, want 1h, 6h, 24h or 7d", windowName), http.StatusBadRequest)
+		return
+	}
+	if !knownPool(asset) {
+		http.Error(w, fmt.Sprintf("pool %s not found", asset), http.StatusNotFound)
+		return
+	}
+
+	c, _ := dominantDirectionCaches.LoadOrStore(asset+" "+windowName, &timedCache{TTL: 6 * time.Second})
+	v, err := c.(*timedCache).get(func() (interface{}, error) {
+		return dominantDirection(r.Context(), asset, period)
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	respJSON(w, v)
+}
+
+// DominantDirection compares the swaps from RUNE, i.e., buys of the asset, with
+// the swaps to RUNE within period before the latest block. Sells count at the
+// current pool price.
+func dominantDirection(ctx context.Context, asset string, period time.Duration) (map[string]interface{}, error) {
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	window := stat.Window{Since: timestamp.Add(-period), Until: timestamp.Add(1)}
+	buys, err := stat.PoolSwapsFromRuneLookup(ctx, asset, window)
+	if err != nil {
+		return nil, err
+	}
+	sells, err := stat.PoolSwapsToRuneLookup(ctx, asset, window)
+	if err != nil {
+		return nil, err
+	}
+
+	buyE8 := float64(buys.RuneE8Total)
+	var sellE8 float64
+	if assetDepth := assetE8DepthPerPool[asset]; assetDepth != 0 {
+		sellE8 = float64(sells.AssetE8Total) * float64(runeE8DepthPerPool[asset]) / float64(assetDepth)
+	}
+	index, direction := swapPressure(buyE8, sellE8)
+
+	m := map[string]interface{}{
+		"buyCount":          intStr(buys.TxCount),
+		"sellCount":         intStr(sells.TxCount),
+		"buyVolumeRune":     intStr(int64(buyE8)),
+		"sellVolumeRune":    intStr(int64(sellE8)),
+		"dominantDirection": direction,
+		"pressureIndex":     strconv.FormatFloat(index, 'f', -1, 64),
+	}
+	if total := buyE8 + sellE8; total != 0 {
+		m["buyRatio"] = strconv.FormatFloat(buyE8/total, 'f', -1, 64)
+		m["sellRatio"] = strconv.FormatFloat(sellE8/total, 'f', -1, 64)
+	}
+	return m, nil
+}
+
+// SwapPressure returns (buy − sell) ÷ (buy + sell), from -1 for sells only to
+// +1 for buys only, with the side which prevails.
+func swapPressure(buyE8, sellE8 float64) (index float64, direction string) {
+	if total := buyE8 + sellE8; total != 0 {
+		index = (buyE8 - sellE8) / total
+	}
+	switch {
+	case index > 0:
+		return index, "buy"
+	case index < 0:
+		return index, "sell"
+	default:
+		return 0, "neutral"
+	}
+}
+
+// LPClusterRules classify liquidity providers by their behaviour. Each
+// provider counts in the first cluster it matches only.
+var lpClusterRules = []struct {
+	Name        string
+	Description string
+	Match       func(*stat.LPBehavior) bool
+}{
+	{"whale passive holders", "held for over a year with less than 3 transactions", func(b *stat.LPBehavior) bool {
+		return b.HoldTime > 365*24*time.Hour && b.TxCount < 3
+	}},
+	{"frequent traders", "more than one stake or unstake per month", func(b *stat.LPBehavior) bool {
+		return b.TxPerMonth > 1
+	}},
+	{"arbitrageurs", "swap volume over 10 times the staked value", func(b *stat.LPBehavior) bool {
+		return b.SwapStakeRatio > 10
+	}},
+}
+
+func serveV1LPClusters(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lps, err := stat.LPBehaviorMetrics(r.Context(), asset)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	_, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	poolUnits, err := poolUnitsLookup(r.Context(), asset, stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	respJSON(w, map[string]interface{}{
+		"clusters": lpClusters(lps, poolUnits, runeE8DepthPerPool[asset]),
+	})
+}
+
+// LPClusters counts the providers per cluster, with their units valued at
+// both sides of the pool. Providers without a match are left out.
+func lpClusters(lps []stat.LPBehavior, poolUnits, runeDepth int64) []interface{} {
+	counts := make([]int64, len(lpClusterRules))
+	units := make([]int64, len(lpClusterRules))
+	for i := range lps {
+		for j, rule := range lpClusterRules {
+			if rule.Match(&lps[i]) {
+				counts[j]++
+				units[j] += lps[i].Units
+				break
+			}
+		}
+	}
+
+	clusters := make([]interface{}, len(lpClusterRules))
+	for i, rule := range lpClusterRules {
+		value := new(big.Rat)
+		if poolUnits != 0 {
+			value.SetFrac64(2*runeDepth, poolUnits)
+			value.Mul(value, big.NewRat(units[i], 1))
+		}
+		clusters[i] = map[string]interface{}{
+			"name":           rule.Name,
+			"description":    rule.Description,
+			"addressCount":   intStr(counts[i]),
+			"totalUnitsRune": ratIntStr(value),
+		}
+	}
+	return clusters
+}
+
+// DepthMAPeriods are the accepted values of the period URL parameter, in days.
+var depthMAPeriods = map[string]int{"7": 7, "14": 14, "30": 30, "50": 50, "200": 200}
+
+func serveV1DepthMA(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	period := 30
+	if s := q.Get("period"); s != "" {
+		var ok bool
+		period, ok = depthMAPeriods[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown period %q, want 7, 14, 30, 50 or 200", s), http.StatusBadRequest)
+			return
+		}
+	}
+	maType := stat.SimpleMovingAverage
+	if s := q.Get("type"); s != "" {
+		if s != stat.SimpleMovingAverage && s != stat.ExponentialMovingAverage {
+			http.Error(w, fmt.Sprintf("unknown type %q, want sma or ema", s), http.StatusBadRequest)
+			return
+		}
+		maType = s
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-90 * 24 * time.Hour), Until: timestamp})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	points, err := stat.DepthMovingAverage(r.Context(), asset, period, maType, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	array := make([]interface{}, len(points))
+	for i, p := range points {
+		array[i] = map[string]interface{}{
+			"time":      p.Time.Unix(),
+			"runeDepth": intStr(p.RuneE8),
+			"ma":        strconv.FormatFloat(p.MA, 'f', -1, 64),
+		}
+	}
+	respJSON(w, array)
+}
+
+// CohortIntervals are the accepted values of the cohortInterval URL parameter.
+var cohortIntervals = map[string]time.Duration{
+	"month":   30 * 24 * time.Hour,
+	"quarter": 90 * 24 * time.Hour,
+}
+
+func serveV1LPRetention(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval := cohortIntervals["month"]
+	if s := r.URL.Query().Get("cohortInterval"); s != "" {
+		var ok bool
+		interval, ok = cohortIntervals[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown cohortInterval %q, want month or quarter", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	cohorts, err := stat.LPRetentionCohorts(r.Context(), asset, interval)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	respJSON(w, lpRetention(cohorts, interval, timestamp))
+}
+
+// LPRetention omits the milestones which are not reached yet by all providers
+// of a cohort.
+func lpRetention(cohorts []stat.RetentionCohort, interval time.Duration, now time.Time) []interface{} {
+	names := []string{"stillActiveAt30d", "stillActiveAt90d", "stillActiveAt1y"}
+	array := make([]interface{}, len(cohorts))
+	for i, c := range cohorts {
+		m := map[string]interface{}{
+			"cohortPeriod": c.Start.Unix(),
+			"initialLPs":   intStr(c.InitialLPs),
+		}
+		for j, milestone := range stat.RetentionMilestones {
+			if !c.Start.Add(interval + milestone).After(now) {
+				m[names[j]] = intStr(c.StillActive[j])
+			}
+		}
+		array[i] = m
+	}
+	return array
+}
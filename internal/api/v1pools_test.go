@@ -0,0 +1,325 @@
+package api
+
+import (
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/thorchain/midgard/internal/timeseries"
+	"gitlab.com/thorchain/midgard/internal/timeseries/stat"
+)
+
+func TestIlInRune(t *testing.T) {
+	// 100 asset and 100 RUNE staked for 10 out of 110 units at price 1
+	p := &stat.StakerPoolPosition{AssetE8: 100, RuneE8: 100, Units: 10}
+
+	// no price change
+	if got := ilInRune(p, 110, 1100, 1100); got.Sign() != 0 {
+		t.Errorf("same price got loss %s, want 0", got.RatString())
+	}
+	// price 4× with a constant product gives 20% loss on 500 RUNE held
+	if got := ilInRune(p, 110, 2200, 550); got.Cmp(big.NewRat(100, 1)) != 0 {
+		t.Errorf("price 4× got loss %s, want 100", got.RatString())
+	}
+	// empty pool
+	if got := ilInRune(p, 0, 0, 0); got.Sign() != 0 {
+		t.Errorf("empty pool got loss %s, want 0", got.RatString())
+	}
+}
+
+func TestPoolPathParam(t *testing.T) {
+	for _, p := range []string{
+		"/v1/pools/bnb.bnb/roi",
+		"/v1/pools/BNB.BNB/members/count",
+		"/v1/pools/BNB.BNB/depth/at",
+	} {
+		got, err := poolPathParam(httptest.NewRequest(http.MethodGet, p, nil))
+		if err != nil {
+			t.Errorf("%s: %s", p, err)
+		} else if got != "BNB.BNB" {
+			t.Errorf("%s: got asset %q, want BNB.BNB", p, got)
+		}
+	}
+}
+
+func TestInactiveReason(t *testing.T) {
+	for _, test := range []struct {
+		status, previous, want string
+	}{
+		{"Bootstrap", "", "not activated yet"},
+		{"Bootstrap", "Enabled", "returned to bootstrap from enabled"},
+		{"Suspended", "Enabled", "suspended from enabled"},
+		{"Suspended", "", "suspended"},
+	} {
+		c := timeseries.PoolStatusChange{Pool: "BNB.BNB", Status: test.status, Previous: test.previous}
+		if got := inactiveReason(c); got != test.want {
+			t.Errorf("%s after %q got reason %q, want %q", test.status, test.previous, got, test.want)
+		}
+	}
+}
+
+func TestArbitrageVolume(t *testing.T) {
+	// 100 asset and 100 RUNE at price 1
+	if got := arbitrageVolume(100, 100, 1); got != 0 {
+		t.Errorf("at parity got %f RUNE, want 0", got)
+	}
+	// price 4 at 50 asset and 200 RUNE
+	if got := arbitrageVolume(100, 100, 4); math.Abs(got-100) > 1e-9 {
+		t.Errorf("to price 4 got %f RUNE, want 100", got)
+	}
+	// price 0.25 at 200 asset and 50 RUNE
+	if got := arbitrageVolume(100, 100, 0.25); math.Abs(got-50) > 1e-9 {
+		t.Errorf("to price 0.25 got %f RUNE, want 50", got)
+	}
+}
+
+func TestBalanceCheckJSON(t *testing.T) {
+	tests := []struct {
+		midgard, reported int64
+		balanced          bool
+	}{
+		{100000, 100000, true},
+		{100000, 100100, true},
+		{100000, 99900, true},
+		{100000, 100101, false},
+		{100000, 0, false},
+		{0, 0, true},
+		{0, 1, false},
+	}
+	for _, test := range tests {
+		got := balanceCheckJSON("BNB.BNB", test.midgard, test.reported)["isBalanced"]
+		if got != test.balanced {
+			t.Errorf("depth %d with vault balance %d got balanced %v, want %t", test.midgard, test.reported, got, test.balanced)
+		}
+	}
+}
+
+func TestQuarterIncome(t *testing.T) {
+	// 10% of a pool with 1000 asset and 1000 RUNE
+	opening := lpSnapshot{Units: 10, PoolUnits: 100, AssetDepth: 1000, RuneDepth: 1000}
+	// price 4× with a constant product, plus a deposit of 10 RUNE and 10 asset
+	closing := lpSnapshot{Units: 12, PoolUnits: 100, AssetDepth: 500, RuneDepth: 2000}
+	stakes := &stat.PoolStakes{AssetE8Total: 10, RuneE8Total: 10}
+	withdrawals := new(stat.PoolWithdrawals)
+
+	got := quarterIncome(opening, closing, stakes, withdrawals, 7)
+	want := map[string]interface{}{
+		"deposits":         "50",
+		"withdrawals":      "0",
+		"feesEarned":       "7",
+		"priceChange":      "300",
+		"impermanentLoss":  "-100",
+		"netPnL":           "230",
+		"openingValueRune": "200",
+		"closingValueRune": "480",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("got %s %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestQuarterIncomeWithdrawal(t *testing.T) {
+	// half of 10% withdrawn at a constant price
+	opening := lpSnapshot{Units: 10, PoolUnits: 100, AssetDepth: 1000, RuneDepth: 1000}
+	closing := lpSnapshot{Units: 5, PoolUnits: 100, AssetDepth: 1000, RuneDepth: 1000}
+	withdrawals := &stat.PoolWithdrawals{AssetE8Total: 50, RuneE8Total: 50}
+
+	got := quarterIncome(opening, closing, new(stat.PoolStakes), withdrawals, 0)
+	if got["withdrawals"] != "100" || got["netPnL"] != "0" {
+		t.Errorf("got withdrawals %v and net PnL %v, want 100 and 0", got["withdrawals"], got["netPnL"])
+	}
+}
+
+func TestPoolAlerts(t *testing.T) {
+	healthy := poolAlertInput{
+		Status:       "Enabled",
+		RuneDepth:    20000e8,
+		MinimumDepth: 10000e8,
+		DepthUSD:     big.NewRat(50000, 1),
+		Volume24h:    big.NewRat(60, 1),
+		VolumePrev:   big.NewRat(100, 1),
+		HHI:          1200,
+		SlipBPs:      []int64{10, 20, 30},
+	}
+	if got := poolAlerts(&healthy); len(got) != 0 {
+		t.Errorf("healthy pool got alerts %v", got)
+	}
+
+	sick := poolAlertInput{
+		Status:       "Bootstrap",
+		RuneDepth:    5000e8,
+		MinimumDepth: 10000e8,
+		DepthUSD:     big.NewRat(9000, 1),
+		Volume24h:    big.NewRat(40, 1),
+		VolumePrev:   big.NewRat(100, 1),
+		HHI:          6000,
+		SlipBPs:      []int64{400, 700},
+	}
+	got := poolAlerts(&sick)
+	want := []string{"pool_status", "depth_usd", "depth_minimum", "volume_decline", "concentration", "slip"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d alerts %v, want %d", len(got), got, len(want))
+	}
+	for i, rule := range want {
+		if m := got[i].(map[string]interface{}); m["rule"] != rule {
+			t.Errorf("alert %d got rule %v, want %s", i, m["rule"], rule)
+		}
+	}
+}
+
+func TestFeeAPYRank(t *testing.T) {
+	apys := map[string]float64{"A.A": 0.1, "B.B": 0.4, "C.C": 0.2, "D.D": 0.3}
+	avg, median, rank := feeAPYRank(apys, "C.C")
+	if math.Abs(avg-0.25) > 1e-12 || math.Abs(median-0.25) > 1e-12 || rank != 3 {
+		t.Errorf("got average %g, median %g and rank %d, want 0.25, 0.25 and 3", avg, median, rank)
+	}
+	if _, _, rank := feeAPYRank(apys, "B.B"); rank != 1 {
+		t.Errorf("highest got rank %d, want 1", rank)
+	}
+	apys["E.E"] = 0.4
+	if _, median, rank := feeAPYRank(apys, "E.E"); median != 0.3 || rank != 1 {
+		t.Errorf("tie got median %g and rank %d, want 0.3 and 1", median, rank)
+	}
+}
+
+func TestPoolShareFactor(t *testing.T) {
+	for _, tc := range []struct {
+		bonded, pooled int64
+		want           *big.Rat
+	}{
+		{200, 100, big.NewRat(1, 3)},
+		{100, 0, big.NewRat(1, 1)},
+		{100, 100, new(big.Rat)},
+		{50, 100, new(big.Rat)},
+		{0, 0, new(big.Rat)},
+	} {
+		if got := poolShareFactor(tc.bonded, tc.pooled); got.Cmp(tc.want) != 0 {
+			t.Errorf("bonded %d pooled %d got %s, want %s", tc.bonded, tc.pooled, got, tc.want)
+		}
+	}
+}
+
+func TestExitImpact(t *testing.T) {
+	// 10 out of 40 units on 400 asset and 800 RUNE
+	steps := exitImpact(10, 40, 400, 800)
+	if len(steps) != 4 {
+		t.Fatalf("got %d steps, want 4", len(steps))
+	}
+	half := steps[1].(map[string]interface{})
+	if half["runeReceived"] != "100" || half["assetReceived"] != "50" || half["newPoolDepth"] != "1400" {
+		t.Errorf("half withdraw got %+v, want 100 RUNE and 50 asset for a 1400 depth", half)
+	}
+	full := steps[3].(map[string]interface{})
+	if full["priceImpactPercent"] != "33.333333333333336" {
+		t.Errorf("full withdraw got price impact %v, want 33.333333333333336", full["priceImpactPercent"])
+	}
+
+	all := exitImpact(40, 40, 400, 800)[3].(map[string]interface{})
+	if _, ok := all["priceImpactPercent"]; ok {
+		t.Errorf("withdraw of the entire pool got price impact %v", all["priceImpactPercent"])
+	}
+}
+
+func TestIlpCoverage(t *testing.T) {
+	for _, tc := range []struct {
+		holdDays, fullDays int64
+		want               *big.Rat
+	}{
+		{0, 100, new(big.Rat)},
+		{-1, 100, new(big.Rat)},
+		{25, 100, big.NewRat(1, 4)},
+		{100, 100, big.NewRat(1, 1)},
+		{150, 100, big.NewRat(1, 1)},
+		{5, 0, big.NewRat(1, 1)},
+	} {
+		if got := ilpCoverage(tc.holdDays, tc.fullDays); got.Cmp(tc.want) != 0 {
+			t.Errorf("%d of %d days got %s, want %s", tc.holdDays, tc.fullDays, got, tc.want)
+		}
+	}
+}
+
+func TestCorrelationPairs(t *testing.T) {
+	pools := []string{"A.A", "B.B", "C.C"}
+	series := [][]float64{
+		{1, 2, 3, 4},
+		{4, 3, 2, 1},
+		{1, 3, 2, 4},
+	}
+	got := correlationPairs(pools, series, 2)
+	if len(got) != 2 {
+		t.Fatalf("got %d pairs, want 2", len(got))
+	}
+	first := got[0].(map[string]interface{})
+	if first["pool1"] != "A.A" || first["pool2"] != "B.B" || first["correlation"] != "-1" {
+		t.Errorf("got first pair %v, want A.A and B.B with -1", first)
+	}
+}
+
+func TestSwapPressure(t *testing.T) {
+	for _, tc := range []struct {
+		buy, sell float64
+		index     float64
+		direction string
+	}{
+		{300, 100, 0.5, "buy"},
+		{100, 300, -0.5, "sell"},
+		{0, 100, -1, "sell"},
+		{100, 100, 0, "neutral"},
+		{0, 0, 0, "neutral"},
+	} {
+		index, direction := swapPressure(tc.buy, tc.sell)
+		if index != tc.index || direction != tc.direction {
+			t.Errorf("buy %g and sell %g got %g %s, want %g %s", tc.buy, tc.sell, index, direction, tc.index, tc.direction)
+		}
+	}
+}
+
+func TestLPClusters(t *testing.T) {
+	year := 365 * 24 * time.Hour
+	lps := []stat.LPBehavior{
+		{Addr: "whale", Units: 40, HoldTime: 2 * year, TxCount: 1, TxPerMonth: 0.04},
+		{Addr: "both", Units: 10, HoldTime: 2 * year, TxCount: 2, SwapStakeRatio: 20},
+		{Addr: "trader", Units: 20, HoldTime: 30 * 24 * time.Hour, TxCount: 4, TxPerMonth: 4},
+		{Addr: "arb", Units: 5, HoldTime: year, TxCount: 2, TxPerMonth: 0.2, SwapStakeRatio: 11},
+		{Addr: "none", Units: 25, HoldTime: year, TxCount: 3, TxPerMonth: 0.25},
+	}
+	// 2 × 1000 RUNE for 100 units
+	got := lpClusters(lps, 100, 1000)
+	want := []struct{ count, rune string }{
+		{"2", "1000"},
+		{"1", "400"},
+		{"1", "100"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d clusters, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		m := got[i].(map[string]interface{})
+		if m["addressCount"] != w.count || m["totalUnitsRune"] != w.rune {
+			t.Errorf("cluster %d got %v, want %s addresses with %s RUNE", i, m, w.count, w.rune)
+		}
+	}
+}
+
+func TestLPRetention(t *testing.T) {
+	const day = 24 * time.Hour
+	start := time.Unix(0, 0)
+	cohorts := []stat.RetentionCohort{
+		{Start: start, InitialLPs: 3, StillActive: []int64{2, 1, 1}},
+		{Start: start.Add(300 * day), InitialLPs: 1, StillActive: []int64{1, 1, 1}},
+	}
+	got := lpRetention(cohorts, 30*day, start.Add(400*day))
+	first, second := got[0].(map[string]interface{}), got[1].(map[string]interface{})
+	if first["stillActiveAt30d"] != "2" || first["stillActiveAt90d"] != "1" || first["stillActiveAt1y"] != "1" {
+		t.Errorf("got first cohort %v, want all milestones", first)
+	}
+	// 300 + 30 + 90 days is beyond 400 days
+	if second["stillActiveAt30d"] != "1" || second["stillActiveAt90d"] != nil || second["stillActiveAt1y"] != nil {
+		t.Errorf("got second cohort %v, want the 30 day milestone only", second)
+	}
+}
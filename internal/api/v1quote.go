@@ -0,0 +1,540 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/thorchain/midgard/event"
+	"gitlab.com/thorchain/midgard/internal/timeseries"
+	"gitlab.com/thorchain/midgard/internal/timeseries/stat"
+)
+
+func serveV1QuoteSwap(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	fromAsset, err := normalizeAsset(q.Get("fromAsset"))
+	if err != nil {
+		http.Error(w, "fromAsset parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	toAsset, err := normalizeAsset(q.Get("toAsset"))
+	if err != nil {
+		http.Error(w, "toAsset parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	amount, err := strconv.ParseInt(q.Get("amount"), 10, 64)
+	if err != nil || amount <= 0 {
+		http.Error(w, fmt.Sprintf("amount parameter %q is not a positive integer", q.Get("amount")), http.StatusBadRequest)
+		return
+	}
+
+	mimir, err := timeseries.Mimir(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	feeBP := mimirInt(mimir, "LiquidityFeeBasisPoints", 0)
+
+	assetE8DepthPerPool, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	quote, err := quoteSwap(fromAsset, toAsset, amount, assetE8DepthPerPool, runeE8DepthPerPool, feeBP)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respJSON(w, map[string]interface{}{
+		"fromAsset":        fromAsset,
+		"toAsset":          toAsset,
+		"inputAmount":      intStr(amount),
+		"outputAmount":     ratIntStr(quote.Output),
+		"fee":              ratIntStr(quote.Fee),
+		"slip":             ratFloatStr(quote.Slip),
+		"priceImpact":      ratFloatStr(quote.PriceImpact),
+		"isDoubleSwap":     quote.IntermediaryPool != "",
+		"intermediaryPool": quote.IntermediaryPool,
+	})
+}
+
+// SwapQuote is an estimate with the current depths.
+type swapQuote struct {
+	Output           *big.Rat // in the to asset, with fees deducted
+	Fee              *big.Rat // in the to asset
+	Slip             *big.Rat // ratio of the output lost to depth
+	PriceImpact      *big.Rat // ratio of the output lost compared to the pool price
+	IntermediaryPool string   // first pool of a double swap, if any
+}
+
+// QuoteSwap estimates a swap of amount. Swaps between two non-RUNE assets
+// route through RUNE, in which case each pool applies a fee.
+func quoteSwap(fromAsset, toAsset string, amount int64, assetE8DepthPerPool, runeE8DepthPerPool map[string]int64, feeBP int64) (*swapQuote, error) {
+	fromRune, toRune := event.IsRune([]byte(fromAsset)), event.IsRune([]byte(toAsset))
+	if fromAsset == toAsset || fromRune && toRune {
+		return nil, fmt.Errorf("no swap from %s to %s", fromAsset, toAsset)
+	}
+	for _, asset := range []string{fromAsset, toAsset} {
+		if !event.IsRune([]byte(asset)) && (assetE8DepthPerPool[asset] <= 0 || runeE8DepthPerPool[asset] <= 0) {
+			return nil, fmt.Errorf("no depth in pool %s", asset)
+		}
+	}
+
+	x := big.NewRat(amount, 1)
+	var quote swapQuote
+	switch {
+	case toRune:
+		X, Y := big.NewRat(assetE8DepthPerPool[fromAsset], 1), big.NewRat(runeE8DepthPerPool[fromAsset], 1)
+		quote.Output, quote.Fee = swapLeg(x, X, Y, feeBP)
+		quote.Slip = new(big.Rat).Quo(x, new(big.Rat).Add(X, x))
+		quote.PriceImpact = priceImpact(quote.Output, x, X, Y)
+
+	case fromRune:
+		X, Y := big.NewRat(runeE8DepthPerPool[toAsset], 1), big.NewRat(assetE8DepthPerPool[toAsset], 1)
+		quote.Output, quote.Fee = swapLeg(x, X, Y, feeBP)
+		quote.Slip = new(big.Rat).Quo(x, new(big.Rat).Add(X, x))
+		quote.PriceImpact = priceImpact(quote.Output, x, X, Y)
+
+	default:
+		X1, Y1 := big.NewRat(assetE8DepthPerPool[fromAsset], 1), big.NewRat(runeE8DepthPerPool[fromAsset], 1)
+		runeOut, runeFee := swapLeg(x, X1, Y1, feeBP)
+		X2, Y2 := big.NewRat(runeE8DepthPerPool[toAsset], 1), big.NewRat(assetE8DepthPerPool[toAsset], 1)
+		quote.Output, quote.Fee = swapLeg(runeOut, X2, Y2, feeBP)
+
+		// RUNE fee of the first pool at the price of the second pool
+		runeFee.Mul(runeFee, Y2)
+		quote.Fee.Add(quote.Fee, runeFee.Quo(runeFee, X2))
+
+		// 1 − (1 − slip₁) × (1 − slip₂)
+		keep1 := new(big.Rat).Quo(X1, new(big.Rat).Add(X1, x))
+		keep2 := new(big.Rat).Quo(X2, new(big.Rat).Add(X2, runeOut))
+		quote.Slip = new(big.Rat).Sub(big.NewRat(1, 1), keep1.Mul(keep1, keep2))
+
+		// pool price of both pools combined
+		X, Y := new(big.Rat).Mul(X1, X2), new(big.Rat).Mul(Y1, Y2)
+		quote.PriceImpact = priceImpact(quote.Output, x, X, Y)
+		quote.IntermediaryPool = fromAsset
+	}
+	return &quote, nil
+}
+
+// SwapLeg returns the output of x against depths X and Y, with the constant
+// product formula y = (x × Y) ÷ (X + x). The fee is deducted from the output.
+func swapLeg(x, X, Y *big.Rat, feeBP int64) (y, fee *big.Rat) {
+	y = new(big.Rat).Mul(x, Y)
+	y.Quo(y, new(big.Rat).Add(X, x))
+	fee = new(big.Rat).Mul(y, big.NewRat(feeBP, 10000))
+	return y.Sub(y, fee), fee
+}
+
+// PriceImpact returns the ratio of y lost compared to x at the price Y ÷ X.
+func priceImpact(y, x, X, Y *big.Rat) *big.Rat {
+	atPrice := new(big.Rat).Mul(x, Y)
+	atPrice.Quo(atPrice, X)
+	impact := new(big.Rat).Quo(y, atPrice)
+	return impact.Sub(big.NewRat(1, 1), impact)
+}
+
+func serveV1QuoteStake(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	asset, err := normalizeAsset(q.Get("asset"))
+	if err != nil {
+		http.Error(w, "asset parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	runeAmount, assetAmount, err := stakeAmountParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	window := stat.Window{Since: time.Unix(0, 0), Until: timestamp}
+	stakes, err := stat.PoolStakesLookup(r.Context(), asset, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	unstakes, err := stat.PoolUnstakesLookup(r.Context(), asset, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	poolUnits := stakes.StakeUnitsTotal - unstakes.StakeUnitsTotal
+
+	runeDepth, assetDepth := runeE8DepthPerPool[asset], assetE8DepthPerPool[asset]
+	units, err := stakeUnits(poolUnits, runeDepth, assetDepth, runeAmount, assetAmount)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// share of the pool after the stake
+	share := new(big.Rat)
+	if total := new(big.Rat).Add(big.NewRat(poolUnits, 1), units); total.Sign() != 0 {
+		share.Quo(units, total)
+	}
+	runeValue := new(big.Rat).Mul(share, big.NewRat(poolValueInRune(runeDepth+runeAmount), 1))
+
+	respJSON(w, map[string]interface{}{
+		"estimatedUnits":     ratIntStr(units),
+		"poolShare":          ratFloatStr(share),
+		"currentPoolUnits":   intStr(poolUnits),
+		"estimatedRuneValue": ratIntStr(runeValue),
+	})
+}
+
+// StakeAmountParams returns the runeAmount and assetAmount URL parameters.
+// At least one of them is required.
+func stakeAmountParams(r *http.Request) (runeAmount, assetAmount int64, err error) {
+	q := r.URL.Query()
+	if s := q.Get("runeAmount"); s != "" {
+		runeAmount, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || runeAmount < 0 {
+			return 0, 0, fmt.Errorf("runeAmount parameter %q is not a non-negative integer", s)
+		}
+	}
+	if s := q.Get("assetAmount"); s != "" {
+		assetAmount, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || assetAmount < 0 {
+			return 0, 0, fmt.Errorf("assetAmount parameter %q is not a non-negative integer", s)
+		}
+	}
+	if runeAmount == 0 && assetAmount == 0 {
+		return 0, 0, errors.New("need runeAmount and/or assetAmount parameter")
+	}
+	return runeAmount, assetAmount, nil
+}
+
+// StakeUnits returns the pool units for a stake of r RUNE and a asset, with
+// P pool units, R RUNE depth and A asset depth, as
+// units = P × (r × A + a × R) ÷ (2 × R × A). The first stake into a pool gets
+// its RUNE amount in units.
+func stakeUnits(P, R, A, r, a int64) (*big.Rat, error) {
+	if P == 0 || R == 0 || A == 0 {
+		if P != 0 {
+			return nil, errors.New("pool units without depth")
+		}
+		return big.NewRat(r, 1), nil
+	}
+	units := new(big.Rat).Add(big.NewRat(r, 1), new(big.Rat).Mul(big.NewRat(a, A), big.NewRat(R, 1)))
+	units.Mul(units, big.NewRat(P, 2*R))
+	return units, nil
+}
+
+func serveV1QuoteUnstake(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	asset, err := normalizeAsset(q.Get("asset"))
+	if err != nil {
+		http.Error(w, "asset parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	units, err := strconv.ParseInt(q.Get("units"), 10, 64)
+	if err != nil || units <= 0 {
+		http.Error(w, fmt.Sprintf("units parameter %q is not a positive integer", q.Get("units")), http.StatusBadRequest)
+		return
+	}
+	basisPoints := int64(10000)
+	if s := q.Get("basisPoints"); s != "" {
+		basisPoints, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || basisPoints < 0 || basisPoints > 10000 {
+			http.Error(w, fmt.Sprintf("basisPoints parameter %q is not an integer in [0, 10000]", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	window := stat.Window{Since: time.Unix(0, 0), Until: timestamp}
+	stakes, err := stat.PoolStakesLookup(r.Context(), asset, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	unstakes, err := stat.PoolUnstakesLookup(r.Context(), asset, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	poolUnits := stakes.StakeUnitsTotal - unstakes.StakeUnitsTotal
+
+	runeDepth, assetDepth := runeE8DepthPerPool[asset], assetE8DepthPerPool[asset]
+	runeOut, assetOut, unitsReturned, err := unstakeAmounts(units, poolUnits, runeDepth, assetDepth, basisPoints)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m := map[string]interface{}{
+		"estimatedRuneE8":  ratIntStr(runeOut),
+		"estimatedAssetE8": ratIntStr(assetOut),
+		"unitsReturned":    intStr(unitsReturned),
+		"remainingUnits":   intStr(units - unitsReturned),
+	}
+
+	if addr := q.Get("address"); addr != "" {
+		addrStakes, err := stat.PoolStakesAddrLookup(r.Context(), asset, addr, window)
+		if err != nil {
+			respError(w, r, err)
+			return
+		}
+		// entry price is the average of all stakes
+		if addrStakes.AssetE8Total != 0 && assetDepth != 0 {
+			entryPrice := float64(addrStakes.RuneE8Total) / float64(addrStakes.AssetE8Total)
+			currentPrice := float64(runeDepth) / float64(assetDepth)
+			m["impermanentLoss"] = strconv.FormatFloat(stat.ImpermanentLoss(entryPrice, currentPrice), 'f', -1, 64)
+		}
+	}
+
+	respJSON(w, m)
+}
+
+// UnstakeAmounts returns the share of the depths for units, with the
+// withdrawal of basisPoints applied.
+func unstakeAmounts(units, poolUnits, runeDepth, assetDepth, basisPoints int64) (runeOut, assetOut *big.Rat, unitsReturned int64, err error) {
+	if units > poolUnits {
+		return nil, nil, 0, fmt.Errorf("units %d exceed the %d pool units", units, poolUnits)
+	}
+	fraction := big.NewRat(units, poolUnits)
+	fraction.Mul(fraction, big.NewRat(basisPoints, 10000))
+	runeOut = new(big.Rat).Mul(fraction, big.NewRat(runeDepth, 1))
+	assetOut = new(big.Rat).Mul(fraction, big.NewRat(assetDepth, 1))
+	return runeOut, assetOut, units * basisPoints / 10000, nil
+}
+
+// ServeV1PriceImpact quotes swaps against the pool only. The depths need no
+// cache, as AssetAndRuneDepths is an in-memory snapshot of the last block.
+func serveV1PriceImpact(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	direction := q.Get("direction")
+	if direction != "buy" && direction != "sell" {
+		http.Error(w, fmt.Sprintf("direction parameter %q is not buy or sell", direction), http.StatusBadRequest)
+		return
+	}
+	var amounts []int64
+	for _, s := range append(q["amount"], strings.Split(q.Get("amounts"), ",")...) {
+		if s == "" {
+			continue
+		}
+		amount, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || amount <= 0 {
+			http.Error(w, fmt.Sprintf("amount %q is not a positive integer", s), http.StatusBadRequest)
+			return
+		}
+		amounts = append(amounts, amount)
+	}
+	if len(amounts) == 0 {
+		http.Error(w, "need amount or amounts parameter", http.StatusBadRequest)
+		return
+	}
+
+	mimir, err := timeseries.Mimir(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	feeBP := mimirInt(mimir, "LiquidityFeeBasisPoints", 0)
+
+	assetE8DepthPerPool, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	assetDepth, runeDepth := assetE8DepthPerPool[asset], runeE8DepthPerPool[asset]
+	if assetDepth <= 0 || runeDepth <= 0 {
+		http.Error(w, fmt.Sprintf("pool %s has no depth", asset), http.StatusNotFound)
+		return
+	}
+	// buy asset with RUNE, or sell asset for RUNE
+	fromAsset, toAsset, inputDepth := event.Rune, asset, runeDepth
+	if direction == "sell" {
+		fromAsset, toAsset, inputDepth = asset, event.Rune, assetDepth
+	}
+	midPrice := big.NewRat(runeDepth, assetDepth)
+
+	array := make([]interface{}, len(amounts))
+	for i, amount := range amounts {
+		// 30% of the depth
+		if amount*10 > inputDepth*3 {
+			http.Error(w, fmt.Sprintf("amount %d exceeds 30%% of the %s input depth %d; the order is too large for a meaningful estimate", amount, direction, inputDepth), http.StatusBadRequest)
+			return
+		}
+		quote, err := quoteSwap(fromAsset, toAsset, amount, assetE8DepthPerPool, runeE8DepthPerPool, feeBP)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// in RUNE per asset
+		effectivePrice := new(big.Rat)
+		if quote.Output.Sign() > 0 {
+			if direction == "buy" {
+				effectivePrice.Quo(big.NewRat(amount, 1), quote.Output)
+			} else {
+				effectivePrice.Quo(quote.Output, big.NewRat(amount, 1))
+			}
+		}
+		array[i] = map[string]interface{}{
+			"inputAmount":    intStr(amount),
+			"outputAmount":   ratIntStr(quote.Output),
+			"priceImpact":    ratFloatStr(quote.PriceImpact),
+			"effectivePrice": ratFloatStr(effectivePrice),
+			"midPrice":       ratFloatStr(midPrice),
+			"feeAmount":      ratIntStr(quote.Fee),
+			"feeBasisPoints": intStr(feeBP),
+		}
+	}
+
+	if len(array) == 1 && q.Get("amounts") == "" {
+		respJSON(w, array[0])
+		return
+	}
+	respJSON(w, array)
+}
+
+func serveV1PoolRouting(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	fromAsset, err := normalizeAsset(q.Get("from"))
+	if err != nil {
+		http.Error(w, "from parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	toAsset, err := normalizeAsset(q.Get("to"))
+	if err != nil {
+		http.Error(w, "to parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	// one unit by default
+	amount := int64(1e8)
+	if s := q.Get("amount"); s != "" {
+		amount, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || amount <= 0 {
+			http.Error(w, fmt.Sprintf("amount parameter %q is not a positive integer", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	mimir, err := timeseries.Mimir(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	feeBP := mimirInt(mimir, "LiquidityFeeBasisPoints", 0)
+
+	assetE8DepthPerPool, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	for _, asset := range []string{fromAsset, toAsset} {
+		if !event.IsRune([]byte(asset)) && (assetE8DepthPerPool[asset] <= 0 || runeE8DepthPerPool[asset] <= 0) {
+			http.Error(w, fmt.Sprintf("no route from %s to %s: no active pool %s", fromAsset, toAsset, asset), http.StatusNotFound)
+			return
+		}
+	}
+	quote, err := quoteSwap(fromAsset, toAsset, amount, assetE8DepthPerPool, runeE8DepthPerPool, feeBP)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	steps := swapRoute(fromAsset, toAsset, amount, assetE8DepthPerPool, runeE8DepthPerPool, feeBP)
+
+	route := make([]map[string]interface{}, len(steps))
+	for i, s := range steps {
+		route[i] = map[string]interface{}{
+			"step":        i + 1,
+			"pool":        s.Pool,
+			"fromAsset":   s.FromAsset,
+			"toAsset":     s.ToAsset,
+			"inputRuneE8": ratIntStr(s.InputRuneE8),
+		}
+	}
+	// fee in the to asset at the pool price
+	feeRune := new(big.Rat).Set(quote.Fee)
+	if !event.IsRune([]byte(toAsset)) {
+		feeRune.Mul(feeRune, big.NewRat(runeE8DepthPerPool[toAsset], assetE8DepthPerPool[toAsset]))
+	}
+	respJSON(w, map[string]interface{}{
+		"route":           route,
+		"estimatedOutput": ratIntStr(quote.Output),
+		"totalFeeRuneE8":  ratIntStr(feeRune),
+		"totalSlipBP":     ratIntStr(new(big.Rat).Mul(quote.Slip, big.NewRat(10000, 1))),
+		"priceImpact":     ratFloatStr(quote.PriceImpact),
+	})
+}
+
+// RouteStep is a swap through a single pool.
+type routeStep struct {
+	Pool        string
+	FromAsset   string
+	ToAsset     string
+	InputRuneE8 *big.Rat // input valued at the pool price
+}
+
+// SwapRoute returns the pools passed by a swap, which must be valid according
+// to quoteSwap. Swaps between two non-RUNE assets take two steps.
+func swapRoute(fromAsset, toAsset string, amount int64, assetE8DepthPerPool, runeE8DepthPerPool map[string]int64, feeBP int64) []routeStep {
+	x := big.NewRat(amount, 1)
+	switch fromRune, toRune := event.IsRune([]byte(fromAsset)), event.IsRune([]byte(toAsset)); {
+	case fromRune:
+		return []routeStep{{Pool: toAsset, FromAsset: fromAsset, ToAsset: toAsset, InputRuneE8: x}}
+	case toRune:
+		X, Y := big.NewRat(assetE8DepthPerPool[fromAsset], 1), big.NewRat(runeE8DepthPerPool[fromAsset], 1)
+		input := new(big.Rat).Mul(x, Y)
+		return []routeStep{{Pool: fromAsset, FromAsset: fromAsset, ToAsset: toAsset, InputRuneE8: input.Quo(input, X)}}
+	default:
+		X, Y := big.NewRat(assetE8DepthPerPool[fromAsset], 1), big.NewRat(runeE8DepthPerPool[fromAsset], 1)
+		input := new(big.Rat).Mul(x, Y)
+		runeOut, _ := swapLeg(x, X, Y, feeBP)
+		return []routeStep{
+			{Pool: fromAsset, FromAsset: fromAsset, ToAsset: event.Rune, InputRuneE8: input.Quo(input, X)},
+			{Pool: toAsset, FromAsset: event.Rune, ToAsset: toAsset, InputRuneE8: runeOut},
+		}
+	}
+}
+
+// MarketDepthLevels are the price moves, in percent, of the depth chart.
+var marketDepthLevels = []float64{1, 2, 5, 10, 25, 50}
+
+// ServeV1MarketDepth serves the pool as an order book. Each level has the
+// RUNE amount which moves the price by the percentage, without fees.
+func serveV1MarketDepth(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	assetE8DepthPerPool, runeE8DepthPerPool, _ := timeseries.AssetAndRuneDepths()
+	assetDepth, runeDepth := assetE8DepthPerPool[asset], runeE8DepthPerPool[asset]
+	if assetDepth <= 0 || runeDepth <= 0 {
+		http.Error(w, fmt.Sprintf("no depth for pool %q", asset), http.StatusNotFound)
+		return
+	}
+
+	mid := float64(runeDepth) / float64(assetDepth)
+	bids := make([]interface{}, len(marketDepthLevels))
+	asks := make([]interface{}, len(marketDepthLevels))
+	for i, percent := range marketDepthLevels {
+		bidPrice := mid * (1 - percent/100)
+		bids[i] = map[string]interface{}{
+			"priceTarget": strconv.FormatFloat(bidPrice, 'f', -1, 64),
+			"depthRune":   intStr(int64(runeToPrice(float64(assetDepth), float64(runeDepth), bidPrice))),
+		}
+		askPrice := mid * (1 + percent/100)
+		asks[i] = map[string]interface{}{
+			"priceTarget": strconv.FormatFloat(askPrice, 'f', -1, 64),
+			"depthRune":   intStr(int64(runeToPrice(float64(assetDepth), float64(runeDepth), askPrice))),
+		}
+	}
+	respJSON(w, map[string]interface{}{
+		"midPrice": strconv.FormatFloat(mid, 'f', -1, 64),
+		"bids":     bids,
+		"asks":     asks,
+	})
+}
+
+// RuneToPrice returns the RUNE moved by the trade which sets the price Y ÷ X
+// to target, with the constant product k = X × Y. The RUNE depth at target is
+// √(k × target). Buys put RUNE in and sells take RUNE out, both as a positive
+// amount.
+func runeToPrice(X, Y, target float64) float64 {
+	return math.Abs(math.Sqrt(X*Y*target) - Y)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+var quoteTestAssetDepths = map[string]int64{"BNB.BNB": 1000, "BTC.BTC": 10}
+var quoteTestRuneDepths = map[string]int64{"BNB.BNB": 5000, "BTC.BTC": 40000}
+
+func TestQuoteSwapSingle(t *testing.T) {
+	// 100 BNB into 1000 BNB and 5000 RUNE
+	quote, err := quoteSwap("BNB.BNB", "THOR.RUNE", 100, quoteTestAssetDepths, quoteTestRuneDepths, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 100 × 5000 ÷ (1000 + 100)
+	if want := big.NewRat(500000, 1100); quote.Output.Cmp(want) != 0 {
+		t.Errorf("got output %s, want %s", quote.Output.RatString(), want.RatString())
+	}
+	if want := big.NewRat(100, 1100); quote.Slip.Cmp(want) != 0 {
+		t.Errorf("got slip %s, want %s", quote.Slip.RatString(), want.RatString())
+	}
+	// without fees the price impact equals slip
+	if quote.PriceImpact.Cmp(quote.Slip) != 0 {
+		t.Errorf("got price impact %s, want slip %s", quote.PriceImpact.RatString(), quote.Slip.RatString())
+	}
+	if quote.IntermediaryPool != "" {
+		t.Errorf("got intermediary pool %q for single swap", quote.IntermediaryPool)
+	}
+}
+
+func TestQuoteSwapFee(t *testing.T) {
+	// 1000 RUNE into 5000 RUNE and 1000 BNB with 30 basis points fee
+	quote, err := quoteSwap("THOR.RUNE", "BNB.BNB", 1000, quoteTestAssetDepths, quoteTestRuneDepths, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1000 × 1000 ÷ (5000 + 1000) = 1000/6
+	if want := big.NewRat(1000*3, 6*1000); quote.Fee.Cmp(want) != 0 {
+		t.Errorf("got fee %s, want %s", quote.Fee.RatString(), want.RatString())
+	}
+	if want := big.NewRat(1000*9970, 6*10000); quote.Output.Cmp(want) != 0 {
+		t.Errorf("got output %s, want %s", quote.Output.RatString(), want.RatString())
+	}
+}
+
+func TestQuoteSwapDouble(t *testing.T) {
+	quote, err := quoteSwap("BNB.BNB", "BTC.BTC", 100, quoteTestAssetDepths, quoteTestRuneDepths, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// BNB pool gives 100 × 5000 ÷ 1100 = 5000/11 RUNE
+	// BTC pool gives 5000/11 × 10 ÷ (40000 + 5000/11) = 50000/445000 BTC
+	if want := big.NewRat(50000, 445000); quote.Output.Cmp(want) != 0 {
+		t.Errorf("got output %s, want %s", quote.Output.RatString(), want.RatString())
+	}
+	if quote.IntermediaryPool != "BNB.BNB" {
+		t.Errorf("got intermediary pool %q, want BNB.BNB", quote.IntermediaryPool)
+	}
+}
+
+func TestQuoteSwapReject(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"BNB.BNB", "BNB.BNB"},
+		{"THOR.RUNE", "BNB.RUNE-67C"},
+		{"ETH.ETH", "THOR.RUNE"},
+	} {
+		if _, err := quoteSwap(pair[0], pair[1], 100, quoteTestAssetDepths, quoteTestRuneDepths, 0); err == nil {
+			t.Errorf("%s to %s got no error", pair[0], pair[1])
+		}
+	}
+}
+
+func TestStakeUnits(t *testing.T) {
+	tests := []struct {
+		P, R, A, r, a int64
+		want          *big.Rat
+	}{
+		// first stake
+		{0, 0, 0, 1000, 500, big.NewRat(1000, 1)},
+		// symmetric stake of 10% gives 10% of the units
+		{100, 1000, 500, 100, 50, big.NewRat(10, 1)},
+		// asymmetric stakes give half of the symmetric equivalent
+		{100, 1000, 500, 100, 0, big.NewRat(5, 1)},
+		{100, 1000, 500, 0, 50, big.NewRat(5, 1)},
+		// P × (r × A + a × R) ÷ (2 × R × A) = 700 × (30 × 200 + 7 × 900) ÷ (2 × 900 × 200)
+		{700, 900, 200, 30, 7, big.NewRat(700*(30*200+7*900), 2*900*200)},
+	}
+	for _, test := range tests {
+		got, err := stakeUnits(test.P, test.R, test.A, test.r, test.a)
+		if err != nil {
+			t.Errorf("%+v got error: %s", test, err)
+			continue
+		}
+		if got.Cmp(test.want) != 0 {
+			t.Errorf("%+v got %s units, want %s", test, got.RatString(), test.want.RatString())
+		}
+	}
+
+	if _, err := stakeUnits(100, 0, 0, 10, 10); err == nil {
+		t.Error("pool units without depth got no error")
+	}
+}
+
+func TestUnstakeAmounts(t *testing.T) {
+	// 10 out of 100 units from 1000 RUNE and 500 asset
+	runeOut, assetOut, unitsReturned, err := unstakeAmounts(10, 100, 1000, 500, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runeOut.Cmp(big.NewRat(100, 1)) != 0 || assetOut.Cmp(big.NewRat(50, 1)) != 0 || unitsReturned != 10 {
+		t.Errorf("full withdrawal got %s RUNE, %s asset and %d units, want 100, 50 and 10", runeOut.RatString(), assetOut.RatString(), unitsReturned)
+	}
+
+	runeOut, assetOut, unitsReturned, err = unstakeAmounts(10, 100, 1000, 500, 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runeOut.Cmp(big.NewRat(50, 1)) != 0 || assetOut.Cmp(big.NewRat(25, 1)) != 0 || unitsReturned != 5 {
+		t.Errorf("half withdrawal got %s RUNE, %s asset and %d units, want 50, 25 and 5", runeOut.RatString(), assetOut.RatString(), unitsReturned)
+	}
+
+	if _, _, _, err := unstakeAmounts(101, 100, 1000, 500, 10000); err == nil {
+		t.Error("units beyond pool units got no error")
+	}
+}
+
+func TestSwapRoute(t *testing.T) {
+	single := swapRoute("BNB.BNB", "THOR.RUNE", 100, quoteTestAssetDepths, quoteTestRuneDepths, 0)
+	if len(single) != 1 || single[0].Pool != "BNB.BNB" {
+		t.Fatalf("BNB to RUNE got route %+v", single)
+	}
+	// 100 BNB at 5 RUNE each
+	if want := big.NewRat(500, 1); single[0].InputRuneE8.Cmp(want) != 0 {
+		t.Errorf("BNB to RUNE got input %s RUNE, want %s", single[0].InputRuneE8.RatString(), want.RatString())
+	}
+
+	double := swapRoute("BNB.BNB", "BTC.BTC", 100, quoteTestAssetDepths, quoteTestRuneDepths, 0)
+	if len(double) != 2 || double[0].Pool != "BNB.BNB" || double[1].Pool != "BTC.BTC" {
+		t.Fatalf("BNB to BTC got route %+v", double)
+	}
+	if double[0].ToAsset != "THOR.RUNE" || double[1].FromAsset != "THOR.RUNE" {
+		t.Errorf("BNB to BTC got route %+v, want through THOR.RUNE", double)
+	}
+	// 100 × 5000 ÷ (1000 + 100)
+	if want := big.NewRat(5000, 11); double[1].InputRuneE8.Cmp(want) != 0 {
+		t.Errorf("BNB to BTC got second input %s RUNE, want %s", double[1].InputRuneE8.RatString(), want.RatString())
+	}
+}
+
+func TestRuneToPrice(t *testing.T) {
+	tests := []struct {
+		X, Y, target, want float64
+	}{
+		{1e10, 1e10, 1, 0},
+		// price 4× needs the RUNE depth doubled
+		{1e10, 1e10, 4, 1e10},
+		// price ¼× needs the RUNE depth halved
+		{1e10, 1e10, 0.25, 5e9},
+		// price 1.25×, from 2 to 2.5, with k = 5e19
+		{5e9, 1e10, 2.5, 1180339887.498949},
+	}
+	for _, test := range tests {
+		got := runeToPrice(test.X, test.Y, test.target)
+		if math.Abs(got-test.want) > 1e-3 {
+			t.Errorf("X %g, Y %g to %g got %f, want %f", test.X, test.Y, test.target, got, test.want)
+		}
+	}
+}
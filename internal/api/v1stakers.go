@@ -0,0 +1,416 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"gitlab.com/thorchain/midgard/internal/timeseries"
+	"gitlab.com/thorchain/midgard/internal/timeseries/stat"
+)
+
+func serveV1StakerHistory(w http.ResponseWriter, r *http.Request) {
+	addr := path.Base(path.Dir(r.URL.Path))
+	window, err := windowParam(r, stat.Window{Since: time.Unix(0, 0), Until: time.Now()})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := pageParams(r, 50, 200)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := stat.StakerEventHistory(r.Context(), addr, window, limit, offset)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	array := make([]interface{}, len(events))
+	for i, e := range events {
+		array[i] = map[string]interface{}{
+			"type": e.Type,
+			"pool": e.Pool,
+			"amounts": map[string]interface{}{
+				"assetE8": intStr(e.AssetE8),
+				"runeE8":  intStr(e.RuneE8),
+			},
+			"height": intStr(e.Height),
+			"time":   e.Time.Unix(),
+		}
+	}
+	respJSON(w, array)
+}
+
+// StakerTxTypes are the accepted values of the type URL parameter.
+var stakerTxTypes = map[string]string{
+	"all":     "",
+	"swap":    "swap",
+	"stake":   "stake",
+	"unstake": "unstake",
+	"refund":  "refund",
+}
+
+func serveV1StakerTransactions(w http.ResponseWriter, r *http.Request) {
+	addr := path.Base(path.Dir(r.URL.Path))
+	window, err := windowParam(r, stat.Window{Since: time.Unix(0, 0), Until: time.Now()})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := pageParams(r, 50, 200)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var txType string
+	if s := r.URL.Query().Get("type"); s != "" {
+		var ok bool
+		txType, ok = stakerTxTypes[s]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown type %q, want all, swap, stake, unstake or refund", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	txs, total, err := stat.StakerTxsLookup(r.Context(), addr, txType, window, limit, offset)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	events := make([]interface{}, len(txs))
+	for i, tx := range txs {
+		m := map[string]interface{}{
+			"type":    tx.Type,
+			"txID":    tx.TxID,
+			"assetE8": intStr(tx.AssetE8),
+			"runeE8":  intStr(tx.RuneE8),
+			"height":  intStr(tx.Height),
+			"time":    tx.Time.Unix(),
+		}
+		switch tx.Type {
+		case "refund":
+			m["asset"] = tx.Asset
+			m["reason"] = tx.Reason
+		case "swap":
+			m["pool"] = tx.Pool
+			m["fromAsset"] = tx.Asset
+		default:
+			m["pool"] = tx.Pool
+		}
+		events[i] = m
+	}
+	respJSON(w, map[string]interface{}{
+		"total":  intStr(total),
+		"page":   intStr(int64(offset/limit + 1)),
+		"events": events,
+	})
+}
+
+func serveV1StakerCount(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if q.Get("history") == "true" {
+		window, err := windowParam(r, stat.Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		interval, err := intervalParam(r, 24*time.Hour)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		counts, err := stat.StakerCountBucketsLookup(r.Context(), interval, window)
+		if err != nil {
+			respError(w, r, err)
+			return
+		}
+		array := make([]interface{}, len(counts))
+		for i, c := range counts {
+			array[i] = map[string]interface{}{
+				"time":  c.Time.Unix(),
+				"count": intStr(c.Count),
+			}
+		}
+		respJSON(w, array)
+		return
+	}
+
+	var n int64
+	if s := q.Get("at"); s != "" {
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("couldn't parse at parameter as Unix seconds: %s", err), http.StatusBadRequest)
+			return
+		}
+		n, err = stat.StakerCountLookup(r.Context(), stat.Window{Until: time.Unix(sec, 0)})
+		if err != nil {
+			respError(w, r, err)
+			return
+		}
+	} else {
+		var err error
+		n, err = stat.StakerCountCachedLookup(r.Context())
+		if err != nil {
+			respError(w, r, err)
+			return
+		}
+	}
+	respJSON(w, map[string]interface{}{
+		"count": intStr(n),
+	})
+}
+
+func serveV1StakerPositions(w http.ResponseWriter, r *http.Request) {
+	addr := path.Base(path.Dir(r.URL.Path))
+
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	window := stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)}
+	pools, err := stat.AllPoolStakesAddrLookup(r.Context(), addr, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	positions := make([]*stat.StakerPoolPosition, len(pools))
+	poolUnits := make([]int64, len(pools))
+	err = forEachParallel(r.Context(), len(pools), 4, func(ctx context.Context, i int) error {
+		var err error
+		positions[i], err = stat.StakerPoolPositionLookup(ctx, pools[i].Asset, addr, window)
+		if err != nil {
+			return err
+		}
+		poolUnits[i], err = poolUnitsLookup(ctx, pools[i].Asset, window)
+		return err
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	totalValue := new(big.Rat)
+	array := make([]interface{}, 0, len(positions))
+	for i, p := range positions {
+		if p.Units <= 0 || poolUnits[i] <= 0 {
+			continue
+		}
+		asset := pools[i].Asset
+		assetDepth, runeDepth := assetE8DepthPerPool[asset], runeE8DepthPerPool[asset]
+
+		share := big.NewRat(p.Units, poolUnits[i])
+		runeValue := new(big.Rat).Mul(share, big.NewRat(runeDepth, 1))
+		assetValue := new(big.Rat).Mul(share, big.NewRat(assetDepth, 1))
+		value := new(big.Rat).Mul(share, big.NewRat(poolValueInRune(runeDepth), 1))
+		totalValue.Add(totalValue, value)
+
+		m := map[string]interface{}{
+			"asset":             asset,
+			"unitsOwned":        intStr(p.Units),
+			"poolShare":         ratFloatStr(share),
+			"currentRuneValue":  ratIntStr(runeValue),
+			"currentAssetValue": ratIntStr(assetValue),
+			"totalValueRune":    ratIntStr(value),
+		}
+		// against the staked amounts at the current price
+		if assetDepth > 0 {
+			staked := new(big.Rat).Mul(big.NewRat(p.AssetE8, 1), big.NewRat(runeDepth, assetDepth))
+			staked.Add(staked, big.NewRat(p.RuneE8, 1))
+			if staked.Sign() > 0 {
+				roi := new(big.Rat).Sub(value, staked)
+				m["unrealizedROI"] = ratFloatStr(roi.Quo(roi, staked))
+			}
+		}
+		array = append(array, m)
+	}
+
+	respJSON(w, map[string]interface{}{
+		"positions":               array,
+		"totalPortfolioValueRune": ratIntStr(totalValue),
+	})
+}
+
+func serveV1StakerRewards(w http.ResponseWriter, r *http.Request) {
+	addr := path.Base(path.Dir(r.URL.Path))
+
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pools, err := stat.AllPoolStakesAddrLookup(r.Context(), addr, stat.Window{Since: time.Unix(0, 0), Until: window.Until})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	if len(pools) == 0 {
+		http.Error(w, fmt.Sprintf("address %q has no stakes", addr), http.StatusNotFound)
+		return
+	}
+
+	fees := make([]*stat.StakerFees, len(pools))
+	claimHeights := make([]int64, len(pools))
+	err = forEachParallel(r.Context(), len(pools), 4, func(ctx context.Context, i int) error {
+		var err error
+		fees[i], err = stat.StakerFeesLookup(ctx, addr, pools[i].Asset, window)
+		if err != nil || fees[i].LastUnstake.IsZero() {
+			return err
+		}
+		claimHeights[i], _, err = timeseries.HeightAtTime(ctx, fees[i].LastUnstake)
+		return err
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	var total int64
+	array := make([]interface{}, len(pools))
+	for i, f := range fees {
+		total += f.RuneE8
+		array[i] = map[string]interface{}{
+			"asset":      pools[i].Asset,
+			"feesEarned": intStr(f.RuneE8),
+			// rewards realise with an unstake
+			"lastClaimHeight": intStr(claimHeights[i]),
+		}
+	}
+	respJSON(w, map[string]interface{}{
+		"pools":            array,
+		"totalRuneRewards": intStr(total),
+	})
+}
+
+// ServeV1StakerPnL serves the profit and loss of an address over all pools, in
+// RUNE. Realised amounts come from the unstakes, against the cost of the units
+// unstaked. The impermanent loss applies to the remaining units, from their
+// entry price to the current one.
+func serveV1StakerPnL(w http.ResponseWriter, r *http.Request) {
+	addr := path.Base(path.Dir(r.URL.Path))
+
+	bases, err := stat.StakerCostBasis(r.Context(), addr)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	if len(bases) == 0 {
+		http.Error(w, fmt.Sprintf("address %q has no stakes", addr), http.StatusNotFound)
+		return
+	}
+
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	window := stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)}
+	fees := make([]*stat.StakerFees, len(bases))
+	poolUnits := make([]int64, len(bases))
+	err = forEachParallel(r.Context(), len(bases), 4, func(ctx context.Context, i int) error {
+		var err error
+		fees[i], err = stat.StakerFeesLookup(ctx, addr, bases[i].Pool, window)
+		if err != nil {
+			return err
+		}
+		poolUnits[i], err = poolUnitsLookup(ctx, bases[i].Pool, window)
+		return err
+	})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	var realized, unrealized, feesEarned int64
+	var il float64
+	for i, b := range bases {
+		realized += b.ProceedsRuneE8 - b.UnstakedCostRuneE8
+		feesEarned += fees[i].RuneE8
+		if b.Units <= 0 || poolUnits[i] <= 0 {
+			continue
+		}
+		assetDepth, runeDepth := assetE8DepthPerPool[b.Pool], runeE8DepthPerPool[b.Pool]
+		value := float64(b.Units) / float64(poolUnits[i]) * float64(poolValueInRune(runeDepth))
+		unrealized += int64(value) - b.CostRuneE8
+		if b.EntryPrice > 0 && assetDepth > 0 {
+			// loss relative to holding: value ÷ held − 1
+			ratio := stat.ImpermanentLoss(b.EntryPrice, float64(runeDepth)/float64(assetDepth))
+			il += value - value/(1+ratio)
+		}
+	}
+
+	respJSON(w, map[string]interface{}{
+		"realizedPnL":     intStr(realized),
+		"unrealizedPnL":   intStr(unrealized),
+		"totalPnL":        intStr(realized + unrealized),
+		"feesEarned":      intStr(feesEarned),
+		"impermanentLoss": intStr(int64(math.Round(il))),
+		"currency":        "RUNE",
+	})
+}
+
+func serveV1StakingSimulation(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	asset, err := normalizeAsset(q.Get("asset"))
+	if err != nil {
+		http.Error(w, "asset parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	runeAmount, assetAmount, err := stakeAmountParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var holdDays int64
+	if s := q.Get("holdDays"); s != "" {
+		holdDays, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || holdDays < 0 {
+			http.Error(w, fmt.Sprintf("holdDays parameter %q is not a non-negative integer", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	mimir, err := timeseries.Mimir(r.Context(), time.Time{})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	fullDays := mimirInt(mimir, "FullILPProtection", defaultFullILPProtection)
+
+	assetE8DepthPerPool, runeE8DepthPerPool, timestamp := timeseries.AssetAndRuneDepths()
+	poolUnits, err := poolUnitsLookup(r.Context(), asset, stat.Window{Since: time.Unix(0, 0), Until: timestamp.Add(1)})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	units, err := stakeUnits(poolUnits, runeE8DepthPerPool[asset], assetE8DepthPerPool[asset], runeAmount, assetAmount)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	share := new(big.Rat)
+	if total := new(big.Rat).Add(big.NewRat(poolUnits, 1), units); total.Sign() != 0 {
+		share.Quo(units, total)
+	}
+
+	// fee run-rate of the last 30 days
+	apys, err := stat.AllPoolsAPY(r.Context(), stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp.Add(1)})
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	respJSON(w, map[string]interface{}{
+		"estimatedUnits":    ratIntStr(units),
+		"poolShare":         ratFloatStr(share),
+		"annualFeeAPY":      strconv.FormatFloat(apys[asset], 'f', -1, 64),
+		"ilIfPriceDoubles":  strconv.FormatFloat(stat.ImpermanentLoss(1, 2), 'f', -1, 64),
+		"ilIfPriceHalves":   strconv.FormatFloat(stat.ImpermanentLoss(1, 0.5), 'f', -1, 64),
+		"ilpCoverageAtExit": ratFloatStr(ilpCoverage(holdDays, fullDays)),
+	})
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"math/big"
+	"net/http"
+	"path"
+	"time"
+
+	"gitlab.com/thorchain/midgard/event"
+	"gitlab.com/thorchain/midgard/internal/timeseries"
+	"gitlab.com/thorchain/midgard/internal/timeseries/stat"
+)
+
+func serveV1SwapsByAddr(w http.ResponseWriter, r *http.Request) {
+	addr := path.Base(r.URL.Path)
+	window, err := windowParam(r, stat.Window{Since: time.Unix(0, 0), Until: time.Now()})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := pageParams(r, 50, 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	swaps, err := stat.SwapsByAddressLookup(r.Context(), addr, window, limit, offset)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	array := make([]interface{}, len(swaps))
+	for i, swap := range swaps {
+		status := "pending"
+		if swap.Complete {
+			status = "complete"
+		}
+		array[i] = map[string]interface{}{
+			"txID":       swap.TxID,
+			"pool":       swap.Pool,
+			"fromAsset":  swap.FromAsset,
+			"toAsset":    swap.ToAsset,
+			"fromAmount": intStr(swap.FromE8),
+			"toAmount":   intStr(swap.ToE8),
+			"fee":        intStr(swap.LiqFeeInRuneE8),
+			"slip":       ratFloatStr(big.NewRat(swap.TradeSlipBP, 10000)),
+			"time":       swap.Time.Unix(),
+			"status":     status,
+		}
+	}
+	respJSON(w, array)
+}
+
+// ServeV1RecentSwaps lists the last swaps of a pool, regardless of time.
+func serveV1RecentSwaps(w http.ResponseWriter, r *http.Request) {
+	pool, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, _, err := pageParams(r, 10, 50)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	swaps, err := stat.PoolRecentSwapsLookup(r.Context(), pool, limit)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	array := make([]interface{}, len(swaps))
+	for i, swap := range swaps {
+		array[i] = map[string]interface{}{
+			"txID":       swap.TxID,
+			"fromAsset":  swap.FromAsset,
+			"toAsset":    swap.ToAsset,
+			"fromAmount": intStr(swap.FromE8),
+			"toAmount":   intStr(swap.ToE8),
+			"fee":        intStr(swap.LiqFeeInRuneE8),
+			"slip":       ratFloatStr(big.NewRat(swap.TradeSlipBP, 10000)),
+			"trader":     swap.FromAddr,
+			"time":       swap.Time.Unix(),
+		}
+	}
+	respJSON(w, array)
+}
+
+func serveV1SwapAggregate(w http.ResponseWriter, r *http.Request) {
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-24 * time.Hour), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pairs, err := stat.SwapAggregateByPair(r.Context(), event.Rune, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	array := make([]interface{}, len(pairs))
+	for i, p := range pairs {
+		m := map[string]interface{}{
+			"fromAsset":    p.FromAsset,
+			"toAsset":      p.ToAsset,
+			"count":        intStr(p.TxCount),
+			"volumeRuneE8": intStr(p.RuneE8Total),
+		}
+		if p.TxCount != 0 {
+			m["avgFeeRuneE8"] = ratIntStr(big.NewRat(p.LiqFeeInRuneE8Total, p.TxCount))
+			m["avgSlip"] = ratFloatStr(big.NewRat(p.TradeSlipBPTotal, p.TxCount*10000))
+		}
+		array[i] = m
+	}
+	respJSON(w, array)
+}
+
+func serveV1SlippageDistribution(w http.ResponseWriter, r *http.Request) {
+	var pool string
+	if s := r.URL.Query().Get("pool"); s != "" {
+		var err error
+		pool, err = normalizeAsset(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-24 * time.Hour), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := stat.SlippageHistogram(r.Context(), pool, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	var total int64
+	for _, b := range buckets {
+		total += b.Count
+	}
+	array := make([]interface{}, len(buckets))
+	for i, b := range buckets {
+		m := map[string]interface{}{
+			"bucketMin": intStr(b.MinBP),
+			"bucketMax": intStr(b.MaxBP),
+			"count":     intStr(b.Count),
+		}
+		if total != 0 {
+			m["percentOfTotal"] = ratFloatStr(big.NewRat(b.Count*100, total))
+		}
+		array[i] = m
+	}
+	respJSON(w, array)
+}
+
+func serveV1SwapSizeDistribution(w http.ResponseWriter, r *http.Request) {
+	asset, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-30 * 24 * time.Hour), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := stat.SwapSizeDistribution(r.Context(), asset, window)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+
+	var totalCount, totalVolume int64
+	for _, b := range buckets {
+		totalCount += b.Count
+		totalVolume += b.VolumeRuneE8
+	}
+	array := make([]interface{}, len(buckets))
+	for i, b := range buckets {
+		m := map[string]interface{}{
+			"bucketMinRune":   intStr(b.MinRuneE8),
+			"count":           intStr(b.Count),
+			"totalVolumeRune": intStr(b.VolumeRuneE8),
+		}
+		if b.MaxRuneE8 != 0 {
+			m["bucketMaxRune"] = intStr(b.MaxRuneE8)
+		}
+		if totalCount != 0 {
+			m["percentOfCount"] = ratFloatStr(big.NewRat(b.Count*100, totalCount))
+		}
+		if totalVolume != 0 {
+			m["percentOfVolume"] = ratFloatStr(new(big.Rat).Mul(big.NewRat(b.VolumeRuneE8, totalVolume), big.NewRat(100, 1)))
+		}
+		array[i] = m
+	}
+	respJSON(w, array)
+}
+
+func serveV1VWAP(w http.ResponseWriter, r *http.Request) {
+	pool, err := poolPathParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, timestamp, _ := timeseries.LastBlock()
+	window, err := windowParam(r, stat.Window{Since: timestamp.Add(-24 * time.Hour), Until: timestamp.Add(1)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := intervalParam(r, time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := stat.PoolVWAP(r.Context(), pool, window, interval)
+	if err != nil {
+		respError(w, r, err)
+		return
+	}
+	array := make([]interface{}, len(buckets))
+	for i, b := range buckets {
+		m := map[string]interface{}{
+			"time":       b.Time.Unix(),
+			"volume":     intStr(b.RuneE8),
+			"tradeCount": intStr(b.TradeCount),
+			"vwap":       nil,
+		}
+		if b.AssetE8 != 0 {
+			m["vwap"] = ratFloatStr(big.NewRat(b.RuneE8, b.AssetE8))
+		}
+		array[i] = m
+	}
+	respJSON(w, array)
+}
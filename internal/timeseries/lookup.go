@@ -2,9 +2,11 @@ package timeseries
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 )
 
@@ -67,6 +69,77 @@ func PoolStatus(ctx context.Context, pool string, moment time.Time) (string, err
 	return status, rows.Err()
 }
 
+// SearchPools gets the pools with an asset that contains the (case
+// insensitive) substring, in alphabetical order.
+func SearchPools(ctx context.Context, substr string, limit int) ([]string, error) {
+	// escape LIKE pattern characters
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(substr)) + "%"
+
+	const q = "SELECT DISTINCT pool FROM stake_events WHERE LOWER(pool) LIKE $1 ORDER BY pool LIMIT $2"
+	rows, err := DBQuery(ctx, q, pattern, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pools []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return pools, err
+		}
+		pools = append(pools, s)
+	}
+	return pools, rows.Err()
+}
+
+// PoolStatusChange is the latest status of a pool.
+type PoolStatusChange struct {
+	Pool      string
+	Status    string
+	Previous  string    // status before the change, if any
+	Timestamp time.Time // of the change
+}
+
+// PoolsByStatus gets the pools with a (case insensitive) status for a given
+// point in time. A zero moment defaults to the latest available.
+// Requests beyond the last block cause an error.
+func PoolsByStatus(ctx context.Context, status string, moment time.Time) ([]PoolStatusChange, error) {
+	_, timestamp, _ := LastBlock()
+	if moment.IsZero() {
+		moment = timestamp
+	} else if timestamp.Before(moment) {
+		return nil, errBeyondLast
+	}
+
+	const q = `SELECT asset, status, COALESCE(previous, ''), block_timestamp FROM (
+	SELECT DISTINCT ON (asset) asset, status, block_timestamp,
+		LAG(status) OVER (PARTITION BY asset ORDER BY block_timestamp) AS previous
+	FROM pool_events
+	WHERE block_timestamp <= $2
+	ORDER BY asset, block_timestamp DESC
+) AS latest
+WHERE LOWER(status) = LOWER($1)
+ORDER BY asset`
+	rows, err := DBQuery(ctx, q, status, moment.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []PoolStatusChange
+	for rows.Next() {
+		var c PoolStatusChange
+		var ns int64
+		if err := rows.Scan(&c.Pool, &c.Status, &c.Previous, &ns); err != nil {
+			return a, err
+		}
+		c.Timestamp = time.Unix(0, ns)
+		a = append(a, c)
+	}
+	return a, rows.Err()
+}
+
 // StakeAddrs gets all known addresses for a given point in time.
 // A zero moment defaults to the latest available.
 // Requests beyond the last block cause an error.
@@ -108,7 +181,7 @@ func Mimir(ctx context.Context, moment time.Time) (map[string]string, error) {
 	}
 
 	// could optimise by only fetching latest
-	const q = "SELECT name, value FROM set_mimir_event_entries WHERE block_timestamp <= $1"
+	const q = "SELECT key, value FROM set_mimir_events WHERE block_timestamp <= $1 ORDER BY block_timestamp"
 	rows, err := DBQuery(ctx, q, moment.UnixNano())
 	if err != nil {
 		return nil, fmt.Errorf("mimir lookup: %w", err)
@@ -127,6 +200,119 @@ func Mimir(ctx context.Context, moment time.Time) (map[string]string, error) {
 	return m, rows.Err()
 }
 
+// MimirEntry is a value with the block of its last change.
+type MimirEntry struct {
+	Value  string
+	Height int64 // zero when unknown
+}
+
+// MimirEntries gets all values for a given point in time.
+// A zero moment defaults to the latest available.
+// Requests beyond the last block cause an error.
+func MimirEntries(ctx context.Context, moment time.Time) (map[string]MimirEntry, error) {
+	_, timestamp, _ := LastBlock()
+	if moment.IsZero() {
+		moment = timestamp
+	} else if timestamp.Before(moment) {
+		return nil, errBeyondLast
+	}
+
+	const q = `SELECT DISTINCT ON (m.key) m.key, m.value, COALESCE(b.height, 0)
+FROM set_mimir_events m LEFT JOIN block_log b ON b.timestamp = m.block_timestamp
+WHERE m.block_timestamp <= $1
+ORDER BY m.key, m.block_timestamp DESC`
+	rows, err := DBQuery(ctx, q, moment.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("mimir lookup: %w", err)
+	}
+	defer rows.Close()
+
+	m := make(map[string]MimirEntry)
+	for rows.Next() {
+		var key string
+		var e MimirEntry
+		if err := rows.Scan(&key, &e.Value, &e.Height); err != nil {
+			return m, fmt.Errorf("mimir retrieve: %w", err)
+		}
+		m[key] = e
+	}
+	return m, rows.Err()
+}
+
+// MimirChange is a value update with the value it replaced.
+type MimirChange struct {
+	Height    int64 // zero when unknown
+	Timestamp time.Time
+	Key       string
+	OldValue  string // empty for the initial value
+	NewValue  string
+}
+
+// MimirChanges gets all updates on keys in chronological order.
+func MimirChanges(ctx context.Context, keys []string) ([]MimirChange, error) {
+	const q = `SELECT COALESCE(b.height, 0), m.block_timestamp, m.key, COALESCE((
+	SELECT p.value FROM set_mimir_events p
+	WHERE p.key = m.key AND p.block_timestamp < m.block_timestamp
+	ORDER BY p.block_timestamp DESC LIMIT 1
+), ''), m.value
+FROM set_mimir_events m LEFT JOIN block_log b ON b.timestamp = m.block_timestamp
+WHERE UPPER(m.key) = ANY($1)
+ORDER BY m.block_timestamp`
+	rows, err := DBQuery(ctx, q, keys)
+	if err != nil {
+		return nil, fmt.Errorf("mimir changes lookup: %w", err)
+	}
+	defer rows.Close()
+
+	var a []MimirChange
+	for rows.Next() {
+		var c MimirChange
+		var timestamp int64
+		if err := rows.Scan(&c.Height, &timestamp, &c.Key, &c.OldValue, &c.NewValue); err != nil {
+			return a, fmt.Errorf("mimir changes retrieve: %w", err)
+		}
+		c.Timestamp = time.Unix(0, timestamp)
+		a = append(a, c)
+	}
+	return a, rows.Err()
+}
+
+// BondChange is a bond event of a node.
+type BondChange struct {
+	Height    int64 // zero when unknown
+	Timestamp time.Time
+	Type      string // bond_paid, bond_returned or bond_cost
+	E8        int64
+}
+
+// BondHistoryLookup gets the bond events of node within [since, until) in
+// chronological order. Bonds name the node in their memo, or they come from
+// the node itself.
+func BondHistoryLookup(ctx context.Context, node string, since, until time.Time) ([]BondChange, error) {
+	const q = `SELECT COALESCE(b.height, 0), e.block_timestamp, e.bound_type, e.E8
+FROM bond_events e LEFT JOIN block_log b ON b.timestamp = e.block_timestamp
+WHERE (e.from_addr = $1 OR e.to_addr = $1 OR SPLIT_PART(e.memo, ':', 2) = $1)
+	AND e.block_timestamp >= $2 AND e.block_timestamp < $3
+ORDER BY e.block_timestamp`
+	rows, err := DBQuery(ctx, q, node, since.UnixNano(), until.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("bond history lookup: %w", err)
+	}
+	defer rows.Close()
+
+	var a []BondChange
+	for rows.Next() {
+		var c BondChange
+		var timestamp int64
+		if err := rows.Scan(&c.Height, &timestamp, &c.Type, &c.E8); err != nil {
+			return a, fmt.Errorf("bond history retrieve: %w", err)
+		}
+		c.Timestamp = time.Unix(0, timestamp)
+		a = append(a, c)
+	}
+	return a, rows.Err()
+}
+
 // StatusPerNode gets the labels for a given point in time.
 // New nodes have the empty string (for no confirmed status).
 // A zero moment defaults to the latest available.
@@ -186,7 +372,8 @@ func newNodes(ctx context.Context, moment time.Time) (map[string]string, error)
 
 // NodesSecpAndEd returs the public keys mapped to their respective addresses.
 func NodesSecpAndEd(ctx context.Context, t time.Time) (secp256k1Addrs, ed25519Addrs map[string]string, err error) {
-	const q = `SELECT node_addr, secp256k1, ed25519
+	// CHAR columns are space padded
+	const q = `SELECT rtrim(node_addr), rtrim(secp256k1), rtrim(ed25519)
 FROM set_node_keys_events
 WHERE block_timestamp <= $1`
 
@@ -210,7 +397,284 @@ WHERE block_timestamp <= $1`
 		if current, ok := ed25519Addrs[ed]; ok && current != addr {
 			log.Printf("Ed25519 key %q used by node address %q and %q", ed, current, addr)
 		}
-		ed25519Addrs[secp] = addr
+		ed25519Addrs[ed] = addr
 	}
 	return
 }
+
+// DepthAtHeight gets the depths of a pool as of a block height. The
+// aggregate_states table only holds changes, so the last known entry at or
+// before height applies. Pools without any entry get zero depths.
+func DepthAtHeight(ctx context.Context, pool string, height int64) (assetE8, runeE8 int64, err error) {
+	const q = "SELECT asset_e8, rune_e8 FROM aggregate_states WHERE pool = $1 AND height <= $2 ORDER BY height DESC LIMIT 1"
+	rows, err := DBQuery(ctx, q, pool, height)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&assetE8, &runeE8); err != nil {
+			return 0, 0, err
+		}
+	}
+	return assetE8, runeE8, rows.Err()
+}
+
+// HeightAtTime gets the last block at or before t. Times before the first
+// block have a zero height.
+func HeightAtTime(ctx context.Context, t time.Time) (height int64, timestamp time.Time, err error) {
+	const q = "SELECT height, timestamp FROM block_log WHERE timestamp <= $1 ORDER BY timestamp DESC LIMIT 1"
+	rows, err := DBQuery(ctx, q, t.UnixNano())
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var ns int64
+		if err := rows.Scan(&height, &ns); err != nil {
+			return 0, time.Time{}, err
+		}
+		timestamp = time.Unix(0, ns)
+	}
+	return height, timestamp, rows.Err()
+}
+
+// BlockDepth is the state of a pool at a block.
+type BlockDepth struct {
+	Height    int64
+	Timestamp time.Time
+	AssetE8   int64
+	RuneE8    int64
+}
+
+// DepthHistory gets the depths of a pool for each block in the height range,
+// inclusive on both ends.
+func DepthHistory(ctx context.Context, pool string, fromHeight, toHeight int64) ([]BlockDepth, error) {
+	assetE8, runeE8, err := DepthAtHeight(ctx, pool, fromHeight-1)
+	if err != nil {
+		return nil, err
+	}
+
+	const q = `SELECT b.height, b.timestamp, s.asset_e8, s.rune_e8
+FROM block_log b LEFT JOIN aggregate_states s ON s.height = b.height AND s.pool = $1
+WHERE b.height >= $2 AND b.height <= $3
+ORDER BY b.height`
+	rows, err := DBQuery(ctx, q, pool, fromHeight, toHeight)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []BlockDepth
+	for rows.Next() {
+		var height, timestamp int64
+		var assetChange, runeChange sql.NullInt64
+		if err := rows.Scan(&height, &timestamp, &assetChange, &runeChange); err != nil {
+			return a, err
+		}
+		if assetChange.Valid {
+			assetE8 = assetChange.Int64
+		}
+		if runeChange.Valid {
+			runeE8 = runeChange.Int64
+		}
+		a = append(a, BlockDepth{
+			Height:    height,
+			Timestamp: time.Unix(0, timestamp),
+			AssetE8:   assetE8,
+			RuneE8:    runeE8,
+		})
+	}
+	return a, rows.Err()
+}
+
+// VaultBalance gets the amount per asset from the events on a vault address,
+// being the inbounds minus the outbounds. Stakes are not included, as the
+// stake events lack the vault address.
+func VaultBalance(ctx context.Context, chain, vaultAddr string) (map[string]int64, error) {
+	const q = `SELECT asset, SUM(E8) FROM (
+	SELECT from_asset AS asset, from_E8 AS E8 FROM swap_events WHERE chain = $1 AND to_addr = $2
+	UNION ALL
+	SELECT asset, asset_E8 FROM add_events WHERE chain = $1 AND to_addr = $2
+	UNION ALL
+	SELECT asset, asset_E8 FROM bond_events WHERE chain = $1 AND to_addr = $2
+	UNION ALL
+	SELECT asset, asset_E8 FROM refund_events WHERE chain = $1 AND to_addr = $2
+	UNION ALL
+	SELECT asset, asset_E8 FROM reserve_events WHERE chain = $1 AND to_addr = $2
+	UNION ALL
+	SELECT asset, asset_E8 FROM unstake_events WHERE chain = $1 AND to_addr = $2
+	UNION ALL
+	SELECT asset, -asset_E8 FROM outbound_events WHERE chain = $1 AND from_addr = $2
+) AS transfers
+GROUP BY asset`
+
+	rows, err := DBQuery(ctx, q, chain, vaultAddr)
+	if err != nil {
+		return nil, fmt.Errorf("vault balance lookup: %w", err)
+	}
+	defer rows.Close()
+
+	m := make(map[string]int64)
+	for rows.Next() {
+		var asset string
+		var E8 int64
+		if err := rows.Scan(&asset, &E8); err != nil {
+			return m, fmt.Errorf("vault balance retrieve: %w", err)
+		}
+		m[asset] = E8
+	}
+	return m, rows.Err()
+}
+
+// GasRate is the average outbound fee of an asset in a block.
+type GasRate struct {
+	Asset     string
+	Timestamp time.Time
+	AvgE8     int64
+	TxCount   int64
+}
+
+// GasRateHistory gets the outbound fees on chain per block, in chronological
+// order.
+func GasRateHistory(ctx context.Context, chain string, since, until time.Time) ([]GasRate, error) {
+	const q = `SELECT asset, block_timestamp, AVG(asset_E8)::BIGINT, COUNT(*)
+FROM fee_events
+WHERE asset LIKE $1 || '.%' AND block_timestamp >= $2 AND block_timestamp < $3
+GROUP BY block_timestamp, asset
+ORDER BY block_timestamp, asset`
+
+	rows, err := DBQuery(ctx, q, chain, since.UnixNano(), until.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("gas rate lookup: %w", err)
+	}
+	defer rows.Close()
+
+	var a []GasRate
+	for rows.Next() {
+		var r GasRate
+		var timestamp int64
+		if err := rows.Scan(&r.Asset, &timestamp, &r.AvgE8, &r.TxCount); err != nil {
+			return a, fmt.Errorf("gas rate retrieve: %w", err)
+		}
+		r.Timestamp = time.Unix(0, timestamp)
+		a = append(a, r)
+	}
+	return a, rows.Err()
+}
+
+// LastChurnHeight gets the block height of the latest vault activation, with
+// zero for none.
+func LastChurnHeight(ctx context.Context) (int64, error) {
+	const q = `SELECT COALESCE(MAX(height), 0)
+FROM block_log
+WHERE timestamp = (SELECT MAX(block_timestamp) FROM active_vault_events)`
+	rows, err := DBQuery(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("last churn lookup: %w", err)
+	}
+	defer rows.Close()
+
+	var height int64
+	if rows.Next() {
+		if err := rows.Scan(&height); err != nil {
+			return 0, fmt.Errorf("last churn retrieve: %w", err)
+		}
+	}
+	return height, rows.Err()
+}
+
+// AverageBlockTime gets the mean interval of the last n blocks, with zero for
+// less than two blocks.
+func AverageBlockTime(ctx context.Context, n int) (time.Duration, error) {
+	const q = `SELECT COALESCE(MAX(height) - MIN(height), 0), COALESCE(MAX(timestamp) - MIN(timestamp), 0)
+FROM (SELECT height, timestamp FROM block_log ORDER BY height DESC LIMIT $1) b`
+	rows, err := DBQuery(ctx, q, n)
+	if err != nil {
+		return 0, fmt.Errorf("block time lookup: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks, ns int64
+	if rows.Next() {
+		if err := rows.Scan(&blocks, &ns); err != nil {
+			return 0, fmt.Errorf("block time retrieve: %w", err)
+		}
+	}
+	if blocks == 0 {
+		return 0, rows.Err()
+	}
+	return time.Duration(ns / blocks), rows.Err()
+}
+
+// LastPoolActivationHeight gets the height of the last block in which a pool
+// got enabled, with zero for none.
+func LastPoolActivationHeight(ctx context.Context) (int64, error) {
+	const q = `SELECT COALESCE(MAX(height), 0)
+FROM block_log
+WHERE timestamp = (SELECT MAX(block_timestamp) FROM pool_events WHERE LOWER(status) = 'enabled')`
+	rows, err := DBQuery(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("last pool activation lookup: %w", err)
+	}
+	defer rows.Close()
+
+	var height int64
+	if rows.Next() {
+		if err := rows.Scan(&height); err != nil {
+			return 0, fmt.Errorf("last pool activation retrieve: %w", err)
+		}
+	}
+	return height, rows.Err()
+}
+
+// BlockSummary has the event counts of a block.
+type BlockSummary struct {
+	Height       int64
+	Timestamp    time.Time
+	Hash         []byte
+	TxCount      int64 // distinct transactions of the counted events
+	SwapCount    int64
+	StakeCount   int64
+	UnstakeCount int64
+}
+
+// BlockRangeSummary gets the blocks in the height range, inclusive on both
+// ends, with the highest first, up to limit.
+func BlockRangeSummary(ctx context.Context, fromHeight, toHeight int64, limit int) ([]BlockSummary, error) {
+	const q = `SELECT b.height, b.timestamp, b.hash,
+	(SELECT COUNT(DISTINCT tx) FROM (
+		SELECT tx FROM swap_events WHERE block_timestamp = b.timestamp
+		UNION ALL
+		SELECT rune_tx FROM stake_events WHERE block_timestamp = b.timestamp
+		UNION ALL
+		SELECT tx FROM unstake_events WHERE block_timestamp = b.timestamp
+	) AS txs),
+	(SELECT COUNT(*) FROM swap_events WHERE block_timestamp = b.timestamp),
+	(SELECT COUNT(*) FROM stake_events WHERE block_timestamp = b.timestamp),
+	(SELECT COUNT(*) FROM unstake_events WHERE block_timestamp = b.timestamp)
+FROM block_log b
+WHERE b.height >= $1 AND b.height <= $2
+ORDER BY b.height DESC
+LIMIT $3`
+
+	rows, err := DBQuery(ctx, q, fromHeight, toHeight, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []BlockSummary
+	for rows.Next() {
+		var s BlockSummary
+		var ns int64
+		if err := rows.Scan(&s.Height, &ns, &s.Hash, &s.TxCount, &s.SwapCount, &s.StakeCount, &s.UnstakeCount); err != nil {
+			return a, err
+		}
+		s.Timestamp = time.Unix(0, ns)
+		a = append(a, s)
+	}
+	return a, rows.Err()
+}
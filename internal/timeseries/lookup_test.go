@@ -1,11 +1,15 @@
 package timeseries
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"log"
 	"math/rand"
+	"os"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -88,3 +92,279 @@ func TestNodesSecpAndEd(t *testing.T) {
 	}
 	t.Logf("got %+v and %+v", secp, ed)
 }
+
+func TestNodesSecpAndEdKeys(t *testing.T) {
+	mustSetup(t)
+
+	meta := &event.Metadata{BlockTimestamp: time.Unix(0, 1)}
+	EventListener.OnSetNodeKeys(&event.SetNodeKeys{
+		NodeAddr:           []byte("thor1node1"),
+		Secp256k1:          []byte("S1"),
+		Ed25519:            []byte("E1"),
+		ValidatorConsensus: []byte("V1"),
+	}, meta)
+	// reuse of the keys should cause a warning
+	EventListener.OnSetNodeKeys(&event.SetNodeKeys{
+		NodeAddr:           []byte("thor1node2"),
+		Secp256k1:          []byte("S2"),
+		Ed25519:            []byte("E1"),
+		ValidatorConsensus: []byte("V2"),
+	}, meta)
+
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(os.Stderr)
+
+	secp, ed, err := NodesSecpAndEd(context.Background(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := secp["S1"]; got != "thor1node1" {
+		t.Errorf("secp256k1 S1 got node %q, want thor1node1", got)
+	}
+	if got := secp["S2"]; got != "thor1node2" {
+		t.Errorf("secp256k1 S2 got node %q, want thor1node2", got)
+	}
+	if got := ed["E1"]; got != "thor1node1" && got != "thor1node2" {
+		t.Errorf("Ed25519 E1 got node %q, want thor1node1 or thor1node2", got)
+	}
+	if got, ok := ed["S1"]; ok {
+		t.Errorf("Ed25519 got node %q for secp256k1 key S1", got)
+	}
+	if !strings.Contains(logBuf.String(), `Ed25519 key "E1" used by node address`) {
+		t.Errorf("got log %q, want duplicate key warning", logBuf.String())
+	}
+}
+
+func TestDepthHistory(t *testing.T) {
+	mustSetup(t)
+
+	const pool = "BTC.TEST-DEPTH"
+	for _, q := range []string{
+		"DELETE FROM block_log WHERE height BETWEEN 1000001 AND 1000004",
+		"INSERT INTO block_log (height, timestamp, hash) VALUES (1000001, 1, 'h1'), (1000002, 2, 'h2'), (1000003, 3, 'h3'), (1000004, 4, 'h4')",
+		"INSERT INTO aggregate_states (height, pool, asset_e8, rune_e8) VALUES (1000001, 'BTC.TEST-DEPTH', 10, 100), (1000003, 'BTC.TEST-DEPTH', 30, 300)",
+	} {
+		if _, err := DBExec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assetE8, runeE8, err := DepthAtHeight(context.Background(), pool, 1000002)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assetE8 != 10 || runeE8 != 100 {
+		t.Errorf("got depths %d and %d at height 1000002, want 10 and 100", assetE8, runeE8)
+	}
+
+	got, err := DepthHistory(context.Background(), pool, 1000002, 1000004)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BlockDepth{
+		{Height: 1000002, Timestamp: time.Unix(0, 2), AssetE8: 10, RuneE8: 100},
+		{Height: 1000003, Timestamp: time.Unix(0, 3), AssetE8: 30, RuneE8: 300},
+		{Height: 1000004, Timestamp: time.Unix(0, 4), AssetE8: 30, RuneE8: 300},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v", got)
+		t.Errorf("want %+v", want)
+	}
+}
+
+func TestMimir(t *testing.T) {
+	mustSetup(t)
+
+	EventListener.OnSetMimir(&event.SetMimir{
+		Key:   []byte("TestKey"),
+		Value: []byte("1"),
+	}, &event.Metadata{BlockTimestamp: time.Unix(0, 1)})
+	EventListener.OnSetMimir(&event.SetMimir{
+		Key:   []byte("TestKey"),
+		Value: []byte("2"),
+	}, &event.Metadata{BlockTimestamp: time.Unix(0, 2)})
+
+	got, err := Mimir(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["TestKey"] != "2" {
+		t.Errorf("got TestKey %q, want latest value 2", got["TestKey"])
+	}
+}
+
+func TestMimirEntries(t *testing.T) {
+	mustSetup(t)
+
+	got, err := MimirEntries(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestMimirChanges(t *testing.T) {
+	mustSetup(t)
+
+	got, err := MimirChanges(context.Background(), []string{"EMISSIONCURVE", "MINIMUMBONDINRUNE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestBondHistoryLookup(t *testing.T) {
+	mustSetup(t)
+
+	got, err := BondHistoryLookup(context.Background(), "thor1node1", time.Unix(0, 0), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestVaultBalance(t *testing.T) {
+	mustSetup(t)
+
+	got, err := VaultBalance(context.Background(), "BNB", "tbnb1yxfyeda8pnlxlmx0z3cwx74w9xevspwdpzdxpj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestGasRateHistory(t *testing.T) {
+	mustSetup(t)
+
+	got, err := GasRateHistory(context.Background(), "BNB", time.Unix(0, 0), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestLastChurnHeight(t *testing.T) {
+	mustSetup(t)
+
+	got, err := LastChurnHeight(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %d", got)
+}
+
+func TestAverageBlockTime(t *testing.T) {
+	mustSetup(t)
+
+	got, err := AverageBlockTime(context.Background(), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %s", got)
+}
+
+func TestPoolsByStatus(t *testing.T) {
+	mustSetup(t)
+
+	got, err := PoolsByStatus(context.Background(), "Enabled", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestHeightAtTime(t *testing.T) {
+	mustSetup(t)
+
+	for _, q := range []string{
+		"DELETE FROM block_log WHERE timestamp < 100",
+		"INSERT INTO block_log (height, timestamp, hash) VALUES (1000001, 10, 'h1'), (1000002, 20, 'h2')",
+	} {
+		if _, err := DBExec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		t          time.Time
+		wantHeight int64
+	}{
+		{time.Unix(0, 5), 0},        // before genesis
+		{time.Unix(0, 10), 1000001}, // at genesis
+		{time.Unix(0, 15), 1000001}, // between blocks
+		{time.Unix(0, 20), 1000002},
+	}
+	for _, test := range tests {
+		height, _, err := HeightAtTime(context.Background(), test.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if height != test.wantHeight {
+			t.Errorf("at %d ns got height %d, want %d", test.t.UnixNano(), height, test.wantHeight)
+		}
+	}
+
+	// future resolves to the latest block
+	height, timestamp, err := HeightAtTime(context.Background(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height == 0 || timestamp.After(time.Now()) {
+		t.Errorf("future got height %d at %s, want the latest block", height, timestamp)
+	}
+}
+
+func TestSearchPools(t *testing.T) {
+	mustSetup(t)
+
+	for _, pool := range []string{"BNB.TEST_SEARCH-1", "BNB.TESTXSEARCH-2"} {
+		EventListener.OnStake(&event.Stake{
+			Pool:       []byte(pool),
+			AssetTx:    []byte("EUR"),
+			AssetChain: []byte("EU"),
+			RuneTx:     []byte("123"),
+			RuneChain:  []byte("THOR"),
+			RuneAddr:   []byte("home"),
+			RuneE8:     42,
+			StakeUnits: 1,
+		}, new(event.Metadata))
+	}
+
+	got, err := SearchPools(context.Background(), "test_search", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"BNB.TEST_SEARCH-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLastPoolActivationHeight(t *testing.T) {
+	mustSetup(t)
+
+	got, err := LastPoolActivationHeight(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %d", got)
+}
+
+func TestBlockRangeSummary(t *testing.T) {
+	mustSetup(t)
+
+	height, _, _ := LastBlock()
+	got, err := BlockRangeSummary(context.Background(), height-9, height, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) > 5 {
+		t.Errorf("got %d blocks, want at most 5", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Height >= got[i-1].Height {
+			t.Errorf("height %d after %d, want descending", got[i].Height, got[i-1].Height)
+		}
+	}
+	t.Logf("got %+v", got)
+}
@@ -4,9 +4,11 @@
 package timeseries
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 )
 
 type mapStrInt map[string]int64
@@ -74,15 +76,106 @@ func (pim *poolIdMap) getId(poolName string) int {
 
 var poolIdMapperr poolIdMap = poolIdMap{}
 
+// ValidatePoolIdConsistency restores poolIdMapperr from the pool_id_map
+// table. The aggregate_id_states must match aggregate_states with the mapping.
+func validatePoolIdConsistency() error {
+	rows, err := DBQuery(context.Background(), "SELECT pool_id, pool FROM pool_id_map ORDER BY pool_id")
+	if err != nil {
+		return fmt.Errorf("pool ID lookup: %w", err)
+	}
+	defer rows.Close()
+
+	m := poolIdMap{}
+	var conflicts []string
+	for rows.Next() {
+		var id int
+		var pool string
+		if err := rows.Scan(&id, &pool); err != nil {
+			return fmt.Errorf("pool ID retrieve: %w", err)
+		}
+		// getId assigns in sequence
+		if id != len(m) {
+			conflicts = append(conflicts, fmt.Sprintf("pool ID %d for %q out of sequence, want %d", id, pool, len(m)))
+		}
+		m[pool] = id
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("pool ID retrieve: %w", err)
+	}
+
+	// compare the latest entry of each pool
+	const q = `SELECT m.pool_id, m.pool FROM pool_id_map m
+JOIN LATERAL (SELECT height, asset_e8, rune_e8 FROM aggregate_states WHERE pool = m.pool ORDER BY height DESC LIMIT 1) s ON true
+LEFT JOIN aggregate_id_states i ON i.height = s.height AND i.pool_id = m.pool_id
+WHERE i.pool_id IS NULL OR i.asset_e8 <> s.asset_e8 OR i.rune_e8 <> s.rune_e8`
+	rows, err = DBQuery(context.Background(), q)
+	if err != nil {
+		return fmt.Errorf("pool ID consistency lookup: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var pool string
+		if err := rows.Scan(&id, &pool); err != nil {
+			return fmt.Errorf("pool ID consistency retrieve: %w", err)
+		}
+		conflicts = append(conflicts, fmt.Sprintf("pool ID %d for %q does not match aggregate_id_states", id, pool))
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("pool ID consistency retrieve: %w", err)
+	}
+
+	// IDs from before the persistence of pool_id_map are unknown
+	if len(m) == 0 {
+		rows, err := DBQuery(context.Background(), "SELECT 1 FROM aggregate_id_states LIMIT 1")
+		if err != nil {
+			return fmt.Errorf("pool ID consistency lookup: %w", err)
+		}
+		defer rows.Close()
+		if rows.Next() {
+			conflicts = append(conflicts, "aggregate_id_states without any pool_id_map entries")
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("pool ID consistency retrieve: %w", err)
+		}
+	}
+
+	if len(conflicts) != 0 {
+		for _, s := range conflicts {
+			log.Print(s)
+		}
+		return fmt.Errorf("aggregate_id_states inconsistent with pool_id_map (%d conflicts); run a migration to rebuild aggregate_id_states from aggregate_states", len(conflicts))
+	}
+
+	poolIdMapperr = m
+	return nil
+}
+
+// SnapshotInterval is the number of blocks between depth writes. Depth changes
+// are detected on each block regardless. Pending changes are lost on a crash,
+// in which case the depth history shows the previous values for those blocks.
+var SnapshotInterval int64 = 1
+
 type snapshotManager struct {
 	assetE8DepthSnapshot mapDiff
 	runeE8DepthSnapshot  mapDiff
 	snapshotHeight       int64
+
+	// changes not persisted yet
+	pending []depthRow
+}
+
+type depthRow struct {
+	height  int64
+	pool    string
+	assetE8 int64
+	runeE8  int64
 }
 
 var depthSnapshot snapshotManager
 
-// Returns query which will insert.
+// Update detects the depth changes at height. The changes are written every
+// SnapshotInterval blocks.
 func (sm *snapshotManager) update(height int64, assetE8DepthPerPool, runeE8DepthPerPool map[string]int64) error {
 	dolog := height%10000 == 0 // || len(assetE8DepthPerPool) != 0
 
@@ -96,13 +189,6 @@ func (sm *snapshotManager) update(height int64, assetE8DepthPerPool, runeE8Depth
 	// }
 	sm.snapshotHeight = height
 
-	// type row struct {
-	// 	pool         string
-	// 	assetE8Depth int64
-	// 	runeE8Depth  int64
-	// }
-	// newRows := []row{}
-
 	// we need to iterate over all 4 maps (old, new; snapshot, new)
 	poolNames := map[string]bool{}
 	accumulatePoolNames := func(m map[string]int64) {
@@ -115,19 +201,6 @@ func (sm *snapshotManager) update(height int64, assetE8DepthPerPool, runeE8Depth
 	accumulatePoolNames(sm.assetE8DepthSnapshot.snapshot)
 	accumulatePoolNames(sm.runeE8DepthSnapshot.snapshot)
 
-	// TODO_BEFORE_COMIT check if there is a small limit on query size. should we add rows separately?
-	queryFront := "INSERT INTO aggregate_states (height, pool, asset_e8, rune_e8) VALUES "
-	queryEnd := " ON CONFLICT DO NOTHING;"
-	rowFormat := "($%d, $%d, $%d, $%d)"
-	rowStrs := []string{}
-	values := []interface{}{}
-
-	queryFront2 := "INSERT INTO aggregate_id_states (height, pool_id, asset_e8, rune_e8) VALUES "
-	queryEnd2 := " ON CONFLICT DO NOTHING;"
-	rowFormat2 := "($%d, $%d, $%d, $%d)"
-	rowStrs2 := []string{}
-	values2 := []interface{}{}
-
 	if dolog {
 		log.Printf("pool names: %v", poolNames)
 	}
@@ -135,69 +208,107 @@ func (sm *snapshotManager) update(height int64, assetE8DepthPerPool, runeE8Depth
 		assetDiff, assetValue := sm.assetE8DepthSnapshot.diffAtKey(pool, assetE8DepthPerPool)
 		runeDiff, runeValue := sm.runeE8DepthSnapshot.diffAtKey(pool, runeE8DepthPerPool)
 		if assetDiff || runeDiff {
-			// dolog = true
-			// newRows = append(newRows, row{pool, assetValue, runeValue})
-			p := len(values)
-			rowStrs = append(rowStrs, fmt.Sprintf(rowFormat, p+1, p+2, p+3, p+4))
-			values = append(values, height, pool, assetValue, runeValue)
-
-			poolId := poolIdMapperr.getId(pool)
-			rowStrs2 = append(rowStrs2, fmt.Sprintf(rowFormat2, p+1, p+2, p+3, p+4))
-			values2 = append(values2, height, poolId, assetValue, runeValue)
+			sm.pending = append(sm.pending, depthRow{height, pool, assetValue, runeValue})
 		}
 	}
 	sm.assetE8DepthSnapshot.save(assetE8DepthPerPool)
 	sm.runeE8DepthSnapshot.save(runeE8DepthPerPool)
 
-	diffNum := len(rowStrs)
+	if SnapshotInterval > 1 && height%SnapshotInterval != 0 {
+		return nil
+	}
+	return sm.flush(height)
+}
 
+// Flush writes all pending depth changes, up to and including height. New pool
+// IDs, aggregate_states and aggregate_id_states are written with a single
+// statement, which either applies all of them or none of them. The new pool IDs
+// enter poolIdMapperr only once written. The pending changes are dropped on
+// error too, as a retry would most likely fail the same way.
+func (sm *snapshotManager) flush(height int64) error {
+	defer func() { sm.pending = sm.pending[:0] }()
+	diffNum := len(sm.pending)
 	if 0 == diffNum {
 		// log.Printf("Height doesn't have depth changes %d", height)
 		return nil
 	}
+	dolog := height%10000 == 0
+
+	// TODO_BEFORE_COMIT check if there is a small limit on query size. should we add rows separately?
+	var values []interface{}
+	var stateRows, idStateRows, idRows []string
+	newIds := make(map[string]int)
+	for _, row := range sm.pending {
+		poolId, ok := poolIdMapperr[row.pool]
+		if !ok {
+			poolId, ok = newIds[row.pool]
+			if !ok {
+				poolId = len(poolIdMapperr) + len(newIds)
+				newIds[row.pool] = poolId
+				p := len(values)
+				idRows = append(idRows, fmt.Sprintf("($%d, $%d)", p+1, p+2))
+				values = append(values, poolId, row.pool)
+			}
+		}
+
+		p := len(values)
+		stateRows = append(stateRows, fmt.Sprintf("($%d, $%d, $%d, $%d)", p+1, p+2, p+4, p+5))
+		idStateRows = append(idStateRows, fmt.Sprintf("($%d, $%d, $%d, $%d)", p+1, p+3, p+4, p+5))
+		values = append(values, row.height, row.pool, poolId, row.assetE8, row.runeE8)
+	}
 
-	query := queryFront + strings.Join(rowStrs, ", ") + queryEnd
-	query2 := queryFront2 + strings.Join(rowStrs2, ", ") + queryEnd2
+	query := "WITH "
+	if len(idRows) != 0 {
+		query += "ids AS (INSERT INTO pool_id_map (pool_id, pool) VALUES " + strings.Join(idRows, ", ") + "), "
+	}
+	query += "states AS (INSERT INTO aggregate_states (height, pool, asset_e8, rune_e8) VALUES " + strings.Join(stateRows, ", ") + ") " +
+		"INSERT INTO aggregate_id_states (height, pool_id, asset_e8, rune_e8) VALUES " + strings.Join(idStateRows, ", ")
 	if dolog {
 		log.Printf("Saving query: %s | values: %v", query, values)
-		log.Printf("Saving query2: %s | values: %v", query2, values2)
 	}
-	// time.Sleep(100 * time.Millisecond)
-	{
-		result, err := DBExec(query, values...)
-		if err != nil {
-			return fmt.Errorf("Error saving depths %d: %w", height, err)
-		}
-		n, err := result.RowsAffected()
-		if err != nil {
-			return fmt.Errorf("Error saving depths %d results: %w", height, err)
-		}
-		if n != int64(diffNum) {
-			return fmt.Errorf("Not all depths were saved at height %d (expected: %d, actual: %d)", height, n, diffNum)
-		}
+	// conflicts fail the entire statement
+	if _, err := DBExec(query, values...); err != nil {
+		return fmt.Errorf("Error saving %d depths at height %d: %w", diffNum, height, err)
 	}
-	{
-		result, err := DBExec(query2, values2...)
-		if err != nil {
-			return fmt.Errorf("Error 2 saving depths %d: %w", height, err)
-		}
-		n, err := result.RowsAffected()
-		if err != nil {
-			return fmt.Errorf("Error 2 saving depths %d results: %w", height, err)
-		}
-		if n != int64(diffNum) {
 
-			log.Printf("Saving query: %s | values: %v", query, values)
-			log.Printf("Saving query2: %s | values: %v", query2, values2)
-			log.Printf("Mapper %v", poolIdMapperr)
-			for i, v := range values {
-				if i%4 == 1 {
-					log.Printf("%v -> %v", v, poolIdMapperr.getId(v.(string)))
+	for pool, id := range newIds {
+		log.Printf("New pool name: |%s|", pool)
+		poolIdMapperr[pool] = id
+	}
+	return nil
+}
 
-				}
-			}
-			return fmt.Errorf("2 Not all depths were saved at height %d (expected: %d, actual: %d)", height, diffNum, n)
+// PruneAggregateStates downsamples the depth history before retainAfter to
+// one row per pool for each keepResolution time bucket, namely the latest.
+// The return is the number of rows deleted.
+func PruneAggregateStates(ctx context.Context, keepResolution time.Duration, retainAfter time.Time) (int64, error) {
+	if keepResolution <= 0 {
+		return 0, fmt.Errorf("prune with non-positive resolution %s", keepResolution)
+	}
+
+	const q = `WITH deleted AS (
+	DELETE FROM aggregate_states s
+	USING block_log b
+	WHERE b.height = s.height AND b.timestamp < $2 AND EXISTS (
+		SELECT 1
+		FROM aggregate_states later JOIN block_log lb ON lb.height = later.height
+		WHERE later.pool = s.pool AND later.height > s.height AND lb.timestamp / $1 = b.timestamp / $1
+	)
+	RETURNING 1
+)
+SELECT COUNT(*) FROM deleted`
+
+	rows, err := DBQuery(ctx, q, keepResolution.Nanoseconds(), retainAfter.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("aggregate states prune: %w", err)
+	}
+	defer rows.Close()
+
+	var n int64
+	if rows.Next() {
+		if err := rows.Scan(&n); err != nil {
+			return 0, fmt.Errorf("aggregate states prune result: %w", err)
 		}
 	}
-	return nil
+	return n, rows.Err()
 }
@@ -145,6 +145,29 @@ VALUES ($1, $2, $3)`
 	}
 }
 
+func (_ *eventRecorder) OnMintBurn(e *event.MintBurn, meta *event.Metadata) {
+	if !e.IsSynth() {
+		return // RUNE supply changes only
+	}
+	var q string
+	switch string(e.Supply) {
+	case "mint":
+		q = "INSERT INTO synthetic_mint_events (asset, E8, reason, block_timestamp) VALUES ($1, $2, $3, $4)"
+	case "burn":
+		q = "INSERT INTO synthetic_burn_events (asset, E8, reason, block_timestamp) VALUES ($1, $2, $3, $4)"
+	default:
+		log.Printf("mint_burn event from height %d with unknown supply %q ignored", meta.BlockHeight, e.Supply)
+		return
+	}
+	if e.Reason == nil {
+		e.Reason = empty
+	}
+	_, err := DBExec(q, e.Asset, e.E8, e.Reason, meta.BlockTimestamp.UnixNano())
+	if err != nil {
+		log.Printf("mint_burn event from height %d lost on %s", meta.BlockHeight, err)
+	}
+}
+
 func (_ *eventRecorder) OnNewNode(e *event.NewNode, meta *event.Metadata) {
 	const q = `INSERT INTO new_node_events (node_addr, block_timestamp)
 VALUES ($1, $2)`
@@ -0,0 +1,220 @@
+package stat
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// BondTotal is the net amount bonded at the end of a bucket.
+type BondTotal struct {
+	Time time.Time // bucket start
+	E8   int64
+}
+
+// NetBondBucketsLookup gets the cumulative bonds minus the returns and costs
+// at the end of each bucket.
+func NetBondBucketsLookup(ctx context.Context, bucketSize time.Duration, w Window) ([]BondTotal, error) {
+	n, err := bucketsFor(bucketSize, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(bucketSize) * int64(bucketSize)
+
+	// changes before the first bucket count as one
+	const q = `SELECT GREATEST(time_bucket($1, block_timestamp), $2 - $1) AS bucket,
+	SUM(CASE WHEN bound_type IN ('bond_returned', 'bond_cost') THEN -E8 ELSE E8 END)
+FROM bond_events
+WHERE block_timestamp < $3
+GROUP BY bucket
+ORDER BY bucket`
+
+	rows, err := DBQuery(ctx, q, bucketSize.Nanoseconds(), first, first+n*int64(bucketSize))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]BondTotal, n)
+	for i := range a {
+		a[i].Time = time.Unix(0, first+int64(i)*int64(bucketSize))
+	}
+	var total int64
+	i := -1 // index of bucket at total
+	for rows.Next() {
+		var bucket, e8 int64
+		if err := rows.Scan(&bucket, &e8); err != nil {
+			return nil, err
+		}
+		// complete the buckets before
+		for j := int((bucket - first) / int64(bucketSize)); i < j; i++ {
+			if i >= 0 {
+				a[i].E8 = total
+			}
+		}
+		total += e8
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for ; i < len(a); i++ {
+		if i >= 0 {
+			a[i].E8 = total
+		}
+	}
+	return a, nil
+}
+
+// NodeStatusChange is an update_node_account_status event.
+type nodeStatusChange struct {
+	Node      string
+	Status    string
+	Timestamp int64
+}
+
+// NodeActiveDurations gets the time spent in the active status per node
+// within the window. Nodes which were never active within the window are absent.
+func NodeActiveDurations(ctx context.Context, w Window) (map[string]time.Duration, error) {
+	changes, err := nodeStatusChangesLookup(ctx, w.Until)
+	if err != nil {
+		return nil, err
+	}
+	return activeDurations(changes, w), nil
+}
+
+// NodeStatusChangesLookup gets all status changes before until in
+// chronological order.
+func nodeStatusChangesLookup(ctx context.Context, until time.Time) ([]nodeStatusChange, error) {
+	const q = `SELECT node_addr, current, block_timestamp
+FROM update_node_account_status_events
+WHERE block_timestamp < $1
+ORDER BY block_timestamp`
+	rows, err := DBQuery(ctx, q, until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []nodeStatusChange
+	for rows.Next() {
+		var c nodeStatusChange
+		if err := rows.Scan(&c.Node, &c.Status, &c.Timestamp); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// ActiveDurations sums the active periods per node, clipped to the window.
+// The changes must be in chronological order.
+func activeDurations(changes []nodeStatusChange, w Window) map[string]time.Duration {
+	since, until := w.Since.UnixNano(), w.Until.UnixNano()
+	activeSince := make(map[string]int64)
+	m := make(map[string]time.Duration)
+	add := func(node string, from, to int64) {
+		if from < since {
+			from = since
+		}
+		if to > from {
+			m[node] += time.Duration(to - from)
+		}
+	}
+	for _, c := range changes {
+		from, wasActive := activeSince[c.Node]
+		isActive := strings.EqualFold(c.Status, "active")
+		switch {
+		case wasActive && !isActive:
+			add(c.Node, from, c.Timestamp)
+			delete(activeSince, c.Node)
+		case !wasActive && isActive:
+			activeSince[c.Node] = c.Timestamp
+		}
+	}
+	for node, from := range activeSince {
+		add(node, from, until)
+	}
+	return m
+}
+
+// NodeRotation is the turnover of the active node set within a window.
+type NodeRotation struct {
+	Churns         int     // number of blocks with nodes entering or leaving
+	NodesAdded     int     // number of times a node became active
+	NodesRemoved   int     // number of times a node stopped being active
+	AvgActiveNodes float64 // time-weighted average size of the active set
+	StartActive    int     // size of the active set at the start
+	StableNodes    int     // nodes active at both the start and the end
+}
+
+// NodeRotationLookup gets the changes of the active node set within the window.
+func NodeRotationLookup(ctx context.Context, w Window) (*NodeRotation, error) {
+	changes, err := nodeStatusChangesLookup(ctx, w.Until)
+	if err != nil {
+		return nil, err
+	}
+	return nodeRotation(changes, w), nil
+}
+
+// NodeRotation replays the changes on the active set. The changes must be in
+// chronological order.
+func nodeRotation(changes []nodeStatusChange, w Window) *NodeRotation {
+	since, until := w.Since.UnixNano(), w.Until.UnixNano()
+	active := make(map[string]bool)
+	var startActive map[string]bool
+	var r NodeRotation
+
+	// integral of the active set size over time
+	var activeNanos float64
+	last := since
+	churnBlocks := make(map[int64]bool)
+	for _, c := range changes {
+		if c.Timestamp >= since && startActive == nil {
+			startActive = make(map[string]bool, len(active))
+			for node := range active {
+				startActive[node] = true
+			}
+		}
+		if c.Timestamp > last {
+			activeNanos += float64(len(active)) * float64(c.Timestamp-last)
+			last = c.Timestamp
+		}
+
+		isActive := strings.EqualFold(c.Status, "active")
+		if active[c.Node] == isActive {
+			continue
+		}
+		if isActive {
+			active[c.Node] = true
+		} else {
+			delete(active, c.Node)
+		}
+		if c.Timestamp < since {
+			continue
+		}
+		churnBlocks[c.Timestamp] = true
+		if isActive {
+			r.NodesAdded++
+		} else {
+			r.NodesRemoved++
+		}
+	}
+	if startActive == nil {
+		startActive = active
+	}
+	if until > last {
+		activeNanos += float64(len(active)) * float64(until-last)
+	}
+
+	r.Churns = len(churnBlocks)
+	if until > since {
+		r.AvgActiveNodes = activeNanos / float64(until-since)
+	}
+	r.StartActive = len(startActive)
+	for node := range startActive {
+		if active[node] {
+			r.StableNodes++
+		}
+	}
+	return &r
+}
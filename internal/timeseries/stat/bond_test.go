@@ -0,0 +1,72 @@
+package stat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/sqltest"
+)
+
+func TestNetBondBucketsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := NetBondBucketsLookup(context.Background(), 24*time.Hour, Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Errorf("got %d buckets, want 31", len(got))
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestActiveDurations(t *testing.T) {
+	w := Window{Since: time.Unix(100, 0), Until: time.Unix(200, 0)}
+	changes := []nodeStatusChange{
+		{"a", "Active", time.Unix(50, 0).UnixNano()},
+		{"b", "Active", time.Unix(120, 0).UnixNano()},
+		{"a", "Standby", time.Unix(150, 0).UnixNano()},
+		{"c", "Active", time.Unix(10, 0).UnixNano()},
+		{"c", "Disabled", time.Unix(90, 0).UnixNano()},
+		{"b", "Active", time.Unix(130, 0).UnixNano()},
+	}
+	got := activeDurations(changes, w)
+	want := map[string]time.Duration{"a": 50 * time.Second, "b": 80 * time.Second}
+	if len(got) != len(want) || got["a"] != want["a"] || got["b"] != want["b"] {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestNodeActiveDurations(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := NodeActiveDurations(context.Background(), Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestNodeRotation(t *testing.T) {
+	w := Window{Since: time.Unix(100, 0), Until: time.Unix(200, 0)}
+	changes := []nodeStatusChange{
+		{"a", "Active", time.Unix(10, 0).UnixNano()},
+		{"b", "Active", time.Unix(10, 0).UnixNano()},
+		{"c", "Active", time.Unix(150, 0).UnixNano()},
+		{"b", "Standby", time.Unix(150, 0).UnixNano()},
+		{"c", "Active", time.Unix(160, 0).UnixNano()},
+	}
+	got := nodeRotation(changes, w)
+	want := NodeRotation{Churns: 1, NodesAdded: 1, NodesRemoved: 1, AvgActiveNodes: 2, StartActive: 2, StableNodes: 1}
+	if *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+}
+
+func TestNodeRotationLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := NodeRotationLookup(context.Background(), Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
@@ -0,0 +1,610 @@
+package stat
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// PoolDepths are the asset and RUNE depths of each pool at a point in time.
+type PoolDepths struct {
+	Time           time.Time
+	AssetE8PerPool map[string]int64
+	RuneE8PerPool  map[string]int64
+}
+
+// PoolDepthsBucketsLookup gets the depths of all pools at the end of each
+// bucket. The aggregate_states table is sparse; pools without a change in a
+// bucket retain their previous depths.
+func PoolDepthsBucketsLookup(ctx context.Context, bucketSize time.Duration, w Window) ([]PoolDepths, error) {
+	n, err := bucketsFor(bucketSize, w)
+	if err != nil {
+		return nil, err
+	}
+
+	// depths at the start of the first bucket
+	const offsetQ = `SELECT DISTINCT ON (s.pool) s.pool, s.asset_e8, s.rune_e8, 0
+FROM aggregate_states s JOIN block_log b ON b.height = s.height
+WHERE b.timestamp < $1
+ORDER BY s.pool, s.height DESC`
+	first := time.Unix(0, w.Since.UnixNano()/int64(bucketSize)*int64(bucketSize))
+	current := PoolDepths{
+		AssetE8PerPool: make(map[string]int64),
+		RuneE8PerPool:  make(map[string]int64),
+	}
+	err = scanDepthChanges(ctx, func(pool string, assetE8, runeE8, _ int64) {
+		current.AssetE8PerPool[pool] = assetE8
+		current.RuneE8PerPool[pool] = runeE8
+	}, offsetQ, first.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+
+	// replay changes in chronological order
+	const changesQ = `SELECT s.pool, s.asset_e8, s.rune_e8, b.timestamp
+FROM aggregate_states s JOIN block_log b ON b.height = s.height
+WHERE b.timestamp >= $1 AND b.timestamp < $2
+ORDER BY s.height`
+	a := make([]PoolDepths, 0, n)
+	bucketEnd := first.Add(bucketSize)
+	flush := func() {
+		a = append(a, PoolDepths{
+			Time:           bucketEnd.Add(-bucketSize),
+			AssetE8PerPool: copyDepths(current.AssetE8PerPool),
+			RuneE8PerPool:  copyDepths(current.RuneE8PerPool),
+		})
+		bucketEnd = bucketEnd.Add(bucketSize)
+	}
+	err = scanDepthChanges(ctx, func(pool string, assetE8, runeE8, timestamp int64) {
+		for timestamp >= bucketEnd.UnixNano() {
+			flush()
+		}
+		current.AssetE8PerPool[pool] = assetE8
+		current.RuneE8PerPool[pool] = runeE8
+	}, changesQ, first.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	for int64(len(a)) < n {
+		flush()
+	}
+	return a, nil
+}
+
+func scanDepthChanges(ctx context.Context, apply func(pool string, assetE8, runeE8, timestamp int64), q string, args ...interface{}) error {
+	rows, err := DBQuery(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pool string
+		var assetE8, runeE8, timestamp int64
+		if err := rows.Scan(&pool, &assetE8, &runeE8, &timestamp); err != nil {
+			return err
+		}
+		apply(pool, assetE8, runeE8, timestamp)
+	}
+	return rows.Err()
+}
+
+func copyDepths(m map[string]int64) map[string]int64 {
+	c := make(map[string]int64, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// PoolDepthStats describe the variation of the RUNE depth of a pool.
+type PoolDepthStats struct {
+	Count         int64 // number of data points
+	MeanRuneE8    float64
+	StdDevRuneE8  float64
+	MinRuneE8     int64
+	MaxRuneE8     int64
+	DeepDiveCount int64 // days with an intraday drop of more than 20 %
+}
+
+// PoolDepthStatsLookup gets the variation of the RUNE depth of pool within the
+// window. The aggregate_states table is sparse, so each data point is a depth
+// change, or a snapshot.
+func PoolDepthStatsLookup(ctx context.Context, pool string, w Window) (*PoolDepthStats, error) {
+	const q = `WITH depths AS (
+	SELECT s.height, s.rune_e8, time_bucket(86400000000000, b.timestamp) AS day
+	FROM aggregate_states s JOIN block_log b ON b.height = s.height
+	WHERE s.pool = $1 AND b.timestamp >= $2 AND b.timestamp < $3
+), drops AS (
+	SELECT day, MIN(rune_e8::FLOAT / NULLIF(peak, 0)) AS worst FROM (
+		SELECT day, rune_e8, MAX(rune_e8) OVER (PARTITION BY day ORDER BY height) AS peak
+		FROM depths
+	) AS peaks
+	GROUP BY day
+)
+SELECT COUNT(*), COALESCE(AVG(rune_e8)::FLOAT, 0), COALESCE(STDDEV(rune_e8)::FLOAT, 0), COALESCE(MIN(rune_e8), 0), COALESCE(MAX(rune_e8), 0),
+	(SELECT COUNT(*) FROM drops WHERE worst < 0.8)
+FROM depths`
+
+	rows, err := DBQuery(ctx, q, pool, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var r PoolDepthStats
+	if rows.Next() {
+		if err := rows.Scan(&r.Count, &r.MeanRuneE8, &r.StdDevRuneE8, &r.MinRuneE8, &r.MaxRuneE8, &r.DeepDiveCount); err != nil {
+			return nil, err
+		}
+	}
+	return &r, rows.Err()
+}
+
+// DepthTrend is a least-squares fit of the RUNE depth over time, with the
+// time in Unix seconds.
+type DepthTrend struct {
+	Count     int // number of data points
+	Slope     float64
+	Intercept float64
+	RSquared  float64 // coefficient of determination
+}
+
+// At returns the depth predicted by the trend.
+func (t *DepthTrend) At(moment time.Time) float64 {
+	return t.Slope*float64(moment.Unix()) + t.Intercept
+}
+
+// PoolDepthTrend fits the RUNE depth changes of pool within the window.
+func PoolDepthTrend(ctx context.Context, pool string, w Window) (*DepthTrend, error) {
+	const q = `SELECT b.timestamp, s.rune_e8
+FROM aggregate_states s JOIN block_log b ON b.height = s.height
+WHERE s.pool = $1 AND b.timestamp >= $2 AND b.timestamp < $3
+ORDER BY s.height`
+
+	rows, err := DBQuery(ctx, q, pool, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var xs, ys []float64
+	for rows.Next() {
+		var ns, runeE8 int64
+		if err := rows.Scan(&ns, &runeE8); err != nil {
+			return nil, err
+		}
+		xs = append(xs, float64(ns/1e9))
+		ys = append(ys, float64(runeE8))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return linearRegression(xs, ys), nil
+}
+
+// LinearRegression fits y = slope × x + intercept with least squares.
+func linearRegression(xs, ys []float64) *DepthTrend {
+	t := DepthTrend{Count: len(xs)}
+	if len(xs) == 0 {
+		return &t
+	}
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+
+	// centered for numerical stability
+	var sxx, sxy, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx != 0 {
+		t.Slope = sxy / sxx
+	}
+	t.Intercept = meanY - t.Slope*meanX
+	switch {
+	case syy == 0:
+		t.RSquared = 1 // constant fits exactly
+	case sxx != 0:
+		t.RSquared = sxy * sxy / (sxx * syy)
+	}
+	return &t
+}
+
+// Correlation is a Pearson correlation coefficient with its significance.
+type Correlation struct {
+	Count       int
+	Coefficient float64
+	PValue      float64 // two-tailed, for no correlation
+}
+
+// PoolCorrelation correlates the RUNE depth of pool with the price of the
+// reference pool, both sampled at the end of each day in the window. Days
+// without a price for the reference are skipped.
+func PoolCorrelation(ctx context.Context, pool, reference string, w Window) (*Correlation, error) {
+	depths, err := PoolDepthsBucketsLookup(ctx, 24*time.Hour, w)
+	if err != nil {
+		return nil, err
+	}
+	var xs, ys []float64
+	for _, d := range depths {
+		assetE8, runeE8 := d.AssetE8PerPool[reference], d.RuneE8PerPool[reference]
+		if assetE8 == 0 {
+			continue
+		}
+		xs = append(xs, float64(d.RuneE8PerPool[pool]))
+		ys = append(ys, float64(runeE8)/float64(assetE8))
+	}
+	return pearson(xs, ys), nil
+}
+
+// Pearson calculates the sample correlation coefficient of xs and ys. The
+// p-value follows from Student's t-distribution with n − 2 degrees of freedom.
+func pearson(xs, ys []float64) *Correlation {
+	c := Correlation{Count: len(xs), PValue: 1}
+	if len(xs) < 3 {
+		return &c
+	}
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+
+	var sxx, sxy, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return &c // undefined for constants
+	}
+	c.Coefficient = sxy / math.Sqrt(sxx*syy)
+
+	df := float64(len(xs) - 2)
+	r2 := c.Coefficient * c.Coefficient
+	if r2 >= 1 {
+		c.PValue = 0
+		return &c
+	}
+	// P(|T| ≥ t) = I(df ÷ (df + t²); df ÷ 2, ½) with t² = r² × df ÷ (1 − r²)
+	c.PValue = regularizedIncompleteBeta(1-r2, df/2, 0.5)
+	return &c
+}
+
+// RegularizedIncompleteBeta returns I(x; a, b) with the continued fraction of
+// Lentz, as described in Numerical Recipes, section 6.4.
+func regularizedIncompleteBeta(x, a, b float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case x >= 1:
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	// the fraction converges fast for x < (a + 1) ÷ (a + b + 2)
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+func betaContinuedFraction(x, a, b float64) float64 {
+	const tiny = 1e-300
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1.0; m <= 300; m++ {
+		// even step
+		n := m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		d = 1 + n*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + n/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		// odd step
+		n = -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		d = 1 + n*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + n/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return h
+}
+
+// Metrics accepted by PoolMetricTimeSeries.
+const (
+	MetricTVL    = "tvl"
+	MetricVolume = "volume"
+	MetricPrice  = "price"
+)
+
+// PoolMetricTimeSeries gets a metric of pool per day in the window, in RUNE.
+// The TVL and the price are sampled at the end of each day, with the TVL as
+// twice the RUNE depth. The volume values the asset side at the price of the
+// day's end. Days without an asset depth have a NaN price.
+func PoolMetricTimeSeries(ctx context.Context, pool, metric string, w Window) ([]float64, error) {
+	const day = 24 * time.Hour
+	n, err := bucketsFor(day, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(day) * int64(day)
+
+	if metric != MetricTVL && metric != MetricVolume && metric != MetricPrice {
+		return nil, fmt.Errorf("unknown metric %q", metric)
+	}
+
+	// depths at the end of each day
+	const depthQ = `SELECT COALESCE(d.asset_e8, 0), COALESCE(d.rune_e8, 0)
+FROM generate_series($2::BIGINT, $3::BIGINT, $4::BIGINT) AS e(t)
+LEFT JOIN LATERAL (
+	SELECT s.asset_e8, s.rune_e8
+	FROM aggregate_states s JOIN block_log b ON b.height = s.height
+	WHERE s.pool = $1 AND b.timestamp < e.t
+	ORDER BY s.height DESC
+	LIMIT 1
+) AS d ON true
+ORDER BY e.t`
+	rows, err := DBQuery(ctx, depthQ, pool, first+int64(day), first+n*int64(day), int64(day))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	assetE8s := make([]int64, 0, n)
+	runeE8s := make([]int64, 0, n)
+	for rows.Next() {
+		var assetE8, runeE8 int64
+		if err := rows.Scan(&assetE8, &runeE8); err != nil {
+			return nil, err
+		}
+		assetE8s = append(assetE8s, assetE8)
+		runeE8s = append(runeE8s, runeE8)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	series := make([]float64, len(runeE8s))
+	for i := range series {
+		switch metric {
+		case MetricTVL:
+			series[i] = 2 * float64(runeE8s[i])
+		case MetricPrice:
+			series[i] = math.NaN()
+			if assetE8s[i] != 0 {
+				series[i] = float64(runeE8s[i]) / float64(assetE8s[i])
+			}
+		}
+	}
+	if metric != MetricVolume {
+		return series, nil
+	}
+
+	const volumeQ = `SELECT time_bucket($4, block_timestamp) AS bucket,
+	SUM(CASE WHEN from_asset = pool THEN from_E8 ELSE 0 END)::BIGINT,
+	SUM(CASE WHEN from_asset = pool THEN 0 ELSE from_E8 END)::BIGINT
+FROM swap_events
+WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+GROUP BY bucket
+ORDER BY bucket`
+	rows, err = DBQuery(ctx, volumeQ, pool, first, first+n*int64(day), int64(day))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bucket, assetE8, runeE8 int64
+		if err := rows.Scan(&bucket, &assetE8, &runeE8); err != nil {
+			return nil, err
+		}
+		i := (bucket - first) / int64(day)
+		if i < 0 || i >= int64(len(series)) {
+			continue
+		}
+		series[i] = float64(runeE8)
+		if assetE8s[i] != 0 {
+			series[i] += float64(assetE8) * float64(runeE8s[i]) / float64(assetE8s[i])
+		}
+	}
+	return series, rows.Err()
+}
+
+// SeriesCorrelation correlates xs with ys, both of the same length, with the
+// NaN values and their counterparts omitted.
+func SeriesCorrelation(xs, ys []float64) *Correlation {
+	var fx, fy []float64
+	for i := range xs {
+		if math.IsNaN(xs[i]) || math.IsNaN(ys[i]) {
+			continue
+		}
+		fx = append(fx, xs[i])
+		fy = append(fy, ys[i])
+	}
+	return pearson(fx, fy)
+}
+
+// PriceChange is the move of a pool price in RUNE per asset over a window.
+type PriceChange struct {
+	StartPrice    float64 // zero without asset depth
+	EndPrice      float64 // zero without asset depth
+	ChangePercent float64 // zero without a start price
+}
+
+// PoolPriceChange gets the pool price from the depths at the start and at the
+// end of the window.
+func PoolPriceChange(ctx context.Context, pool string, w Window) (*PriceChange, error) {
+	const q = `SELECT COALESCE(s.asset_e8, 0), COALESCE(s.rune_e8, 0)
+FROM unnest($2::BIGINT[]) WITH ORDINALITY AS m(t, i)
+LEFT JOIN LATERAL (
+	SELECT s.asset_e8, s.rune_e8
+	FROM aggregate_states s JOIN block_log b ON b.height = s.height
+	WHERE s.pool = $1 AND b.timestamp < m.t
+	ORDER BY s.height DESC
+	LIMIT 1
+) AS s ON true
+ORDER BY m.i`
+	rows, err := DBQuery(ctx, q, pool, []int64{w.Since.UnixNano(), w.Until.UnixNano()})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []float64
+	for rows.Next() {
+		var assetE8, runeE8 int64
+		if err := rows.Scan(&assetE8, &runeE8); err != nil {
+			return nil, err
+		}
+		var price float64
+		if assetE8 != 0 {
+			price = float64(runeE8) / float64(assetE8)
+		}
+		prices = append(prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(prices) != 2 {
+		return nil, fmt.Errorf("got %d prices for pool %q, want 2", len(prices), pool)
+	}
+
+	c := PriceChange{StartPrice: prices[0], EndPrice: prices[1]}
+	if c.StartPrice != 0 {
+		c.ChangePercent = (c.EndPrice - c.StartPrice) / c.StartPrice * 100
+	}
+	return &c, nil
+}
+
+// Moving average types accepted by DepthMovingAverage.
+const (
+	SimpleMovingAverage      = "sma"
+	ExponentialMovingAverage = "ema"
+)
+
+// DepthMA is the RUNE depth of a pool at the end of a day with its moving
+// average.
+type DepthMA struct {
+	Time   time.Time // day start
+	RuneE8 int64
+	MA     float64
+}
+
+// DepthMovingAverage gets the RUNE depth of pool at the end of each day in the
+// window, with its moving average over period days. The average includes the
+// days before the window. Days before the pool's first depth are absent, and
+// at the start of the series the average covers the available days only. The
+// EMA starts at the first depth, with a smoothing factor of 2 ÷ (period + 1).
+func DepthMovingAverage(ctx context.Context, pool string, period int, maType string, w Window) ([]DepthMA, error) {
+	if period < 1 {
+		return nil, fmt.Errorf("moving average period %d not positive", period)
+	}
+	if maType != SimpleMovingAverage && maType != ExponentialMovingAverage {
+		return nil, fmt.Errorf("unknown moving average type %q", maType)
+	}
+	const day = 24 * time.Hour
+	lookback := Window{Since: w.Since.Add(-time.Duration(period-1) * day), Until: w.Until}
+	n, err := bucketsFor(day, lookback)
+	if err != nil {
+		return nil, err
+	}
+	first := lookback.Since.UnixNano() / int64(day) * int64(day)
+	windowFirst := w.Since.UnixNano() / int64(day) * int64(day)
+
+	const q = `SELECT m.t, m.rune_e8, m.sma FROM (
+	SELECT e.t, d.rune_e8, AVG(d.rune_e8) OVER (ORDER BY e.t ROWS BETWEEN $5 PRECEDING AND CURRENT ROW)::FLOAT AS sma
+	FROM generate_series($2::BIGINT, $3::BIGINT, $4::BIGINT) AS e(t)
+	LEFT JOIN LATERAL (
+		SELECT s.rune_e8
+		FROM aggregate_states s JOIN block_log b ON b.height = s.height
+		WHERE s.pool = $1 AND b.timestamp < e.t
+		ORDER BY s.height DESC
+		LIMIT 1
+	) AS d ON true
+) AS m
+WHERE m.rune_e8 IS NOT NULL
+ORDER BY m.t`
+	rows, err := DBQuery(ctx, q, pool, first+int64(day), first+n*int64(day), int64(day), int64(period-1))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []DepthMA
+	for rows.Next() {
+		var end int64
+		var p DepthMA
+		if err := rows.Scan(&end, &p.RuneE8, &p.MA); err != nil {
+			return nil, err
+		}
+		p.Time = time.Unix(0, end-int64(day))
+		a = append(a, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if maType == ExponentialMovingAverage {
+		values := make([]float64, len(a))
+		for i := range a {
+			values[i] = float64(a[i].RuneE8)
+		}
+		for i, v := range expMovingAverage(values, period) {
+			a[i].MA = v
+		}
+	}
+
+	// drop the lookback days
+	for len(a) != 0 && a[0].Time.UnixNano() < windowFirst {
+		a = a[1:]
+	}
+	return a, nil
+}
+
+// ExpMovingAverage returns the EMA of values, starting with the first value.
+func expMovingAverage(values []float64, period int) []float64 {
+	alpha := 2 / float64(period+1)
+	ema := make([]float64, len(values))
+	for i, v := range values {
+		if i == 0 {
+			ema[i] = v
+			continue
+		}
+		ema[i] = alpha*v + (1-alpha)*ema[i-1]
+	}
+	return ema
+}
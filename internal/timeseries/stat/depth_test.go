@@ -0,0 +1,197 @@
+package stat
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/sqltest"
+)
+
+func TestPoolDepthsBucketsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolDepthsBucketsLookup(context.Background(), 24*time.Hour, Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Errorf("got %d buckets, want 31", len(got))
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestPoolDepthStatsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolDepthStatsLookup(context.Background(), "BNB.BNB", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestPoolDepthTrend(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolDepthTrend(context.Background(), "BNB.BNB", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestLinearRegression(t *testing.T) {
+	exact := linearRegression([]float64{1, 2, 3, 4}, []float64{3, 5, 7, 9})
+	if exact.Slope != 2 || exact.Intercept != 1 || exact.RSquared != 1 {
+		t.Errorf("y = 2x + 1 got %+v", exact)
+	}
+
+	flat := linearRegression([]float64{1, 2, 3}, []float64{5, 5, 5})
+	if flat.Slope != 0 || flat.Intercept != 5 || flat.RSquared != 1 {
+		t.Errorf("y = 5 got %+v", flat)
+	}
+
+	// residuals −1, 2, −1 around y = x
+	noisy := linearRegression([]float64{0, 1, 2}, []float64{-1, 3, 1})
+	if noisy.Slope != 1 || noisy.Intercept != 0 {
+		t.Errorf("noisy got %+v, want slope 1 and intercept 0", noisy)
+	}
+	// 1 − 6 ÷ 8
+	if math.Abs(noisy.RSquared-0.25) > 1e-12 {
+		t.Errorf("noisy got R² %g, want 0.25", noisy.RSquared)
+	}
+}
+
+func TestPoolCorrelation(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolCorrelation(context.Background(), "BNB.BNB", "BNB.BUSD-BD1", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestPoolMetricTimeSeries(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	w := Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()}
+	for _, metric := range []string{MetricTVL, MetricVolume, MetricPrice} {
+		got, err := PoolMetricTimeSeries(context.Background(), "BNB.BNB", metric, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 31 {
+			t.Errorf("%s got %d days, want 31", metric, len(got))
+		}
+		t.Logf("%s got %v", metric, got)
+	}
+}
+
+func TestSeriesCorrelation(t *testing.T) {
+	got := SeriesCorrelation([]float64{1, 2, math.NaN(), 3, 4}, []float64{3, 5, 100, 7, math.NaN()})
+	if got.Count != 3 || got.Coefficient != 1 {
+		t.Errorf("got %+v, want 3 points with coefficient 1", got)
+	}
+}
+
+func TestPoolPriceChange(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolPriceChange(context.Background(), "BNB.BNB", Window{Since: time.Now().Add(-24 * time.Hour), Until: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestPearson(t *testing.T) {
+	exact := pearson([]float64{1, 2, 3, 4}, []float64{3, 5, 7, 9})
+	if exact.Coefficient != 1 || exact.PValue != 0 {
+		t.Errorf("y = 2x + 1 got %+v", exact)
+	}
+	inverse := pearson([]float64{1, 2, 3, 4}, []float64{4, 3, 2, 1})
+	if inverse.Coefficient != -1 || inverse.PValue != 0 {
+		t.Errorf("y = 5 − x got %+v", inverse)
+	}
+	flat := pearson([]float64{1, 2, 3}, []float64{5, 5, 5})
+	if flat.Coefficient != 0 || flat.PValue != 1 {
+		t.Errorf("y = 5 got %+v", flat)
+	}
+
+	// r = 11 ÷ 15 with 8 degrees of freedom gives t ≈ 3.051
+	got := pearson([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, []float64{4, 1, 6, 2, 3, 8, 5, 10, 7, 9})
+	if math.Abs(got.Coefficient-11.0/15) > 1e-12 {
+		t.Errorf("got coefficient %g, want 11 ÷ 15", got.Coefficient)
+	}
+	if math.Abs(got.PValue-0.0158006) > 1e-6 {
+		t.Errorf("got p-value %g, want 0.0158006", got.PValue)
+	}
+}
+
+func TestRegularizedIncompleteBeta(t *testing.T) {
+	golden := []struct{ x, a, b, want float64 }{
+		{0.5, 1, 1, 0.5},    // uniform
+		{0.25, 1, 1, 0.25},  // uniform
+		{0.5, 2, 2, 0.5},    // symmetric
+		{0.2, 2, 3, 0.1808}, // 6x² − 8x³ + 3x⁴
+		{0.9, 0.5, 0.5, 0.7951672353008665},
+	}
+	for _, g := range golden {
+		got := regularizedIncompleteBeta(g.x, g.a, g.b)
+		if math.Abs(got-g.want) > 1e-9 {
+			t.Errorf("I(%g; %g, %g) got %g, want %g", g.x, g.a, g.b, got, g.want)
+		}
+	}
+}
+
+func TestExpMovingAverage(t *testing.T) {
+	got := expMovingAverage([]float64{100, 200, 400}, 7)
+	want := []float64{100, 125, 193.75}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%d: got %g, want %g", i, got[i], want[i])
+		}
+	}
+	if got := expMovingAverage([]float64{3, 5}, 1); got[0] != 3 || got[1] != 5 {
+		t.Errorf("period 1 got %v, want the values", got)
+	}
+	if got := expMovingAverage(nil, 7); len(got) != 0 {
+		t.Errorf("no values got %v", got)
+	}
+}
+
+func TestDepthMovingAverage(t *testing.T) {
+	tx := sqltest.NewTx(t)
+	DBQuery = tx.QueryContext
+
+	// pool starts in the window, without history for the lookback
+	day0 := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(day int) int64 {
+		return day0.Add(time.Duration(day)*24*time.Hour + time.Hour).UnixNano()
+	}
+	if _, err := tx.Exec("INSERT INTO block_log (height, timestamp, hash) VALUES (9000001, $1, 'h1'), (9000002, $2, 'h2'), (9000003, $3, 'h3')", at(0), at(1), at(2)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO aggregate_states (height, pool, asset_e8, rune_e8) VALUES (9000001, 'BTC.TEST-MA', 1, 100), (9000002, 'BTC.TEST-MA', 1, 200), (9000003, 'BTC.TEST-MA', 1, 400)"); err != nil {
+		t.Fatal(err)
+	}
+	w := Window{Since: day0, Until: day0.Add(60 * time.Hour)}
+
+	for _, tc := range []struct {
+		maType string
+		want   []float64
+	}{
+		{SimpleMovingAverage, []float64{100, 150, 700.0 / 3}},
+		{ExponentialMovingAverage, []float64{100, 125, 193.75}},
+	} {
+		got, err := DepthMovingAverage(context.Background(), "BTC.TEST-MA", 7, tc.maType, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s got %+v, want %d days", tc.maType, got, len(tc.want))
+		}
+		for i, want := range tc.want {
+			if !got[i].Time.Equal(day0.Add(time.Duration(i)*24*time.Hour)) || math.Abs(got[i].MA-want) > 1e-9 {
+				t.Errorf("%s day %d got %+v, want MA %g", tc.maType, i, got[i], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package stat
+
+import (
+	"context"
+	"time"
+)
+
+// PoolMemberCountLookup gets the number of addresses with a positive amount
+// of stake units in pool at the end of the window.
+func PoolMemberCountLookup(ctx context.Context, pool string, w Window) (int64, error) {
+	const q = `SELECT COUNT(*) FROM (
+	SELECT addr FROM (
+		SELECT rune_addr AS addr, stake_units AS units FROM stake_events
+		WHERE pool = $1 AND block_timestamp < $2
+		UNION ALL
+		SELECT from_addr, -stake_units FROM unstake_events
+		WHERE pool = $1 AND block_timestamp < $2
+	) AS changes
+	GROUP BY addr
+	HAVING SUM(units) > 0
+) AS members`
+
+	rows, err := DBQuery(ctx, q, pool, w.Until.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n int64
+	if rows.Next() {
+		if err := rows.Scan(&n); err != nil {
+			return 0, err
+		}
+	}
+	return n, rows.Err()
+}
+
+// MemberCount is the number of pool members at the end of a bucket.
+type MemberCount struct {
+	Time  time.Time // bucket start
+	Count int64
+}
+
+// PoolMemberCountBucketsLookup gets the member count history from the
+// pool_member_counts view, which has an hourly resolution. Changes since the
+// last refresh of the view are not included.
+func PoolMemberCountBucketsLookup(ctx context.Context, pool string, bucketSize time.Duration, w Window) ([]MemberCount, error) {
+	n, err := bucketsFor(bucketSize, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(bucketSize) * int64(bucketSize)
+
+	// The count of an hour applies at its end.
+	const q = `SELECT bucket + 3600000000000, count FROM pool_member_counts
+WHERE pool = $1 AND bucket + 3600000000000 <= $3 AND (
+	bucket + 3600000000000 > $2
+	OR bucket = (SELECT MAX(bucket) FROM pool_member_counts WHERE pool = $1 AND bucket + 3600000000000 <= $2)
+)
+ORDER BY bucket`
+
+	rows, err := DBQuery(ctx, q, pool, first, first+n*int64(bucketSize))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]MemberCount, 0, n)
+	var count int64
+	bucketEnd := first + int64(bucketSize)
+	flush := func() {
+		a = append(a, MemberCount{Time: time.Unix(0, bucketEnd-int64(bucketSize)), Count: count})
+		bucketEnd += int64(bucketSize)
+	}
+	for rows.Next() {
+		var end, c int64
+		if err := rows.Scan(&end, &c); err != nil {
+			return nil, err
+		}
+		for end > bucketEnd {
+			flush()
+		}
+		count = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for int64(len(a)) < n {
+		flush()
+	}
+	return a, nil
+}
+
+// StakerCountLookup gets the number of distinct addresses which staked before
+// the end of the window.
+func StakerCountLookup(ctx context.Context, w Window) (int64, error) {
+	const q = "SELECT COUNT(DISTINCT rune_addr) FROM stake_events WHERE block_timestamp < $1"
+	return countLookup(ctx, q, w.Until.UnixNano())
+}
+
+// StakerCountCachedLookup gets the number of distinct addresses which staked
+// from the staker_count view. Changes since the last refresh of the view are
+// not included.
+func StakerCountCachedLookup(ctx context.Context) (int64, error) {
+	return countLookup(ctx, "SELECT count FROM staker_count")
+}
+
+func countLookup(ctx context.Context, q string, args ...interface{}) (int64, error) {
+	rows, err := DBQuery(ctx, q, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n int64
+	if rows.Next() {
+		if err := rows.Scan(&n); err != nil {
+			return 0, err
+		}
+	}
+	return n, rows.Err()
+}
+
+// StakerCountBucketsLookup gets the number of distinct addresses which staked
+// before the end of each bucket.
+func StakerCountBucketsLookup(ctx context.Context, bucketSize time.Duration, w Window) ([]MemberCount, error) {
+	n, err := bucketsFor(bucketSize, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(bucketSize) * int64(bucketSize)
+
+	const q = `SELECT GREATEST(time_bucket($1, first), $2 - $1), COUNT(*) FROM (
+	SELECT MIN(block_timestamp) AS first FROM stake_events
+	WHERE block_timestamp < $3
+	GROUP BY rune_addr
+) AS firsts
+GROUP BY 1
+ORDER BY 1`
+
+	rows, err := DBQuery(ctx, q, bucketSize.Nanoseconds(), first, first+n*int64(bucketSize))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]MemberCount, n)
+	for i := range a {
+		a[i].Time = time.Unix(0, first+int64(i)*int64(bucketSize))
+	}
+	var total int64
+	i := -1 // index of bucket at total
+	for rows.Next() {
+		var bucket, c int64
+		if err := rows.Scan(&bucket, &c); err != nil {
+			return nil, err
+		}
+		// complete the buckets before
+		for j := int((bucket - first) / int64(bucketSize)); i < j; i++ {
+			if i >= 0 {
+				a[i].Count = total
+			}
+		}
+		total += c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for ; i < len(a); i++ {
+		if i >= 0 {
+			a[i].Count = total
+		}
+	}
+	return a, nil
+}
+
+// Concentration is the distribution of stake units over the pool members.
+type Concentration struct {
+	LPCount    int64
+	HHI        float64 // Herfindahl-Hirschman Index in [0, 10000]
+	Top1Share  float64
+	Top10Share float64
+	Gini       float64
+}
+
+// LPConcentration gets the distribution of the current stake units in pool.
+func LPConcentration(ctx context.Context, pool string) (*Concentration, error) {
+	const q = `SELECT SUM(units) FROM (
+	SELECT rune_addr AS addr, stake_units AS units FROM stake_events
+	WHERE pool = $1
+	UNION ALL
+	SELECT from_addr, -stake_units FROM unstake_events
+	WHERE pool = $1
+) AS changes
+GROUP BY addr
+HAVING SUM(units) > 0
+ORDER BY 1`
+
+	rows, err := DBQuery(ctx, q, pool)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var units []int64
+	for rows.Next() {
+		var n int64
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		units = append(units, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return concentrationOf(units), nil
+}
+
+// ConcentrationOf calculates the distribution of units in ascending order.
+func concentrationOf(units []int64) *Concentration {
+	c := Concentration{LPCount: int64(len(units))}
+	var total float64
+	for _, n := range units {
+		total += float64(n)
+	}
+	if total == 0 {
+		return &c
+	}
+
+	// Gini = 2·Σ(i·xᵢ) / (n·Σxᵢ) − (n+1)/n with i in [1, n] ascending
+	var weighted float64
+	for i, n := range units {
+		share := float64(n) / total
+		c.HHI += share * share
+		weighted += float64(i+1) * float64(n)
+		if i >= len(units)-10 {
+			c.Top10Share += share
+		}
+	}
+	c.HHI *= 10000
+	c.Top1Share = float64(units[len(units)-1]) / total
+	count := float64(len(units))
+	c.Gini = 2*weighted/(count*total) - (count+1)/count
+	return &c
+}
+
+// WealthBucket is a range of addresses ordered by their RUNE staked.
+type WealthBucket struct {
+	MinE8   int64
+	MaxE8   int64
+	TotalE8 int64
+	Count   int64
+}
+
+// WealthDistribution is the RUNE staked per address in deciles, from the
+// smallest stakes to the largest.
+type WealthDistribution struct {
+	Deciles [10]WealthBucket
+	TotalE8 int64
+	Gini    float64
+}
+
+// RuneStakingDistribution gets the distribution of the net RUNE staked per
+// address over all pools. Unstakes reduce the RUNE staked proportional to the
+// units, like PoolLPPositionsLookup does. Addresses with nothing left are
+// excluded.
+func RuneStakingDistribution(ctx context.Context) (*WealthDistribution, error) {
+	const q = `SELECT SUM(s.rune_E8 * (s.units - COALESCE(u.units, 0)) / s.units)::BIGINT
+FROM (
+	SELECT rune_addr AS addr, pool, SUM(rune_E8) AS rune_E8, SUM(stake_units) AS units
+	FROM stake_events
+	GROUP BY rune_addr, pool
+) AS s LEFT JOIN (
+	SELECT from_addr AS addr, pool, SUM(stake_units) AS units
+	FROM unstake_events
+	GROUP BY from_addr, pool
+) AS u ON s.addr = u.addr AND s.pool = u.pool
+WHERE s.units > COALESCE(u.units, 0)
+GROUP BY s.addr
+HAVING SUM(s.rune_E8 * (s.units - COALESCE(u.units, 0)) / s.units)::BIGINT > 0
+ORDER BY 1`
+
+	rows, err := DBQuery(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var amounts []int64
+	for rows.Next() {
+		var n int64
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		amounts = append(amounts, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return wealthDistributionOf(amounts), nil
+}
+
+// WealthDistributionOf splits amounts, in ascending order, into deciles.
+func wealthDistributionOf(amounts []int64) *WealthDistribution {
+	d := WealthDistribution{Gini: concentrationOf(amounts).Gini}
+	n := len(amounts)
+	for i := range d.Deciles {
+		part := amounts[i*n/10 : (i+1)*n/10]
+		if len(part) == 0 {
+			continue
+		}
+		b := &d.Deciles[i]
+		b.MinE8, b.MaxE8 = part[0], part[len(part)-1]
+		b.Count = int64(len(part))
+		for _, v := range part {
+			b.TotalE8 += v
+		}
+		d.TotalE8 += b.TotalE8
+	}
+	return &d
+}
@@ -0,0 +1,148 @@
+package stat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/sqltest"
+)
+
+func TestPoolMemberCountLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolMemberCountLookup(context.Background(), "BNB.MATIC-416", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %d", got)
+}
+
+func TestPoolMemberCountBucketsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	w := Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()}
+	got, err := PoolMemberCountBucketsLookup(context.Background(), "BNB.MATIC-416", 24*time.Hour, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Errorf("got %d buckets, want 31", len(got))
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestStakerCount(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+
+	n, err := StakerCountLookup(context.Background(), Window{Until: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %d", n)
+
+	got, err := StakerCountBucketsLookup(context.Background(), 24*time.Hour, Window{Since: time.Now().Add(-7 * 24 * time.Hour), Until: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestLPConcentration(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := LPConcentration(context.Background(), "BNB.MATIC-416")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestConcentrationOf(t *testing.T) {
+	equal := concentrationOf([]int64{5, 5, 5, 5})
+	if equal.HHI != 2500 || equal.Gini != 0 || equal.Top1Share != 0.25 || equal.Top10Share != 1 {
+		t.Errorf("equal shares got %+v", equal)
+	}
+	single := concentrationOf([]int64{42})
+	if single.HHI != 10000 || single.Gini != 0 || single.Top1Share != 1 {
+		t.Errorf("single member got %+v", single)
+	}
+	skewed := concentrationOf([]int64{1, 1, 1, 97})
+	if skewed.Gini < 0.7 || skewed.Top1Share != 0.97 {
+		t.Errorf("skewed shares got %+v", skewed)
+	}
+	if empty := concentrationOf(nil); *empty != (Concentration{}) {
+		t.Errorf("no members got %+v", empty)
+	}
+}
+
+func TestRuneStakingDistribution(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := RuneStakingDistribution(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestRuneStakingDistributionUnstaked(t *testing.T) {
+	tx := sqltest.NewTx(t)
+	DBQuery = tx.QueryContext
+	exec := func(q string, args ...interface{}) {
+		if _, err := tx.Exec(q, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	total := func() int64 {
+		d, err := RuneStakingDistribution(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d.TotalE8
+	}
+
+	const addr, pool = "thor1distribution", "BTC.TEST-DISTRIBUTION"
+	base := total()
+	exec(`INSERT INTO stake_events (pool, asset_tx, asset_chain, asset_E8, stake_units, rune_tx, rune_addr, rune_E8, block_timestamp)
+VALUES ($1, '', 'BTC', 100, 10, '', $2, 100e8, 1)`, pool, addr)
+	// the request has a donation of 1 RUNE only
+	exec(`INSERT INTO unstake_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, pool, stake_units, basis_points, asymmetry, block_timestamp)
+VALUES ('UNSTAKE1', 'THOR', $1, '', 'THOR.RUNE', 1, '', $2, 5, 5000, 0, 2)`, addr, pool)
+	if got := total() - base; got != 50e8 {
+		t.Errorf("got %d RUNE E8 after half unstaked, want 50e8", got)
+	}
+	exec(`INSERT INTO unstake_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, pool, stake_units, basis_points, asymmetry, block_timestamp)
+VALUES ('UNSTAKE2', 'THOR', $1, '', 'THOR.RUNE', 1, '', $2, 5, 10000, 0, 3)`, addr, pool)
+	if got := total() - base; got != 0 {
+		t.Errorf("got %d RUNE E8 after all unstaked, want none", got)
+	}
+}
+
+func TestWealthDistributionOf(t *testing.T) {
+	amounts := make([]int64, 20)
+	for i := range amounts {
+		amounts[i] = int64(i + 1)
+	}
+	got := wealthDistributionOf(amounts)
+	if got.TotalE8 != 210 {
+		t.Errorf("got total %d, want 210", got.TotalE8)
+	}
+	want := WealthBucket{MinE8: 19, MaxE8: 20, TotalE8: 39, Count: 2}
+	if got.Deciles[9] != want {
+		t.Errorf("got top decile %+v, want %+v", got.Deciles[9], want)
+	}
+	want = WealthBucket{MinE8: 1, MaxE8: 2, TotalE8: 3, Count: 2}
+	if got.Deciles[0] != want {
+		t.Errorf("got bottom decile %+v, want %+v", got.Deciles[0], want)
+	}
+
+	// fewer addresses than deciles leaves some empty
+	few := wealthDistributionOf([]int64{1, 2, 3})
+	var count int64
+	for _, b := range few.Deciles {
+		count += b.Count
+	}
+	if count != 3 || few.TotalE8 != 6 {
+		t.Errorf("3 addresses got %+v", few)
+	}
+	if empty := wealthDistributionOf(nil); *empty != (WealthDistribution{}) {
+		t.Errorf("no addresses got %+v", empty)
+	}
+}
@@ -0,0 +1,157 @@
+package stat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PoolTx is a swap, a stake or an unstake on a pool.
+type PoolTx struct {
+	Type           string // "swap", "stake" or "unstake"
+	TxID           string
+	Addr           string
+	AssetE8        int64 // pool asset amount
+	RuneE8         int64
+	LiqFeeInRuneE8 int64 // zero for stakes and unstakes
+	Height         int64 // zero when unknown
+	Time           time.Time
+}
+
+// Selects per PoolTx.Type with a column for each PoolTx field.
+var poolTxSelects = map[string]string{
+	"swap": `SELECT 'swap', tx, from_addr, CASE WHEN from_asset = pool THEN from_E8 ELSE 0 END, CASE WHEN from_asset = pool THEN 0 ELSE from_E8 END, liq_fee_in_rune_E8, block_timestamp
+	FROM swap_events
+	WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3`,
+	"stake": `SELECT 'stake', rune_tx, rune_addr, asset_E8, rune_E8, 0, block_timestamp
+	FROM stake_events
+	WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3`,
+	"unstake": `SELECT 'unstake', tx, from_addr, CASE WHEN asset = pool THEN asset_E8 ELSE 0 END, CASE WHEN asset = pool THEN 0 ELSE asset_E8 END, 0, block_timestamp
+	FROM unstake_events
+	WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3`,
+}
+
+// PoolTxListLookup gets the transactions of pool, most recent first. The
+// empty txType includes all types.
+func PoolTxListLookup(ctx context.Context, pool string, txType string, w Window, limit, offset int) ([]PoolTx, error) {
+	var selects []string
+	if txType == "" {
+		// fixed order for query plan reuse
+		selects = []string{poolTxSelects["swap"], poolTxSelects["stake"], poolTxSelects["unstake"]}
+	} else {
+		s, ok := poolTxSelects[txType]
+		if !ok {
+			return nil, fmt.Errorf("unknown transaction type %q", txType)
+		}
+		selects = []string{s}
+	}
+
+	q := `SELECT e.type, e.tx, e.from_addr, e.asset_E8, e.rune_E8, e.liq_fee_in_rune_E8, COALESCE(b.height, 0), e.block_timestamp
+FROM (
+	` + strings.Join(selects, "\n\tUNION ALL\n\t") + `
+) e (type, tx, from_addr, asset_E8, rune_E8, liq_fee_in_rune_E8, block_timestamp)
+LEFT JOIN block_log b ON b.timestamp = e.block_timestamp
+ORDER BY e.block_timestamp DESC
+LIMIT $4 OFFSET $5`
+
+	return queryPoolTxs(ctx, q, pool, w.Since.UnixNano(), w.Until.UnixNano(), limit, offset)
+}
+
+func queryPoolTxs(ctx context.Context, q string, args ...interface{}) ([]PoolTx, error) {
+	rows, err := DBQuery(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []PoolTx
+	for rows.Next() {
+		var tx PoolTx
+		var timestamp int64
+		if err := rows.Scan(&tx.Type, &tx.TxID, &tx.Addr, &tx.AssetE8, &tx.RuneE8, &tx.LiqFeeInRuneE8, &tx.Height, &timestamp); err != nil {
+			return a, err
+		}
+		tx.TxID = strings.TrimRight(tx.TxID, " ")
+		tx.Time = time.Unix(0, timestamp)
+		a = append(a, tx)
+	}
+	return a, rows.Err()
+}
+
+// LargeTransactions gets the transactions of pool worth at least minRuneE8,
+// most valuable first. Asset amounts count at the current pool price.
+func LargeTransactions(ctx context.Context, pool string, minRuneE8 int64, w Window, limit int) ([]PoolTx, error) {
+	q := `WITH depth AS (
+	SELECT asset_e8, rune_e8 FROM aggregate_states
+	WHERE pool = $1
+	ORDER BY height DESC
+	LIMIT 1
+)
+SELECT e.type, e.tx, e.from_addr, e.asset_E8, e.rune_E8, e.liq_fee_in_rune_E8, COALESCE(b.height, 0), e.block_timestamp
+FROM (
+	` + strings.Join([]string{poolTxSelects["swap"], poolTxSelects["stake"], poolTxSelects["unstake"]}, "\n\tUNION ALL\n\t") + `
+) e (type, tx, from_addr, asset_E8, rune_E8, liq_fee_in_rune_E8, block_timestamp)
+LEFT JOIN depth d ON d.asset_e8 > 0
+LEFT JOIN block_log b ON b.timestamp = e.block_timestamp
+WHERE e.rune_E8 + COALESCE(e.asset_E8::NUMERIC * d.rune_e8 / d.asset_e8, 0) >= $4
+ORDER BY e.rune_E8 + COALESCE(e.asset_E8::NUMERIC * d.rune_e8 / d.asset_e8, 0) DESC
+LIMIT $5`
+
+	return queryPoolTxs(ctx, q, pool, w.Since.UnixNano(), w.Until.UnixNano(), minRuneE8, limit)
+}
+
+// PoolTxCount is the transaction mix of a pool in a time bucket.
+type PoolTxCount struct {
+	Time       time.Time // bucket start
+	SwapNum    int64
+	StakeNum   int64
+	UnstakeNum int64
+	AddrNum    int64 // distinct addresses over all types
+}
+
+// PoolTxCounts gets the number of transactions per type for each bucket.
+func PoolTxCounts(ctx context.Context, pool string, bucketSize time.Duration, w Window) ([]PoolTxCount, error) {
+	n, err := bucketsFor(bucketSize, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(bucketSize) * int64(bucketSize)
+
+	q := `SELECT time_bucket($4, e.block_timestamp) AS bucket,
+	COUNT(*) FILTER (WHERE e.type = 'swap'),
+	COUNT(*) FILTER (WHERE e.type = 'stake'),
+	COUNT(*) FILTER (WHERE e.type = 'unstake'),
+	COUNT(DISTINCT e.from_addr)
+FROM (
+	` + poolTxSelects["swap"] + `
+	UNION ALL
+	` + poolTxSelects["stake"] + `
+	UNION ALL
+	` + poolTxSelects["unstake"] + `
+) e (type, tx, from_addr, asset_E8, rune_E8, liq_fee_in_rune_E8, block_timestamp)
+GROUP BY bucket
+ORDER BY bucket`
+
+	rows, err := DBQuery(ctx, q, pool, first, first+n*int64(bucketSize), bucketSize.Nanoseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]PoolTxCount, n)
+	for i := range a {
+		a[i].Time = time.Unix(0, first+int64(i)*int64(bucketSize))
+	}
+	for rows.Next() {
+		var bucket int64
+		var c PoolTxCount
+		if err := rows.Scan(&bucket, &c.SwapNum, &c.StakeNum, &c.UnstakeNum, &c.AddrNum); err != nil {
+			return nil, err
+		}
+		i := (bucket - first) / int64(bucketSize)
+		c.Time = a[i].Time
+		a[i] = c
+	}
+	return a, rows.Err()
+}
@@ -0,0 +1,43 @@
+package stat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/sqltest"
+)
+
+func TestPoolTxListLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	for _, txType := range []string{"", "swap", "stake", "unstake"} {
+		got, err := PoolTxListLookup(context.Background(), "BNB.MATIC-416", txType, testWindow, 100, 0)
+		if err != nil {
+			t.Fatalf("type %q: %s", txType, err)
+		}
+		t.Logf("type %q got %+v", txType, got)
+	}
+
+	if _, err := PoolTxListLookup(context.Background(), "BNB.MATIC-416", "add", testWindow, 100, 0); err == nil {
+		t.Error("unknown type got no error")
+	}
+}
+
+func TestPoolTxCounts(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	w := Window{Since: testWindow.Since, Until: testWindow.Since.Add(30 * 24 * time.Hour)}
+	got, err := PoolTxCounts(context.Background(), "BNB.MATIC-416", 24*time.Hour, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestLargeTransactions(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := LargeTransactions(context.Background(), "BNB.MATIC-416", 1e8, testWindow, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
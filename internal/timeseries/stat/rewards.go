@@ -0,0 +1,96 @@
+package stat
+
+import (
+	"context"
+	"time"
+)
+
+// Rewards are the RUNE emissions from the reserve.
+type Rewards struct {
+	Time        time.Time // bucket start
+	BondE8      int64     // to the node operators
+	PoolE8      int64     // to the pools combined
+	ReserveInE8 int64     // contributions to the reserve
+}
+
+// RewardsBucketsLookup gets the emissions per bucket. Buckets without any
+// rewards are included with zero amounts.
+func RewardsBucketsLookup(ctx context.Context, bucketSize time.Duration, w Window) ([]Rewards, error) {
+	n, err := bucketsFor(bucketSize, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(bucketSize) * int64(bucketSize)
+	a := make([]Rewards, n)
+	for i := range a {
+		a[i].Time = time.Unix(0, first+int64(i)*int64(bucketSize))
+	}
+
+	const q = `SELECT bucket, SUM(bond_E8), SUM(pool_E8), SUM(reserve_in_E8) FROM (
+	SELECT time_bucket($1, block_timestamp) AS bucket, COALESCE(SUM(bond_E8), 0) AS bond_E8, 0 AS pool_E8, 0 AS reserve_in_E8
+	FROM rewards_events
+	WHERE block_timestamp >= $2 AND block_timestamp < $3
+	GROUP BY bucket
+UNION ALL
+	SELECT time_bucket($1, block_timestamp) AS bucket, 0 AS bond_E8, COALESCE(SUM(rune_E8), 0) AS pool_E8, 0 AS reserve_in_E8
+	FROM rewards_event_entries
+	WHERE block_timestamp >= $2 AND block_timestamp < $3
+	GROUP BY bucket
+UNION ALL
+	SELECT time_bucket($1, block_timestamp) AS bucket, 0 AS bond_E8, 0 AS pool_E8, COALESCE(SUM(E8), 0) AS reserve_in_E8
+	FROM reserve_events
+	WHERE block_timestamp >= $2 AND block_timestamp < $3
+	GROUP BY bucket
+) AS rewards
+GROUP BY bucket
+ORDER BY bucket`
+
+	rows, err := DBQuery(ctx, q, bucketSize.Nanoseconds(), w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, noTableErr(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket, bondE8, poolE8, reserveInE8 int64
+		if err := rows.Scan(&bucket, &bondE8, &poolE8, &reserveInE8); err != nil {
+			return a, err
+		}
+		i := (bucket - first) / int64(bucketSize)
+		if i < 0 || i >= n {
+			continue // not possible
+		}
+		a[i].BondE8 = bondE8
+		a[i].PoolE8 = poolE8
+		a[i].ReserveInE8 = reserveInE8
+	}
+	return a, rows.Err()
+}
+
+// ReserveChangeLookup gets the net amount added to the reserve, which is the
+// contributions minus the emissions.
+func ReserveChangeLookup(ctx context.Context, w Window) (int64, error) {
+	const q = `SELECT
+	(SELECT COALESCE(SUM(E8), 0) FROM reserve_events WHERE block_timestamp >= $1 AND block_timestamp < $2)
+	- (SELECT COALESCE(SUM(bond_E8), 0) FROM rewards_events WHERE block_timestamp >= $1 AND block_timestamp < $2)
+	- (SELECT COALESCE(SUM(rune_E8), 0) FROM rewards_event_entries WHERE block_timestamp >= $1 AND block_timestamp < $2)`
+
+	rows, err := DBQuery(ctx, q, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return 0, noTableErr(err)
+	}
+	defer rows.Close()
+
+	var n int64
+	if rows.Next() {
+		if err := rows.Scan(&n); err != nil {
+			return 0, err
+		}
+	}
+	return n, rows.Err()
+}
+
+// ReserveBalance gets the reserve as tracked with ReserveChangeLookup, at t.
+func ReserveBalance(ctx context.Context, t time.Time) (int64, error) {
+	return ReserveChangeLookup(ctx, Window{Since: time.Unix(0, 0), Until: t.Add(1)})
+}
@@ -0,0 +1,40 @@
+package stat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/sqltest"
+)
+
+func TestRewardsBucketsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	w := Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()}
+	got, err := RewardsBucketsLookup(context.Background(), 24*time.Hour, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Errorf("got %d buckets, want 31", len(got))
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestReserveChangeLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := ReserveChangeLookup(context.Background(), testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %d", got)
+}
+
+func TestReserveBalance(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := ReserveBalance(context.Background(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %d", got)
+}
@@ -0,0 +1,234 @@
+package stat
+
+import (
+	"context"
+	"time"
+)
+
+// PoolROI is the return on investment of a pool within a time period. The
+// depths at the start of the period count as staked.
+type PoolROI struct {
+	AssetROI float64
+	RuneROI  float64
+	PoolROI  float64 // average of asset and RUNE
+}
+
+// PoolROIForWindow gets the ROI of the pool within w.
+func PoolROIForWindow(ctx context.Context, asset string, w Window) (*PoolROI, error) {
+	assetStart, runeStart, err := poolDepthsBefore(ctx, asset, w.Since)
+	if err != nil {
+		return nil, err
+	}
+	assetEnd, runeEnd, err := poolDepthsBefore(ctx, asset, w.Until)
+	if err != nil {
+		return nil, err
+	}
+	stakes, err := PoolStakesLookup(ctx, asset, w)
+	if err != nil {
+		return nil, err
+	}
+	unstakes, err := PoolUnstakesLookup(ctx, asset, w)
+	if err != nil {
+		return nil, err
+	}
+
+	assetStaked := assetStart + stakes.AssetE8Total - unstakes.AssetE8Total
+	runeStaked := runeStart + stakes.RuneE8Total - unstakes.RuneE8Total
+	return calcPoolROI(assetEnd, runeEnd, assetStaked, runeStaked), nil
+}
+
+// CalcPoolROI compares the depths to the amounts staked. Zero stakes give
+// zero ROI.
+func calcPoolROI(assetDepth, runeDepth, assetStaked, runeStaked int64) *PoolROI {
+	var roi PoolROI
+	if assetStaked != 0 {
+		roi.AssetROI = float64(assetDepth-assetStaked) / float64(assetStaked)
+	}
+	if runeStaked != 0 {
+		roi.RuneROI = float64(runeDepth-runeStaked) / float64(runeStaked)
+	}
+	roi.PoolROI = (roi.AssetROI + roi.RuneROI) / 2
+	return &roi
+}
+
+// PoolDepthsBefore gets the last depths of the pool before t.
+func poolDepthsBefore(ctx context.Context, asset string, t time.Time) (assetE8, runeE8 int64, err error) {
+	const q = `SELECT s.asset_e8, s.rune_e8
+FROM aggregate_states s JOIN block_log b ON b.height = s.height
+WHERE s.pool = $1 AND b.timestamp < $2
+ORDER BY s.height DESC
+LIMIT 1`
+
+	rows, err := DBQuery(ctx, q, asset, t.UnixNano())
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&assetE8, &runeE8); err != nil {
+			return 0, 0, err
+		}
+	}
+	return assetE8, runeE8, rows.Err()
+}
+
+// AllPoolsAPY gets the annualised fee yield of each pool with a depth at the
+// start of the window, as the fees deducted relative to that depth.
+func AllPoolsAPY(ctx context.Context, w Window) (map[string]float64, error) {
+	const q = `WITH starts AS (
+	SELECT DISTINCT ON (s.pool) s.pool, s.rune_e8
+	FROM aggregate_states s JOIN block_log b ON b.height = s.height
+	WHERE b.timestamp < $1
+	ORDER BY s.pool, s.height DESC
+), fees AS (
+	SELECT asset AS pool, SUM(pool_deduct) AS rune_e8
+	FROM fee_events
+	WHERE block_timestamp >= $1 AND block_timestamp < $2
+	GROUP BY asset
+)
+SELECT starts.pool, starts.rune_e8, COALESCE(fees.rune_e8, 0)::BIGINT
+FROM starts LEFT JOIN fees ON fees.pool = starts.pool
+WHERE starts.rune_e8 > 0`
+
+	rows, err := DBQuery(ctx, q, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	years := float64(w.Until.Sub(w.Since)) / float64(365*24*time.Hour)
+	m := make(map[string]float64)
+	for rows.Next() {
+		var pool string
+		var depthE8, feesE8 int64
+		if err := rows.Scan(&pool, &depthE8, &feesE8); err != nil {
+			return m, err
+		}
+		m[pool] = float64(feesE8) / float64(depthE8) / years
+	}
+	return m, rows.Err()
+}
+
+// PoolFeeYield is the fee income of a pool relative to its depth.
+type PoolFeeYield struct {
+	FeesRuneE8       int64 // outbound fees deducted in RUNE
+	StartDepthRuneE8 int64
+	AvgDepthRuneE8   int64 // mean of the depth changes, including the start
+}
+
+// PoolFeeYieldLookup gets the fee income of pool within the window.
+func PoolFeeYieldLookup(ctx context.Context, asset string, w Window) (*PoolFeeYield, error) {
+	fees, err := PoolFeesLookup(ctx, asset, w)
+	if err != nil {
+		return nil, err
+	}
+	_, runeStart, err := poolDepthsBefore(ctx, asset, w.Since)
+	if err != nil {
+		return nil, err
+	}
+
+	const q = `SELECT COALESCE(SUM(s.rune_e8), 0), COUNT(*)
+FROM aggregate_states s JOIN block_log b ON b.height = s.height
+WHERE s.pool = $1 AND b.timestamp >= $2 AND b.timestamp < $3`
+	rows, err := DBQuery(ctx, q, asset, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sum, n int64
+	if rows.Next() {
+		if err := rows.Scan(&sum, &n); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	y := PoolFeeYield{FeesRuneE8: fees.PoolDeductTotal, StartDepthRuneE8: runeStart}
+	if runeStart != 0 {
+		sum += runeStart
+		n++
+	}
+	if n != 0 {
+		y.AvgDepthRuneE8 = sum / n
+	}
+	return &y, nil
+}
+
+// APYPoint is the performance of a pool over a rolling window ending at Time.
+type APYPoint struct {
+	Time       time.Time
+	FeeAPY     float64 // annualized fees relative to the average RUNE depth
+	TotalAPY   float64 // annualized fees plus rewards
+	ILEstimate float64 // impermanent loss from the price change
+}
+
+// APYHistory gets the APY over the rolling window at each bucket start within
+// the query window. The rolling window may reach before the query window.
+func APYHistory(ctx context.Context, pool string, rollingWindow time.Duration, bucketInterval time.Duration, queryWindow Window) ([]APYPoint, error) {
+	n, err := bucketsFor(bucketInterval, queryWindow)
+	if err != nil {
+		return nil, err
+	}
+	first := queryWindow.Since.UnixNano() / int64(bucketInterval) * int64(bucketInterval)
+	last := first + (n-1)*int64(bucketInterval)
+
+	const q = `SELECT b.t, COALESCE(f.fees, 0), COALESCE(rw.rewards, 0), COALESCE(d.avg_rune, 0),
+	COALESCE(s0.asset_e8, 0), COALESCE(s0.rune_e8, 0), COALESCE(s1.asset_e8, 0), COALESCE(s1.rune_e8, 0)
+FROM generate_series($2::BIGINT, $3::BIGINT, $4::BIGINT) AS b(t)
+LEFT JOIN LATERAL (
+	SELECT SUM(pool_deduct)::BIGINT AS fees FROM fee_events
+	WHERE asset = $1 AND block_timestamp >= b.t - $5 AND block_timestamp < b.t
+) f ON true
+LEFT JOIN LATERAL (
+	SELECT SUM(rune_E8)::BIGINT AS rewards FROM rewards_event_entries
+	WHERE pool = $1 AND block_timestamp >= b.t - $5 AND block_timestamp < b.t
+) rw ON true
+LEFT JOIN LATERAL (
+	SELECT AVG(s.rune_e8)::BIGINT AS avg_rune
+	FROM aggregate_states s JOIN block_log l ON l.height = s.height
+	WHERE s.pool = $1 AND l.timestamp >= b.t - $5 AND l.timestamp < b.t
+) d ON true
+LEFT JOIN LATERAL (
+	SELECT s.asset_e8, s.rune_e8
+	FROM aggregate_states s JOIN block_log l ON l.height = s.height
+	WHERE s.pool = $1 AND l.timestamp < b.t - $5
+	ORDER BY s.height DESC
+	LIMIT 1
+) s0 ON true
+LEFT JOIN LATERAL (
+	SELECT s.asset_e8, s.rune_e8
+	FROM aggregate_states s JOIN block_log l ON l.height = s.height
+	WHERE s.pool = $1 AND l.timestamp < b.t
+	ORDER BY s.height DESC
+	LIMIT 1
+) s1 ON true
+ORDER BY b.t`
+
+	rows, err := DBQuery(ctx, q, pool, first, last, int64(bucketInterval), int64(rollingWindow))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perYear := float64(365*24*time.Hour) / float64(rollingWindow)
+	a := make([]APYPoint, 0, n)
+	for rows.Next() {
+		var t, fees, rewards, avgRune, assetStart, runeStart, assetEnd, runeEnd int64
+		if err := rows.Scan(&t, &fees, &rewards, &avgRune, &assetStart, &runeStart, &assetEnd, &runeEnd); err != nil {
+			return nil, err
+		}
+		p := APYPoint{Time: time.Unix(0, t)}
+		if avgRune != 0 {
+			p.FeeAPY = float64(fees) / float64(avgRune) * perYear
+			p.TotalAPY = float64(fees+rewards) / float64(avgRune) * perYear
+		}
+		if assetStart != 0 && runeStart != 0 && assetEnd != 0 {
+			p.ILEstimate = ImpermanentLoss(float64(runeStart)/float64(assetStart), float64(runeEnd)/float64(assetEnd))
+		}
+		a = append(a, p)
+	}
+	return a, rows.Err()
+}
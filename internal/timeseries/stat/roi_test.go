@@ -0,0 +1,80 @@
+package stat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/sqltest"
+)
+
+func TestPoolROIForWindow(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolROIForWindow(context.Background(), "BNB.MATIC-416", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestPoolROIForWindowNoActivity(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	for _, d := range []time.Duration{7 * 24 * time.Hour, 365 * 24 * time.Hour} {
+		w := Window{Since: time.Now().Add(-d), Until: time.Now()}
+		got, err := PoolROIForWindow(context.Background(), "NO.SUCHPOOL-000", w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got != (PoolROI{}) {
+			t.Errorf("window %s got %+v, want zero ROI", d, *got)
+		}
+	}
+}
+
+func TestCalcPoolROI(t *testing.T) {
+	if got := calcPoolROI(0, 0, 0, 0); *got != (PoolROI{}) {
+		t.Errorf("no activity got %+v, want zero ROI", *got)
+	}
+
+	got := calcPoolROI(80, 150, 100, 100)
+	if got.AssetROI != -0.2 || got.RuneROI != 0.5 || got.PoolROI != 0.15 {
+		t.Errorf("got %+v, want asset -0.2, RUNE 0.5 and pool 0.15", *got)
+	}
+
+	got = calcPoolROI(90, 90, 100, 100)
+	if got.PoolROI >= 0 {
+		t.Errorf("depth below staked got pool ROI %f, want negative", got.PoolROI)
+	}
+}
+
+func TestPoolFeeYieldLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolFeeYieldLookup(context.Background(), "BNB.MATIC-416", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestAPYHistory(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	w := Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()}
+	got, err := APYHistory(context.Background(), "BNB.MATIC-416", 30*24*time.Hour, 24*time.Hour, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Errorf("got %d buckets, want 31", len(got))
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestAllPoolsAPY(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	w := Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()}
+	got, err := AllPoolsAPY(context.Background(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
@@ -2,6 +2,7 @@ package stat
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
@@ -64,16 +65,88 @@ type PoolStakes struct {
 	First, Last     time.Time
 }
 
+// PoolStakesLookup gets the stakes of asset within the window. Results for
+// windows until the latest block come from StdPoolStakesCache when present.
 func PoolStakesLookup(ctx context.Context, asset string, w Window) (*PoolStakes, error) {
+	if r, ok := StdPoolStakesCache.get(asset, w); ok {
+		return &r, nil
+	}
+
 	const q = `SELECT $1, COALESCE(COUNT(*), 0), COALESCE(SUM(asset_e8), 0), COALESCE(SUM(rune_e8), 0), COALESCE(SUM(stake_units), 0), COALESCE(MIN(block_timestamp), 0), COALESCE(MAX(block_timestamp), 0)
 FROM stake_events
 WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3`
 
 	var a [1]PoolStakes
 	_, err := appendPoolStakes(ctx, a[:0], q, asset, w.Since.UnixNano(), w.Until.UnixNano())
+	if err == nil {
+		StdPoolStakesCache.put(asset, w, a[0])
+	}
 	return &a[0], err
 }
 
+// StdPoolStakesCache is the cache of PoolStakesLookup.
+var StdPoolStakesCache PoolStakesCache
+
+// PoolStakesCache holds PoolStakes for windows which end at the latest block
+// timestamp, either exclusive or inclusive, i.e., plus one nanosecond. Any
+// other window bypasses the cache. The zero value is disabled until the first
+// Invalidate.
+type PoolStakesCache struct {
+	mutex   sync.RWMutex
+	latest  int64 // block timestamp
+	entries map[poolStakesKey]PoolStakes
+}
+
+type poolStakesKey struct {
+	pool  string
+	since int64
+	until int64 // either latest or latest + 1
+}
+
+// Invalidate drops all entries, and it accepts windows until latest from now
+// on. Call on each block commit.
+func (c *PoolStakesCache) Invalidate(latest time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.latest = latest.UnixNano()
+	c.entries = nil
+}
+
+// Key gets the map key of the window, if accepted. Lookups may span an
+// invalidation, which is why windows past the latest block (with time.Now for
+// example) are not accepted.
+func (c *PoolStakesCache) key(pool string, w Window) (k poolStakesKey, ok bool) {
+	until := w.Until.UnixNano()
+	if c.latest == 0 || (until != c.latest && until != c.latest+1) {
+		return k, false
+	}
+	return poolStakesKey{pool, w.Since.UnixNano(), until}, true
+}
+
+func (c *PoolStakesCache) get(pool string, w Window) (PoolStakes, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	k, ok := c.key(pool, w)
+	if !ok {
+		return PoolStakes{}, false
+	}
+	r, ok := c.entries[k]
+	return r, ok
+}
+
+func (c *PoolStakesCache) put(pool string, w Window, r PoolStakes) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	k, ok := c.key(pool, w)
+	if !ok {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[poolStakesKey]PoolStakes)
+	}
+	c.entries[k] = r
+}
+
 func PoolStakesBucketsLookup(ctx context.Context, asset string, bucketSize time.Duration, w Window) ([]PoolStakes, error) {
 	n, err := bucketsFor(bucketSize, w)
 	if err != nil {
@@ -149,3 +222,39 @@ func appendPoolStakes(ctx context.Context, a []PoolStakes, q string, args ...int
 	}
 	return a, rows.Err()
 }
+
+// StakeEvent is a single stake in a pool.
+type StakeEvent struct {
+	TxID     string // RUNE transaction, or asset transaction when absent
+	RuneAddr string
+	AssetE8  int64
+	RuneE8   int64
+	Units    int64
+	Time     time.Time
+}
+
+// PoolStakeEventList gets the stakes of pool within the window, most recent first.
+func PoolStakeEventList(ctx context.Context, pool string, w Window, limit, offset int) ([]StakeEvent, error) {
+	const q = `SELECT COALESCE(NULLIF(rune_tx, ''), asset_tx), rune_addr, asset_E8, rune_E8, stake_units, block_timestamp
+FROM stake_events
+WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+ORDER BY block_timestamp DESC
+LIMIT $4 OFFSET $5`
+	rows, err := DBQuery(ctx, q, pool, w.Since.UnixNano(), w.Until.UnixNano(), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]StakeEvent, 0, limit)
+	for rows.Next() {
+		var e StakeEvent
+		var timestamp int64
+		if err := rows.Scan(&e.TxID, &e.RuneAddr, &e.AssetE8, &e.RuneE8, &e.Units, &timestamp); err != nil {
+			return a, err
+		}
+		e.Time = time.Unix(0, timestamp)
+		a = append(a, e)
+	}
+	return a, rows.Err()
+}
@@ -35,6 +35,55 @@ func TestPoolStakesLookup(t *testing.T) {
 	t.Logf("got %+v", got)
 }
 
+func TestPoolStakesCache(t *testing.T) {
+	var c PoolStakesCache
+	latest := time.Unix(1600000000, 0)
+	w := Window{Since: time.Unix(0, 0), Until: latest}
+
+	c.put("BNB.BNB", w, PoolStakes{Asset: "BNB.BNB", TxCount: 7})
+	if _, ok := c.get("BNB.BNB", w); ok {
+		t.Fatal("got entry before first invalidate")
+	}
+
+	c.Invalidate(latest)
+	c.put("BNB.BNB", w, PoolStakes{Asset: "BNB.BNB", TxCount: 7})
+	if got, ok := c.get("BNB.BNB", w); !ok || got.TxCount != 7 {
+		t.Errorf("got %+v, %t; want TxCount 7", got, ok)
+	}
+	if _, ok := c.get("BNB.BNB", Window{Since: w.Since, Until: latest.Add(-time.Second)}); ok {
+		t.Error("got entry for earlier window end")
+	}
+	if _, ok := c.get("BNB.BNB", Window{Since: time.Unix(1, 0), Until: latest}); ok {
+		t.Error("got entry for other window start")
+	}
+
+	// latest block included
+	inclusive := Window{Since: w.Since, Until: latest.Add(1)}
+	if _, ok := c.get("BNB.BNB", inclusive); ok {
+		t.Error("got entry of the exclusive window for the inclusive one")
+	}
+	c.put("BNB.BNB", inclusive, PoolStakes{Asset: "BNB.BNB", TxCount: 8})
+	if got, ok := c.get("BNB.BNB", inclusive); !ok || got.TxCount != 8 {
+		t.Errorf("inclusive got %+v, %t; want TxCount 8", got, ok)
+	}
+	if got, ok := c.get("BNB.BNB", w); !ok || got.TxCount != 7 {
+		t.Errorf("exclusive got %+v, %t; want TxCount 7", got, ok)
+	}
+	c.put("BNB.BNB", Window{Since: w.Since, Until: latest.Add(time.Second)}, PoolStakes{Asset: "BNB.BNB"})
+	if _, ok := c.get("BNB.BNB", Window{Since: w.Since, Until: latest.Add(time.Second)}); ok {
+		t.Error("got entry for window past the latest block")
+	}
+
+	c.Invalidate(latest.Add(time.Second))
+	if _, ok := c.get("BNB.BNB", w); ok {
+		t.Error("got entry after invalidate")
+	}
+	c.put("BNB.BNB", w, PoolStakes{Asset: "BNB.BNB"})
+	if _, ok := c.get("BNB.BNB", Window{Since: w.Since, Until: latest.Add(time.Second)}); ok {
+		t.Error("got entry from put with outdated window")
+	}
+}
+
 func TestPoolStakesBucketsLookup(t *testing.T) {
 	DBQuery = sqltest.NewTx(t).QueryContext
 	got, err := PoolStakesBucketsLookup(context.Background(), "BNB.MATIC-416", time.Hour, Window{Since: time.Now().Add(-24 * time.Hour), Until: time.Now()})
@@ -70,3 +119,12 @@ func TestAllPoolStakesAddrLookup(t *testing.T) {
 	}
 	t.Logf("got %+v", got)
 }
+
+func TestPoolStakeEventList(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolStakeEventList(context.Background(), "BNB.MATIC-416", Window{Since: time.Now().Add(-24 * time.Hour), Until: time.Now()}, 50, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
@@ -0,0 +1,679 @@
+package stat
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// StakerEvent is a stake, an unstake or a swap from a staker address.
+type StakerEvent struct {
+	Type    string // "stake", "unstake" or "swap"
+	Pool    string
+	AssetE8 int64 // pool asset amount, withdrawn on unstakes
+	RuneE8  int64 // withdrawn on unstakes
+	Height  int64 // zero when unknown
+	Time    time.Time
+}
+
+// StakerEventHistory gets the events of addr, most recent first. Unstakes have
+// the amounts paid out, as opposed to the amount sent with the request.
+func StakerEventHistory(ctx context.Context, addr string, w Window, limit, offset int) ([]StakerEvent, error) {
+	q := `SELECT e.type, e.pool, e.asset_E8, e.rune_E8, COALESCE(b.height, 0), e.block_timestamp
+FROM (
+	SELECT 'stake' AS type, pool, asset_E8, rune_E8, block_timestamp
+	FROM stake_events
+	WHERE rune_addr = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+	UNION ALL
+	SELECT 'unstake', u.pool, paid.asset_E8, paid.rune_E8, u.block_timestamp
+	FROM unstake_events u ` + unstakeOutboundsJoin + `
+	WHERE u.from_addr = $1 AND u.block_timestamp >= $2 AND u.block_timestamp < $3
+	UNION ALL
+	SELECT 'swap', pool, CASE WHEN from_asset = pool THEN from_E8 ELSE 0 END, CASE WHEN from_asset = pool THEN 0 ELSE from_E8 END, block_timestamp
+	FROM swap_events
+	WHERE from_addr = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+) e
+LEFT JOIN block_log b ON b.timestamp = e.block_timestamp
+ORDER BY e.block_timestamp DESC
+LIMIT $4 OFFSET $5`
+
+	rows, err := DBQuery(ctx, q, addr, w.Since.UnixNano(), w.Until.UnixNano(), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []StakerEvent
+	for rows.Next() {
+		var e StakerEvent
+		var timestamp int64
+		if err := rows.Scan(&e.Type, &e.Pool, &e.AssetE8, &e.RuneE8, &e.Height, &timestamp); err != nil {
+			return a, err
+		}
+		e.Time = time.Unix(0, timestamp)
+		a = append(a, e)
+	}
+	return a, rows.Err()
+}
+
+// StakerTx is a swap, a stake, an unstake or a refund of a staker address.
+type StakerTx struct {
+	Type    string // "swap", "stake", "unstake" or "refund"
+	TxID    string
+	Pool    string // empty for refunds
+	Asset   string // asset sent in, the pool on unstakes, or the asset refunded
+	AssetE8 int64  // non-RUNE amount, withdrawn on unstakes
+	RuneE8  int64  // withdrawn on unstakes
+	Reason  string // refunds only
+	Height  int64  // zero when unknown
+	Time    time.Time
+}
+
+// StakerTxsLookup gets the transactions of addr, most recent first, with a
+// single query for all event types. The type filter is either empty for all,
+// or one of the StakerTx types. The total counts all matches within the window,
+// and it is zero when the page has no entries. Unstakes have the amounts paid
+// out, as opposed to the amount sent with the request.
+func StakerTxsLookup(ctx context.Context, addr, txType string, w Window, limit, offset int) (txs []StakerTx, total int64, err error) {
+	q := `SELECT e.type, e.tx, e.pool, e.asset, e.asset_E8, e.rune_E8, e.reason, COALESCE(b.height, 0), e.block_timestamp, COUNT(*) OVER ()
+FROM (
+	SELECT 'swap'::text AS type, tx, pool, from_asset AS asset,
+		CASE WHEN from_asset = pool THEN from_E8 ELSE 0 END AS asset_E8,
+		CASE WHEN from_asset = pool THEN 0 ELSE from_E8 END AS rune_E8,
+		'' AS reason, block_timestamp
+	FROM swap_events
+	WHERE from_addr = $1 AND block_timestamp >= $2 AND block_timestamp < $3 AND $4 IN ('', 'swap')
+	UNION ALL
+	SELECT 'stake'::text, COALESCE(NULLIF(rune_tx, ''), asset_tx), pool, pool, asset_E8, rune_E8, '', block_timestamp
+	FROM stake_events
+	WHERE rune_addr = $1 AND block_timestamp >= $2 AND block_timestamp < $3 AND $4 IN ('', 'stake')
+	UNION ALL
+	SELECT 'unstake'::text, u.tx, u.pool, u.pool, paid.asset_E8, paid.rune_E8, '', u.block_timestamp
+	FROM unstake_events u ` + unstakeOutboundsJoin + `
+	WHERE u.from_addr = $1 AND u.block_timestamp >= $2 AND u.block_timestamp < $3 AND $4 IN ('', 'unstake')
+	UNION ALL
+	SELECT 'refund'::text, tx, '', asset,
+		CASE WHEN asset IN ('THOR.RUNE', 'BNB.RUNE-67C', 'BNB.RUNE-B1A') THEN 0 ELSE asset_E8 END,
+		CASE WHEN asset IN ('THOR.RUNE', 'BNB.RUNE-67C', 'BNB.RUNE-B1A') THEN asset_E8 ELSE 0 END,
+		reason, block_timestamp
+	FROM refund_events
+	WHERE from_addr = $1 AND block_timestamp >= $2 AND block_timestamp < $3 AND $4 IN ('', 'refund')
+) e
+LEFT JOIN block_log b ON b.timestamp = e.block_timestamp
+ORDER BY e.block_timestamp DESC
+LIMIT $5 OFFSET $6`
+
+	rows, err := DBQuery(ctx, q, addr, w.Since.UnixNano(), w.Until.UnixNano(), txType, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tx StakerTx
+		var timestamp int64
+		if err := rows.Scan(&tx.Type, &tx.TxID, &tx.Pool, &tx.Asset, &tx.AssetE8, &tx.RuneE8, &tx.Reason, &tx.Height, &timestamp, &total); err != nil {
+			return txs, total, err
+		}
+		tx.Time = time.Unix(0, timestamp)
+		txs = append(txs, tx)
+	}
+	return txs, total, rows.Err()
+}
+
+// StakerPoolPosition is the remaining stake of an address in a pool.
+type StakerPoolPosition struct {
+	AssetE8    int64 // staked for the remaining units
+	RuneE8     int64 // staked for the remaining units
+	Units      int64
+	FirstStake time.Time // zero for none
+}
+
+// StakerPoolPositionLookup gets the position of addr in pool. Partial unstakes
+// reduce the staked amounts proportional to the units.
+func StakerPoolPositionLookup(ctx context.Context, pool, addr string, w Window) (*StakerPoolPosition, error) {
+	stakes, err := PoolStakesAddrLookup(ctx, pool, addr, w)
+	if err != nil {
+		return nil, err
+	}
+
+	const q = `SELECT COALESCE(SUM(stake_units), 0)
+FROM unstake_events
+WHERE from_addr = $1 AND pool = $2 AND block_timestamp >= $3 AND block_timestamp < $4`
+	rows, err := DBQuery(ctx, q, addr, pool, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var unstakedUnits int64
+	if rows.Next() {
+		if err := rows.Scan(&unstakedUnits); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	p := StakerPoolPosition{FirstStake: stakes.First}
+	p.Units = stakes.StakeUnitsTotal - unstakedUnits
+	if p.Units > 0 && stakes.StakeUnitsTotal > 0 {
+		p.AssetE8 = int64(float64(stakes.AssetE8Total) * float64(p.Units) / float64(stakes.StakeUnitsTotal))
+		p.RuneE8 = int64(float64(stakes.RuneE8Total) * float64(p.Units) / float64(stakes.StakeUnitsTotal))
+	}
+	return &p, nil
+}
+
+// ImpermanentLoss returns the value ratio lost by staking instead of holding,
+// as a negative number, for a price change from entry to current.
+func ImpermanentLoss(entryPrice, currentPrice float64) float64 {
+	k := currentPrice / entryPrice
+	return 2*math.Sqrt(k)/(1+k) - 1
+}
+
+// StakerFees are the estimated pool rewards earned by an address.
+type StakerFees struct {
+	RuneE8      int64
+	LastUnstake time.Time // zero for none
+}
+
+// StakerFeesLookup estimates the pool rewards of addr in pool. Each reward
+// entry in the window applies with the unit share of addr at the time, which
+// makes the result a time-weighted average of the ownership.
+func StakerFeesLookup(ctx context.Context, addr, pool string, w Window) (*StakerFees, error) {
+	const q = `WITH changes AS (
+	SELECT block_timestamp, stake_units AS pool_units, CASE WHEN rune_addr = $2 THEN stake_units ELSE 0 END AS addr_units
+	FROM stake_events
+	WHERE pool = $1 AND block_timestamp < $4
+	UNION ALL
+	SELECT block_timestamp, -stake_units, CASE WHEN from_addr = $2 THEN -stake_units ELSE 0 END
+	FROM unstake_events
+	WHERE pool = $1 AND block_timestamp < $4
+), totals AS (
+	SELECT block_timestamp,
+		SUM(SUM(pool_units)) OVER (ORDER BY block_timestamp) AS pool_units,
+		SUM(SUM(addr_units)) OVER (ORDER BY block_timestamp) AS addr_units
+	FROM changes
+	GROUP BY block_timestamp
+)
+SELECT
+	COALESCE(SUM(r.rune_E8::NUMERIC * t.addr_units / t.pool_units), 0)::BIGINT,
+	(SELECT COALESCE(MAX(block_timestamp), 0) FROM unstake_events WHERE pool = $1 AND from_addr = $2 AND block_timestamp < $4)
+FROM rewards_event_entries r
+CROSS JOIN LATERAL (
+	SELECT pool_units, addr_units FROM totals
+	WHERE totals.block_timestamp <= r.block_timestamp
+	ORDER BY totals.block_timestamp DESC
+	LIMIT 1
+) t
+WHERE r.pool = $1 AND r.block_timestamp >= $3 AND r.block_timestamp < $4 AND t.pool_units > 0 AND t.addr_units > 0`
+
+	rows, err := DBQuery(ctx, q, pool, addr, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fees StakerFees
+	if rows.Next() {
+		var lastUnstake int64
+		if err := rows.Scan(&fees.RuneE8, &lastUnstake); err != nil {
+			return nil, err
+		}
+		if lastUnstake != 0 {
+			fees.LastUnstake = time.Unix(0, lastUnstake)
+		}
+	}
+	return &fees, rows.Err()
+}
+
+// PoolCostBasis is the RUNE accounting of the stakes from an address in a pool.
+// Amounts are valued at the pool price of their block. Unstakes consume stake
+// units first in, first out. The proceeds are the outbounds of the unstakes.
+type PoolCostBasis struct {
+	Pool               string
+	Units              int64   // remaining
+	CostRuneE8         int64   // of the remaining units
+	EntryPrice         float64 // RUNE per asset, weighted by the remaining units
+	UnstakedCostRuneE8 int64   // of the units unstaked
+	ProceedsRuneE8     int64   // from the units unstaked
+}
+
+// StakerCostBasis gets the cost basis of addr for each pool staked, in
+// alphabetical order.
+func StakerCostBasis(ctx context.Context, addr string) ([]PoolCostBasis, error) {
+	q := `SELECT e.pool, e.units, e.asset_E8, e.rune_E8, COALESCE(d.asset_e8, 0), COALESCE(d.rune_e8, 0)
+FROM (
+	SELECT pool, stake_units AS units, asset_E8, rune_E8, block_timestamp
+	FROM stake_events
+	WHERE rune_addr = $1
+	UNION ALL
+	SELECT u.pool, -u.stake_units, paid.asset_E8, paid.rune_E8, u.block_timestamp
+	FROM unstake_events u
+	` + unstakeOutboundsJoin + `
+	WHERE u.from_addr = $1
+) e
+LEFT JOIN LATERAL (
+	SELECT s.asset_e8, s.rune_e8
+	FROM aggregate_states s JOIN block_log b ON b.height = s.height
+	WHERE s.pool = e.pool AND b.timestamp <= e.block_timestamp
+	ORDER BY s.height DESC
+	LIMIT 1
+) d ON true
+ORDER BY e.pool, e.block_timestamp`
+
+	rows, err := DBQuery(ctx, q, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []PoolCostBasis
+	var pool string
+	var events []lpEvent
+	for rows.Next() {
+		var p string
+		var e lpEvent
+		if err := rows.Scan(&p, &e.Units, &e.AssetE8, &e.RuneE8, &e.AssetDepth, &e.RuneDepth); err != nil {
+			return nil, err
+		}
+		if p != pool && len(events) != 0 {
+			a = append(a, costBasisOf(pool, events))
+			events = events[:0]
+		}
+		pool = p
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) != 0 {
+		a = append(a, costBasisOf(pool, events))
+	}
+	return a, nil
+}
+
+// LpEvent is a stake, with positive units, or an unstake, with negative units,
+// including the pool depths at the time.
+type lpEvent struct {
+	Units, AssetE8, RuneE8 int64
+	AssetDepth, RuneDepth  int64
+}
+
+// RuneValue returns the amounts in RUNE at the pool price.
+func (e *lpEvent) runeValue() float64 {
+	v := float64(e.RuneE8)
+	if e.AssetDepth != 0 {
+		v += float64(e.AssetE8) * float64(e.RuneDepth) / float64(e.AssetDepth)
+	}
+	return v
+}
+
+// CostBasisOf accounts events in chronological order.
+func costBasisOf(pool string, events []lpEvent) PoolCostBasis {
+	type lot struct {
+		units int64
+		cost  float64 // RUNE
+		price float64 // RUNE per asset
+	}
+	var lots []lot
+	var unstakedCost, proceeds float64
+	for i := range events {
+		e := &events[i]
+		if e.Units >= 0 {
+			l := lot{units: e.Units, cost: e.runeValue()}
+			if e.AssetDepth != 0 {
+				l.price = float64(e.RuneDepth) / float64(e.AssetDepth)
+			}
+			lots = append(lots, l)
+			continue
+		}
+
+		proceeds += e.runeValue()
+		for units := -e.Units; units > 0 && len(lots) != 0; {
+			l := &lots[0]
+			if l.units <= units {
+				units -= l.units
+				unstakedCost += l.cost
+				lots = lots[1:]
+				continue
+			}
+			part := l.cost * float64(units) / float64(l.units)
+			unstakedCost += part
+			l.cost -= part
+			l.units -= units
+			units = 0
+		}
+	}
+
+	c := PoolCostBasis{
+		Pool:               pool,
+		UnstakedCostRuneE8: int64(math.Round(unstakedCost)),
+		ProceedsRuneE8:     int64(math.Round(proceeds)),
+	}
+	var cost, weightedPrice float64
+	for _, l := range lots {
+		c.Units += l.units
+		cost += l.cost
+		weightedPrice += l.price * float64(l.units)
+	}
+	c.CostRuneE8 = int64(math.Round(cost))
+	if c.Units != 0 {
+		c.EntryPrice = weightedPrice / float64(c.Units)
+	}
+	return c
+}
+
+// LPPosition is the position of an address in a pool.
+type LPPosition struct {
+	Addr string
+	StakerPoolPosition
+}
+
+// PoolLPPositionsLookup gets the current positions in pool, with up to limit
+// entries, oldest first stake first. A zero limit gets all of them. Staked
+// amounts reduce proportional to the units unstaked, like
+// StakerPoolPositionLookup does.
+func PoolLPPositionsLookup(ctx context.Context, pool string, limit int) ([]LPPosition, error) {
+	const q = `SELECT s.addr, s.asset_E8, s.rune_E8, s.units, COALESCE(u.units, 0), s.first
+FROM (
+	SELECT rune_addr AS addr, SUM(asset_E8)::BIGINT AS asset_E8, SUM(rune_E8)::BIGINT AS rune_E8, SUM(stake_units)::BIGINT AS units, MIN(block_timestamp) AS first
+	FROM stake_events
+	WHERE pool = $1
+	GROUP BY rune_addr
+) AS s LEFT JOIN (
+	SELECT from_addr AS addr, SUM(stake_units)::BIGINT AS units
+	FROM unstake_events
+	WHERE pool = $1
+	GROUP BY from_addr
+) AS u ON s.addr = u.addr
+WHERE s.units > COALESCE(u.units, 0)
+ORDER BY s.first
+LIMIT NULLIF($2, 0)`
+	rows, err := DBQuery(ctx, q, pool, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []LPPosition
+	for rows.Next() {
+		var p LPPosition
+		var assetE8Total, runeE8Total, stakedUnits, unstakedUnits, first int64
+		if err := rows.Scan(&p.Addr, &assetE8Total, &runeE8Total, &stakedUnits, &unstakedUnits, &first); err != nil {
+			return a, err
+		}
+		p.FirstStake = time.Unix(0, first)
+		p.Units = stakedUnits - unstakedUnits
+		p.AssetE8 = int64(float64(assetE8Total) * float64(p.Units) / float64(stakedUnits))
+		p.RuneE8 = int64(float64(runeE8Total) * float64(p.Units) / float64(stakedUnits))
+		a = append(a, p)
+	}
+	return a, rows.Err()
+}
+
+// LPBehavior is the activity of a liquidity provider in a pool.
+type LPBehavior struct {
+	Addr           string
+	Units          int64         // current stake units
+	HoldTime       time.Duration // since the first stake
+	TxCount        int64         // stakes and unstakes
+	TxPerMonth     float64       // TxCount per 30 days of HoldTime
+	StakedRuneE8   int64         // both sides, with asset at the current pool price
+	SwapRuneE8     int64         // swapped from the address in the pool
+	SwapStakeRatio float64       // SwapRuneE8 per StakedRuneE8
+}
+
+// LPBehaviorMetrics gets the current liquidity providers of pool with their
+// activity up to the last block. Swaps count by the RUNE address of the
+// provider only, as stakes hold no asset address, and asset amounts count at
+// the current pool price.
+func LPBehaviorMetrics(ctx context.Context, pool string) ([]LPBehavior, error) {
+	const q = `WITH depth AS (
+	SELECT asset_e8, rune_e8 FROM aggregate_states
+	WHERE pool = $1
+	ORDER BY height DESC
+	LIMIT 1
+)
+SELECT s.addr, s.units - COALESCE(u.units, 0), s.first, s.n + COALESCE(u.n, 0),
+	(s.rune_E8 + COALESCE(s.asset_E8 * d.rune_e8 / d.asset_e8, 0))::BIGINT,
+	COALESCE(x.rune_E8, 0), COALESCE((SELECT MAX(timestamp) FROM block_log), 0)
+FROM (
+	SELECT rune_addr AS addr, SUM(asset_E8) AS asset_E8, SUM(rune_E8) AS rune_E8, SUM(stake_units)::BIGINT AS units, MIN(block_timestamp) AS first, COUNT(*) AS n
+	FROM stake_events
+	WHERE pool = $1
+	GROUP BY rune_addr
+) AS s LEFT JOIN (
+	SELECT from_addr AS addr, SUM(stake_units)::BIGINT AS units, COUNT(*) AS n
+	FROM unstake_events
+	WHERE pool = $1
+	GROUP BY from_addr
+) AS u ON s.addr = u.addr
+LEFT JOIN depth d ON d.asset_e8 > 0
+LEFT JOIN (
+	SELECT e.from_addr AS addr, SUM(CASE
+		WHEN e.from_asset IN ('THOR.RUNE', 'BNB.RUNE-67C', 'BNB.RUNE-B1A') THEN e.from_E8
+		ELSE COALESCE(e.from_E8::NUMERIC * d.rune_e8 / d.asset_e8, 0) END)::BIGINT AS rune_E8
+	FROM swap_events e
+	LEFT JOIN depth d ON d.asset_e8 > 0
+	WHERE e.pool = $1
+	GROUP BY e.from_addr
+) AS x ON s.addr = x.addr
+WHERE s.units > COALESCE(u.units, 0)
+ORDER BY s.first`
+	rows, err := DBQuery(ctx, q, pool)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []LPBehavior
+	for rows.Next() {
+		var b LPBehavior
+		var first, last int64
+		if err := rows.Scan(&b.Addr, &b.Units, &first, &b.TxCount, &b.StakedRuneE8, &b.SwapRuneE8, &last); err != nil {
+			return a, err
+		}
+		if last > first {
+			b.HoldTime = time.Duration(last - first)
+			b.TxPerMonth = float64(b.TxCount) / (float64(b.HoldTime) / float64(30*24*time.Hour))
+		}
+		if b.StakedRuneE8 != 0 {
+			b.SwapStakeRatio = float64(b.SwapRuneE8) / float64(b.StakedRuneE8)
+		}
+		a = append(a, b)
+	}
+	return a, rows.Err()
+}
+
+// PoolTurnover is the liquidity which entered and left a pool.
+type PoolTurnover struct {
+	NewCapitalRuneE8    int64
+	ExitedCapitalRuneE8 int64
+	AvgDepthRuneE8      float64 // both sides
+	AvgHoldingDays      float64 // zero without round trips
+	RoundTripCount      int64   // addresses which entered and exited
+}
+
+// PoolTurnoverLookup gets the liquidity flows of pool within the window. Unstake
+// events hold no withdrawn amounts, so both flows are the stake units valued
+// at the share of both sides of the pool at the end of the window. The holding
+// period counts from the first stake to the last unstake, for the addresses
+// with both in the window.
+func PoolTurnoverLookup(ctx context.Context, pool string, w Window) (*PoolTurnover, error) {
+	const unitsQ = `SELECT
+	COALESCE((SELECT SUM(stake_units) FROM stake_events WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3), 0)::BIGINT,
+	COALESCE((SELECT SUM(stake_units) FROM unstake_events WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3), 0)::BIGINT,
+	COALESCE((SELECT SUM(stake_units) FROM stake_events WHERE pool = $1 AND block_timestamp < $3), 0)::BIGINT
+	- COALESCE((SELECT SUM(stake_units) FROM unstake_events WHERE pool = $1 AND block_timestamp < $3), 0)::BIGINT,
+	COALESCE((SELECT s.rune_e8 FROM aggregate_states s JOIN block_log b ON b.height = s.height
+		WHERE s.pool = $1 AND b.timestamp < $3
+		ORDER BY s.height DESC LIMIT 1), 0)`
+	rows, err := DBQuery(ctx, unitsQ, pool, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	var stakedUnits, unstakedUnits, poolUnits, runeE8 int64
+	if rows.Next() {
+		err = rows.Scan(&stakedUnits, &unstakedUnits, &poolUnits, &runeE8)
+	}
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var t PoolTurnover
+	if poolUnits > 0 {
+		perUnit := 2 * float64(runeE8) / float64(poolUnits)
+		t.NewCapitalRuneE8 = int64(float64(stakedUnits) * perUnit)
+		t.ExitedCapitalRuneE8 = int64(float64(unstakedUnits) * perUnit)
+	}
+
+	depths, err := PoolDepthStatsLookup(ctx, pool, w)
+	if err != nil {
+		return nil, err
+	}
+	t.AvgDepthRuneE8 = 2 * depths.MeanRuneE8
+
+	const holdQ = `SELECT COUNT(*), COALESCE(AVG(u.last - s.first), 0)::FLOAT
+FROM (
+	SELECT rune_addr AS addr, MIN(block_timestamp) AS first FROM stake_events
+	WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+	GROUP BY rune_addr
+) AS s JOIN (
+	SELECT from_addr AS addr, MAX(block_timestamp) AS last FROM unstake_events
+	WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+	GROUP BY from_addr
+) AS u ON s.addr = u.addr AND s.first < u.last`
+	rows, err = DBQuery(ctx, holdQ, pool, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var avgNanos float64
+	if rows.Next() {
+		if err := rows.Scan(&t.RoundTripCount, &avgNanos); err != nil {
+			return nil, err
+		}
+	}
+	t.AvgHoldingDays = avgNanos / float64(24*time.Hour)
+	return &t, rows.Err()
+}
+
+// RetentionMilestones are the periods after the first stake at which
+// LPRetentionCohorts checks for a position.
+var RetentionMilestones = []time.Duration{30 * 24 * time.Hour, 90 * 24 * time.Hour, 365 * 24 * time.Hour}
+
+// RetentionCohort are the liquidity providers with their first stake in a
+// time bucket.
+type RetentionCohort struct {
+	Start      time.Time
+	InitialLPs int64
+	// number of providers with units in the pool at each of the
+	// RetentionMilestones after their first stake
+	StillActive []int64
+}
+
+// LPRetentionCohorts gets the liquidity providers of pool grouped by the
+// bucket of their first stake, in chronological order. Buckets align with the
+// Unix epoch. Milestones beyond the last event count the current positions.
+func LPRetentionCohorts(ctx context.Context, pool string, cohortInterval time.Duration) ([]RetentionCohort, error) {
+	const q = `WITH firsts AS (
+	SELECT rune_addr AS addr, MIN(block_timestamp) AS first
+	FROM stake_events
+	WHERE pool = $1
+	GROUP BY rune_addr
+), changes AS (
+	SELECT rune_addr AS addr, stake_units AS units, block_timestamp
+	FROM stake_events
+	WHERE pool = $1
+	UNION ALL
+	SELECT from_addr, -stake_units, block_timestamp
+	FROM unstake_events
+	WHERE pool = $1
+)
+SELECT time_bucket($2, f.first) AS cohort, COUNT(*),
+	COUNT(*) FILTER (WHERE m.at1 > 0),
+	COUNT(*) FILTER (WHERE m.at2 > 0),
+	COUNT(*) FILTER (WHERE m.at3 > 0)
+FROM firsts f
+CROSS JOIN LATERAL (
+	SELECT SUM(c.units) FILTER (WHERE c.block_timestamp < f.first + $3) AS at1,
+		SUM(c.units) FILTER (WHERE c.block_timestamp < f.first + $4) AS at2,
+		SUM(c.units) FILTER (WHERE c.block_timestamp < f.first + $5) AS at3
+	FROM changes c
+	WHERE c.addr = f.addr
+) AS m
+GROUP BY cohort
+ORDER BY cohort`
+	rows, err := DBQuery(ctx, q, pool, cohortInterval.Nanoseconds(), RetentionMilestones[0].Nanoseconds(), RetentionMilestones[1].Nanoseconds(), RetentionMilestones[2].Nanoseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []RetentionCohort
+	for rows.Next() {
+		var start int64
+		c := RetentionCohort{StillActive: make([]int64, len(RetentionMilestones))}
+		if err := rows.Scan(&start, &c.InitialLPs, &c.StillActive[0], &c.StillActive[1], &c.StillActive[2]); err != nil {
+			return a, err
+		}
+		c.Start = time.Unix(0, start)
+		a = append(a, c)
+	}
+	return a, rows.Err()
+}
+
+// NodeOperatorPosition is a pool position of a node operator.
+type NodeOperatorPosition struct {
+	Node      string // node address
+	Addr      string // staker address
+	Pool      string
+	Units     int64
+	PoolUnits int64 // total of the pool
+}
+
+// NodeOperatorLPOverlap gets the current pool positions of node operators. An
+// operator stakes either from the node address itself, or from an address
+// which bonded to the node, i.e., with the node address in the bond memo.
+func NodeOperatorLPOverlap(ctx context.Context) ([]NodeOperatorPosition, error) {
+	const q = `WITH operators AS (
+	SELECT node_addr AS node, node_addr AS addr FROM update_node_account_status_events
+	UNION
+	SELECT SPLIT_PART(memo, ':', 2), from_addr FROM bond_events
+	WHERE bound_type = 'bond_paid' AND SPLIT_PART(memo, ':', 2) <> ''
+), changes AS (
+	SELECT rune_addr AS addr, pool, stake_units AS units FROM stake_events
+	UNION ALL
+	SELECT from_addr, pool, -stake_units FROM unstake_events
+), pools AS (
+	SELECT pool, SUM(units)::BIGINT AS units FROM changes GROUP BY pool
+), positions AS (
+	SELECT addr, pool, SUM(units)::BIGINT AS units FROM changes GROUP BY addr, pool
+	HAVING SUM(units) > 0
+)
+SELECT o.node, p.addr, p.pool, p.units, l.units
+FROM operators o
+JOIN positions p ON p.addr = o.addr
+JOIN pools l ON l.pool = p.pool
+ORDER BY o.node, p.addr, p.pool`
+	rows, err := DBQuery(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []NodeOperatorPosition
+	for rows.Next() {
+		var p NodeOperatorPosition
+		if err := rows.Scan(&p.Node, &p.Addr, &p.Pool, &p.Units, &p.PoolUnits); err != nil {
+			return a, err
+		}
+		a = append(a, p)
+	}
+	return a, rows.Err()
+}
@@ -0,0 +1,278 @@
+package stat
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/sqltest"
+)
+
+func TestStakerEventHistory(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := StakerEventHistory(context.Background(), "tbnb1uhkhl8ctdqal2rnx3n9k4hrf4yfqcz4wzuqc43", testWindow, 200, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestStakerEventHistoryUnstake(t *testing.T) {
+	tx := sqltest.NewTx(t)
+	DBQuery = tx.QueryContext
+
+	const addr, pool = "thor1eventhistory", "BTC.TEST-HISTORY"
+	t0 := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	exec := func(q string, args ...interface{}) {
+		if _, err := tx.Exec(q, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// request with a donation of 1 RUNE
+	exec(`INSERT INTO unstake_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, pool, stake_units, basis_points, asymmetry, block_timestamp)
+VALUES ('UNSTAKEHISTORY', 'THOR', $1, '', 'THOR.RUNE', 1, '', $2, 50, 5000, 0, $3)`, addr, pool, t0)
+	exec(`INSERT INTO outbound_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, in_tx, block_timestamp)
+VALUES ('OUTHISTORY1', 'BTC', '', 'bc1a', $1, 60, '', 'UNSTAKEHISTORY', $2), ('OUTHISTORY2', 'THOR', '', $3, 'THOR.RUNE', 55, '', 'UNSTAKEHISTORY', $2)`, pool, t0+1, addr)
+
+	got, err := StakerEventHistory(context.Background(), addr, Window{Since: time.Unix(0, t0), Until: time.Unix(0, t0+2)}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []StakerEvent{{Type: "unstake", Pool: pool, AssetE8: 60, RuneE8: 55, Time: time.Unix(0, t0)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v", got)
+		t.Errorf("want %+v", want)
+	}
+}
+
+func TestStakerTxsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, total, err := StakerTxsLookup(context.Background(), "tbnb1uhkhl8ctdqal2rnx3n9k4hrf4yfqcz4wzuqc43", "", testWindow, 50, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %d in total: %+v", total, got)
+}
+
+func TestStakerPoolPositionLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := StakerPoolPositionLookup(context.Background(), "BNB.MATIC-416", "tbnb1uhkhl8ctdqal2rnx3n9k4hrf4yfqcz4wzuqc43", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestImpermanentLoss(t *testing.T) {
+	if got := ImpermanentLoss(2, 2); got != 0 {
+		t.Errorf("same price got %f, want 0", got)
+	}
+	// price 4× gives 2 × 2 ÷ 5 − 1 = −0.2
+	if got := ImpermanentLoss(1, 4); math.Abs(got+0.2) > 1e-12 {
+		t.Errorf("price 4× got %f, want -0.2", got)
+	}
+	if got, want := ImpermanentLoss(4, 1), ImpermanentLoss(1, 4); math.Abs(got-want) > 1e-12 {
+		t.Errorf("price ¼× got %f, want %f like 4×", got, want)
+	}
+}
+
+func TestStakerFeesLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := StakerFeesLookup(context.Background(), "tbnb1uhkhl8ctdqal2rnx3n9k4hrf4yfqcz4wzuqc43", "BNB.MATIC-416", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestStakerCostBasis(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := StakerCostBasis(context.Background(), "tbnb1uhkhl8ctdqal2rnx3n9k4hrf4yfqcz4wzuqc43")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestStakerTxsLookupUnstake(t *testing.T) {
+	tx := sqltest.NewTx(t)
+	DBQuery = tx.QueryContext
+
+	const addr, pool = "thor1stakertxs", "BTC.TEST-TXS"
+	t0 := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	exec := func(q string, args ...interface{}) {
+		if _, err := tx.Exec(q, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// request with a donation of 1 RUNE
+	exec(`INSERT INTO unstake_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, pool, stake_units, basis_points, asymmetry, block_timestamp)
+VALUES ('UNSTAKETXS', 'THOR', $1, '', 'THOR.RUNE', 1, '', $2, 50, 5000, 0, $3)`, addr, pool, t0)
+	exec(`INSERT INTO outbound_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, in_tx, block_timestamp)
+VALUES ('OUTTXS1', 'BTC', '', 'bc1a', $1, 60, '', 'UNSTAKETXS', $2), ('OUTTXS2', 'THOR', '', $3, 'THOR.RUNE', 55, '', 'UNSTAKETXS', $2)`, pool, t0+1, addr)
+
+	got, total, err := StakerTxsLookup(context.Background(), addr, "unstake", Window{Since: time.Unix(0, t0), Until: time.Unix(0, t0+2)}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(got) != 1 {
+		t.Fatalf("got %d transactions of %d total, want 1", len(got), total)
+	}
+	if got[0].Type != "unstake" || got[0].Asset != pool || got[0].AssetE8 != 60 || got[0].RuneE8 != 55 {
+		t.Errorf("got %+v, want the outbounds of 60 %s and 55 RUNE", got[0], pool)
+	}
+}
+
+func TestStakerCostBasisProceeds(t *testing.T) {
+	tx := sqltest.NewTx(t)
+	DBQuery = tx.QueryContext
+
+	// price 1 throughout
+	const addr, pool = "thor1costbasis", "BTC.TEST-COST"
+	t0 := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	exec := func(q string, args ...interface{}) {
+		if _, err := tx.Exec(q, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exec(`INSERT INTO block_log (height, timestamp, hash) VALUES (9100001, $1, 'h1')`, t0)
+	exec(`INSERT INTO aggregate_states (height, pool, asset_e8, rune_e8) VALUES (9100001, $1, 1000, 1000)`, pool)
+	exec(`INSERT INTO stake_events (pool, asset_tx, asset_chain, asset_E8, stake_units, rune_tx, rune_addr, rune_E8, block_timestamp)
+VALUES ($1, '', 'BTC', 100, 100, '', $2, 100, $3)`, pool, addr, t0+1)
+	// request with a donation of 1 RUNE for half of the units
+	exec(`INSERT INTO unstake_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, pool, stake_units, basis_points, asymmetry, block_timestamp)
+VALUES ('UNSTAKE1', 'THOR', $1, '', 'THOR.RUNE', 1, '', $2, 50, 5000, 0, $3)`, addr, pool, t0+2)
+	exec(`INSERT INTO outbound_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, in_tx, block_timestamp)
+VALUES ('OUT1', 'BTC', '', 'bc1a', $1, 60, '', 'UNSTAKE1', $2), ('OUT2', 'THOR', '', $3, 'THOR.RUNE', 55, '', 'UNSTAKE1', $2)`, pool, t0+3, addr)
+
+	got, err := StakerCostBasis(context.Background(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PoolCostBasis{{
+		Pool:               pool,
+		Units:              50,
+		CostRuneE8:         100,
+		EntryPrice:         1,
+		UnstakedCostRuneE8: 100,
+		ProceedsRuneE8:     115,
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v", got)
+		t.Errorf("want %+v", want)
+	}
+}
+
+func TestCostBasisOf(t *testing.T) {
+	got := costBasisOf("BNB.BNB", []lpEvent{
+		// 100 asset and 100 RUNE at price 1
+		{Units: 10, AssetE8: 100, RuneE8: 100, AssetDepth: 1000, RuneDepth: 1000},
+		// 50 asset and 100 RUNE at price 2
+		{Units: 10, AssetE8: 50, RuneE8: 100, AssetDepth: 1000, RuneDepth: 2000},
+		// first lot and half of the second for 450 RUNE
+		{Units: -15, AssetE8: 0, RuneE8: 450, AssetDepth: 1000, RuneDepth: 3000},
+	})
+	want := PoolCostBasis{
+		Pool:               "BNB.BNB",
+		Units:              5,
+		CostRuneE8:         100,
+		EntryPrice:         2,
+		UnstakedCostRuneE8: 300,
+		ProceedsRuneE8:     450,
+	}
+	if got != want {
+		t.Errorf("got  %+v", got)
+		t.Errorf("want %+v", want)
+	}
+}
+
+func TestPoolLPPositionsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolLPPositionsLookup(context.Background(), "BNB.MATIC-416", 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestLPBehaviorMetrics(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := LPBehaviorMetrics(context.Background(), "BNB.MATIC-416")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestPoolTurnoverLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolTurnoverLookup(context.Background(), "BNB.MATIC-416", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestLPRetentionCohorts(t *testing.T) {
+	tx := sqltest.NewTx(t)
+	DBQuery = tx.QueryContext
+
+	const pool = "BTC.TEST-RETENTION"
+	const day = 24 * time.Hour
+	cohort := 30 * day
+	start := time.Unix(0, 0).Add(100 * cohort)
+	stakes := []struct {
+		addr   string
+		offset time.Duration
+	}{
+		{"thor1a", 0},
+		{"thor1b", day},
+		{"thor1c", 2 * day},
+		{"thor1d", 40 * day}, // next cohort
+	}
+	for _, s := range stakes {
+		_, err := tx.Exec(`INSERT INTO stake_events (pool, asset_tx, asset_chain, asset_E8, stake_units, rune_tx, rune_addr, rune_E8, block_timestamp)
+VALUES ($1, '', 'BTC', 1, 10, '', $2, 1, $3)`, pool, s.addr, start.Add(s.offset).UnixNano())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	unstakes := []struct {
+		addr   string
+		offset time.Duration
+	}{
+		{"thor1a", 10 * day},
+		{"thor1b", 60 * day},
+	}
+	for _, u := range unstakes {
+		_, err := tx.Exec(`INSERT INTO unstake_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, pool, stake_units, basis_points, asymmetry, block_timestamp)
+VALUES ('', 'THOR', $1, '', 'THOR.RUNE', 0, '', $2, 10, 10000, 0, $3)`, u.addr, pool, start.Add(u.offset).UnixNano())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := LPRetentionCohorts(context.Background(), pool, cohort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RetentionCohort{
+		{Start: start, InitialLPs: 3, StillActive: []int64{2, 1, 1}},
+		{Start: start.Add(cohort), InitialLPs: 1, StillActive: []int64{1, 1, 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v", got)
+		t.Errorf("want %+v", want)
+	}
+}
+
+func TestNodeOperatorLPOverlap(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := NodeOperatorLPOverlap(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
@@ -4,6 +4,7 @@ package stat
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -11,6 +12,18 @@ import (
 // DBQuery is the data source connection.
 var DBQuery func(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 
+// ErrNoTable denies a lookup on a table which does not exist (yet).
+var ErrNoTable = errors.New("table not available")
+
+// NoTableErr wraps undefined table errors from the database with ErrNoTable.
+func noTableErr(err error) error {
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) && state.SQLState() == "42P01" {
+		return fmt.Errorf("%w: %s", ErrNoTable, err)
+	}
+	return err
+}
+
 // Window specifies the applicable time period.
 type Window struct {
 	Since time.Time // lower bound [inclusive]
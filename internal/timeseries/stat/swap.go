@@ -5,6 +5,10 @@ import (
 	"time"
 )
 
+// OutboundTimeout is an upper boundary for the amount of time for a followup
+// on swap events. It matches the timeout of the timeseries package.
+const outboundTimeout = time.Hour
+
 // Swaps are generic swap statistics.
 type Swaps struct {
 	TxCount       int64
@@ -50,6 +54,28 @@ func querySwaps(ctx context.Context, q string, args ...interface{}) (*Swaps, err
 	return &swaps, rows.Err()
 }
 
+// SwapperCountLookup gets the number of distinct addresses which swapped in
+// any pool, in either direction.
+func SwapperCountLookup(ctx context.Context, w Window) (int64, error) {
+	const q = `SELECT COUNT(DISTINCT from_addr)
+FROM swap_events
+WHERE block_timestamp >= $1 AND block_timestamp < $2`
+
+	rows, err := DBQuery(ctx, q, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n int64
+	if rows.Next() {
+		if err := rows.Scan(&n); err != nil {
+			return 0, err
+		}
+	}
+	return n, rows.Err()
+}
+
 // PoolSwaps are swap statistics for a specific asset.
 type PoolSwaps struct {
 	TxCount             int64
@@ -134,3 +160,329 @@ func appendPoolSwaps(ctx context.Context, swaps []PoolSwaps, q string, args ...i
 	}
 	return swaps, rows.Err()
 }
+
+// SwapTx is a swap with its outbound (when seen).
+type SwapTx struct {
+	TxID           string
+	Pool           string
+	FromAddr       string
+	FromAsset      string
+	FromE8         int64
+	ToAsset        string // empty when unknown
+	ToE8           int64
+	LiqFeeInRuneE8 int64
+	TradeSlipBP    int64
+	Time           time.Time
+	Complete       bool // whether an outbound was seen
+}
+
+// SwapsByAddressLookup gets the swaps from or to addr, most recent first.
+func SwapsByAddressLookup(ctx context.Context, addr string, w Window, limit, offset int) ([]SwapTx, error) {
+	const q = `SELECT swap.tx, swap.pool, swap.from_addr, swap.from_asset, swap.from_E8, swap.liq_fee_in_rune_E8, swap.trade_slip_BP, swap.block_timestamp, COALESCE(out.asset, ''), COALESCE(out.asset_E8, 0), out.in_tx IS NOT NULL
+FROM swap_events swap
+LEFT JOIN outbound_events out ON
+	/* limit comparison set—no indinces */
+	swap.block_timestamp <= out.block_timestamp AND
+	swap.block_timestamp + $4 >= out.block_timestamp AND
+	swap.tx = out.in_tx AND
+	out.tx IS NOT NULL /* no intermediate of double-swap */
+WHERE (swap.from_addr = $1 OR swap.to_addr = $1) AND swap.block_timestamp >= $2 AND swap.block_timestamp < $3
+ORDER BY swap.block_timestamp DESC
+LIMIT $5 OFFSET $6`
+
+	return appendSwapTxs(ctx, nil, q, addr, w.Since.UnixNano(), w.Until.UnixNano(), outboundTimeout.Nanoseconds(), limit, offset)
+}
+
+// PoolRecentSwapsLookup gets the last swaps of pool, most recent first.
+func PoolRecentSwapsLookup(ctx context.Context, pool string, limit int) ([]SwapTx, error) {
+	const q = `SELECT swap.tx, swap.pool, swap.from_addr, swap.from_asset, swap.from_E8, swap.liq_fee_in_rune_E8, swap.trade_slip_BP, swap.block_timestamp, COALESCE(out.asset, ''), COALESCE(out.asset_E8, 0), out.in_tx IS NOT NULL
+FROM (
+	/* index on pool and block_timestamp */
+	SELECT * FROM swap_events
+	WHERE pool = $1
+	ORDER BY block_timestamp DESC
+	LIMIT $3
+) AS swap
+LEFT JOIN outbound_events out ON
+	/* limit comparison set—no indinces */
+	swap.block_timestamp <= out.block_timestamp AND
+	swap.block_timestamp + $2 >= out.block_timestamp AND
+	swap.tx = out.in_tx AND
+	out.tx IS NOT NULL /* no intermediate of double-swap */
+ORDER BY swap.block_timestamp DESC`
+
+	return appendSwapTxs(ctx, make([]SwapTx, 0, limit), q, pool, outboundTimeout.Nanoseconds(), limit)
+}
+
+func appendSwapTxs(ctx context.Context, a []SwapTx, q string, args ...interface{}) ([]SwapTx, error) {
+	rows, err := DBQuery(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r SwapTx
+		var timestamp int64
+		err := rows.Scan(&r.TxID, &r.Pool, &r.FromAddr, &r.FromAsset, &r.FromE8, &r.LiqFeeInRuneE8, &r.TradeSlipBP, &timestamp, &r.ToAsset, &r.ToE8, &r.Complete)
+		if err != nil {
+			return a, err
+		}
+		r.Time = time.Unix(0, timestamp)
+		if r.ToAsset == "" && r.FromAsset != r.Pool {
+			// from RUNE
+			r.ToAsset = r.Pool
+		}
+		a = append(a, r)
+	}
+	return a, rows.Err()
+}
+
+// SwapPair are statistics for swaps from one asset to another. Double swaps
+// (from asset to RUNE to asset) count once, with the sum of both legs.
+type SwapPair struct {
+	FromAsset           string
+	ToAsset             string
+	TxCount             int64
+	RuneE8Total         int64 // volume
+	LiqFeeInRuneE8Total int64
+	TradeSlipBPTotal    int64
+}
+
+// SwapAggregateByPair gets the swap statistics per asset pair, most active
+// first. Swaps to RUNE get runeAsset as the to asset.
+func SwapAggregateByPair(ctx context.Context, runeAsset string, w Window) ([]SwapPair, error) {
+	// The RUNE of a swap from asset is the RUNE outbound of the swap, or the
+	// input of the second leg on double swaps. Pending outbounds count zero.
+	const q = `SELECT from_asset, to_asset, COUNT(*), SUM(rune_E8), SUM(liq_fee_in_rune_E8), SUM(trade_slip_BP)
+FROM (
+	SELECT
+		COALESCE(MAX(CASE WHEN from_asset = pool THEN pool END), MAX(from_asset)) AS from_asset,
+		COALESCE(MAX(CASE WHEN from_asset <> pool THEN pool END), $3) AS to_asset,
+		COALESCE(SUM(from_E8) FILTER (WHERE from_asset <> pool), 0) + CASE
+			WHEN NOT bool_or(from_asset = pool) THEN 0
+			WHEN bool_or(from_asset <> pool) THEN SUM(from_E8) FILTER (WHERE from_asset <> pool)
+			ELSE (SELECT COALESCE(SUM(o.asset_E8), 0) FROM outbound_events o WHERE o.in_tx = swap.tx AND o.asset IN ('THOR.RUNE', 'BNB.RUNE-67C', 'BNB.RUNE-B1A'))
+		END AS rune_E8,
+		SUM(liq_fee_in_rune_E8) AS liq_fee_in_rune_E8,
+		SUM(trade_slip_BP) AS trade_slip_BP
+	FROM swap_events AS swap
+	WHERE block_timestamp >= $1 AND block_timestamp < $2
+	GROUP BY swap.tx
+) AS swaps
+GROUP BY from_asset, to_asset
+ORDER BY COUNT(*) DESC, from_asset, to_asset`
+
+	rows, err := DBQuery(ctx, q, w.Since.UnixNano(), w.Until.UnixNano(), runeAsset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []SwapPair
+	for rows.Next() {
+		var p SwapPair
+		if err := rows.Scan(&p.FromAsset, &p.ToAsset, &p.TxCount, &p.RuneE8Total, &p.LiqFeeInRuneE8Total, &p.TradeSlipBPTotal); err != nil {
+			return a, err
+		}
+		a = append(a, p)
+	}
+	return a, rows.Err()
+}
+
+// SlipBucket is a trade slip range, with the minimum inclusive and the maximum
+// exclusive, in basis points.
+type SlipBucket struct {
+	MinBP int64
+	MaxBP int64
+	Count int64
+}
+
+// SlipBucketBounds are the boundaries of the SlippageHistogram buckets.
+var slipBucketBounds = []int64{0, 10, 50, 100, 200, 500, 1000, 2000, 5000, 9000, 10000}
+
+// SlippageHistogram gets the number of swaps per trade slip range. The last
+// bucket includes the maximum. An empty pool selects all pools.
+func SlippageHistogram(ctx context.Context, pool string, w Window) ([]SlipBucket, error) {
+	const q = `SELECT CASE
+	WHEN trade_slip_BP < 10 THEN 0
+	WHEN trade_slip_BP < 50 THEN 1
+	WHEN trade_slip_BP < 100 THEN 2
+	WHEN trade_slip_BP < 200 THEN 3
+	WHEN trade_slip_BP < 500 THEN 4
+	WHEN trade_slip_BP < 1000 THEN 5
+	WHEN trade_slip_BP < 2000 THEN 6
+	WHEN trade_slip_BP < 5000 THEN 7
+	WHEN trade_slip_BP < 9000 THEN 8
+	ELSE 9 END AS bucket, COUNT(*)
+FROM swap_events
+WHERE ($1 = '' OR pool = $1) AND block_timestamp >= $2 AND block_timestamp < $3
+GROUP BY bucket`
+
+	rows, err := DBQuery(ctx, q, pool, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]SlipBucket, len(slipBucketBounds)-1)
+	for i := range a {
+		a[i].MinBP = slipBucketBounds[i]
+		a[i].MaxBP = slipBucketBounds[i+1]
+	}
+	for rows.Next() {
+		var i int
+		var n int64
+		if err := rows.Scan(&i, &n); err != nil {
+			return nil, err
+		}
+		a[i].Count = n
+	}
+	return a, rows.Err()
+}
+
+// SwapSizeBucket is a swap value range, with the minimum inclusive and the
+// maximum exclusive, in RUNE E8. The last bucket has no maximum, i.e., zero.
+type SwapSizeBucket struct {
+	MinRuneE8    int64
+	MaxRuneE8    int64
+	Count        int64
+	VolumeRuneE8 int64
+}
+
+// SwapSizeBucketBounds are the boundaries of the SwapSizeDistribution buckets,
+// one per power of ten RUNE from 1 up to 100 M.
+var swapSizeBucketBounds = []int64{0, 1e8, 1e9, 1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16}
+
+// SwapSizeDistribution gets the number of swaps on pool per value range. Swaps
+// from RUNE count their input. Swaps from asset count their RUNE output, which
+// is the outbound, or the input of the second leg on double swaps. Swaps which
+// did not complete yet are not included.
+func SwapSizeDistribution(ctx context.Context, pool string, w Window) ([]SwapSizeBucket, error) {
+	const q = `SELECT WIDTH_BUCKET(LOG(v.rune_E8 / 1e8), 0, 8, 8) AS bucket, COUNT(*), SUM(v.rune_E8)::BIGINT
+FROM (
+	SELECT CASE
+		WHEN e.from_asset <> e.pool THEN e.from_E8::NUMERIC
+		ELSE COALESCE(
+			(SELECT SUM(l.from_E8) FROM swap_events l
+			WHERE l.block_timestamp = e.block_timestamp AND l.tx = e.tx AND l.from_asset <> l.pool),
+			(SELECT SUM(o.asset_E8) FROM outbound_events o
+			WHERE o.block_timestamp >= e.block_timestamp AND o.block_timestamp <= e.block_timestamp + $4
+				AND o.in_tx = e.tx AND o.asset IN ('THOR.RUNE', 'BNB.RUNE-67C', 'BNB.RUNE-B1A')),
+			0) END AS rune_E8
+	FROM swap_events e
+	WHERE e.pool = $1 AND e.block_timestamp >= $2 AND e.block_timestamp < $3
+) AS v
+WHERE v.rune_E8 > 0
+GROUP BY bucket`
+
+	rows, err := DBQuery(ctx, q, pool, w.Since.UnixNano(), w.Until.UnixNano(), outboundTimeout.Nanoseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]SwapSizeBucket, len(swapSizeBucketBounds))
+	for i := range a {
+		a[i].MinRuneE8 = swapSizeBucketBounds[i]
+		if i+1 < len(swapSizeBucketBounds) {
+			a[i].MaxRuneE8 = swapSizeBucketBounds[i+1]
+		}
+	}
+	for rows.Next() {
+		var i int
+		var n, volume int64
+		if err := rows.Scan(&i, &n, &volume); err != nil {
+			return nil, err
+		}
+		a[i].Count = n
+		a[i].VolumeRuneE8 = volume
+	}
+	return a, rows.Err()
+}
+
+// PoolLastSwapHeight gets the block height of the latest swap on pool, with
+// zero for none.
+func PoolLastSwapHeight(ctx context.Context, pool string) (int64, error) {
+	const q = `SELECT b.height
+FROM swap_events s JOIN block_log b ON b.timestamp = s.block_timestamp
+WHERE s.pool = $1
+ORDER BY s.block_timestamp DESC
+LIMIT 1`
+
+	rows, err := DBQuery(ctx, q, pool)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var height int64
+	if rows.Next() {
+		if err := rows.Scan(&height); err != nil {
+			return 0, err
+		}
+	}
+	return height, rows.Err()
+}
+
+// VWAPBucket has the executed swaps of a pool within a time bucket.
+type VWAPBucket struct {
+	Time       time.Time // bucket start
+	RuneE8     int64
+	AssetE8    int64
+	TradeCount int64
+}
+
+// PoolVWAP gets the executed amounts of pool per bucket. The volume weighted
+// average price, in RUNE per asset, is RuneE8 ÷ AssetE8, as each swap has its
+// price times its asset amount as RUNE. Swaps which did not complete yet, and
+// double swaps, have no executed price in the pool, and they are not included.
+func PoolVWAP(ctx context.Context, pool string, w Window, interval time.Duration) ([]VWAPBucket, error) {
+	n, err := bucketsFor(interval, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(interval) * int64(interval)
+
+	const q = `SELECT time_bucket($4, swap.block_timestamp) AS bucket,
+	SUM(CASE WHEN swap.from_asset = swap.pool THEN out.asset_E8 ELSE swap.from_E8 END)::BIGINT,
+	SUM(CASE WHEN swap.from_asset = swap.pool THEN swap.from_E8 ELSE out.asset_E8 END)::BIGINT,
+	COUNT(*)
+FROM swap_events swap
+JOIN outbound_events out ON
+	/* limit comparison set—no indinces */
+	swap.block_timestamp <= out.block_timestamp AND
+	swap.block_timestamp + $5 >= out.block_timestamp AND
+	swap.tx = out.in_tx AND
+	out.tx IS NOT NULL /* no intermediate of double-swap */
+WHERE swap.pool = $1 AND swap.block_timestamp >= $2 AND swap.block_timestamp < $3
+	AND ((swap.from_asset = swap.pool AND out.asset IN ('THOR.RUNE', 'BNB.RUNE-67C', 'BNB.RUNE-B1A'))
+	OR (swap.from_asset <> swap.pool AND out.asset = swap.pool))
+GROUP BY bucket
+ORDER BY bucket`
+
+	rows, err := DBQuery(ctx, q, pool, first, first+n*int64(interval), int64(interval), outboundTimeout.Nanoseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]VWAPBucket, n)
+	for i := range a {
+		a[i].Time = time.Unix(0, first+int64(i)*int64(interval))
+	}
+	for rows.Next() {
+		var bucket int64
+		var b VWAPBucket
+		if err := rows.Scan(&bucket, &b.RuneE8, &b.AssetE8, &b.TradeCount); err != nil {
+			return nil, err
+		}
+		i := (bucket - first) / int64(interval)
+		if i < 0 || i >= n {
+			continue
+		}
+		b.Time = a[i].Time
+		a[i] = b
+	}
+	return a, rows.Err()
+}
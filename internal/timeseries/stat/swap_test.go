@@ -2,6 +2,7 @@ package stat
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -26,6 +27,33 @@ func TestSwapsToRuneLookup(t *testing.T) {
 	t.Logf("got %+v", got)
 }
 
+func TestSwapperCountLookup(t *testing.T) {
+	tx := sqltest.NewTx(t)
+	DBQuery = tx.QueryContext
+
+	before, err := SwapperCountLookup(context.Background(), testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// same address swaps to and from RUNE
+	for i, fromAsset := range []string{"THOR.RUNE", "BTC.TEST-SWAPPERS"} {
+		_, err := tx.Exec(`INSERT INTO swap_events (tx, chain, from_addr, to_addr, from_asset, from_E8, memo, pool, to_E8_min, trade_slip_BP, liq_fee_E8, liq_fee_in_rune_E8, block_timestamp)
+VALUES ($1, 'THOR', 'thor1swapper', 'thor1swapper', $2, 1e8, '', 'BTC.TEST-SWAPPERS', 0, 0, 0, 0, $3)`, fmt.Sprintf("SWAPPER%d", i), fromAsset, testWindow.Since.UnixNano()+int64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := SwapperCountLookup(context.Background(), testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != before+1 {
+		t.Errorf("got %d swappers, want %d", got, before+1)
+	}
+}
+
 func TestPoolSwapsFromRuneLookup(t *testing.T) {
 	DBQuery = sqltest.NewTx(t).QueryContext
 	got, err := PoolSwapsFromRuneLookup(context.Background(), "BNB.MATIC-416", testWindow)
@@ -61,3 +89,155 @@ func TestPoolSwapsToRuneBucketsLookup(t *testing.T) {
 	}
 	t.Logf("got %d buckets", len(got))
 }
+
+func TestSwapsByAddressLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := SwapsByAddressLookup(context.Background(), "tbnb1uhkhl8ctdqal2rnx3n9k4hrf4yfqcz4wzuqc43", testWindow, 50, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestPoolRecentSwapsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolRecentSwapsLookup(context.Background(), "BNB.BNB", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestSwapAggregateByPair(t *testing.T) {
+	tx := sqltest.NewTx(t)
+	DBQuery = tx.QueryContext
+	exec := func(q string, args ...interface{}) {
+		t.Helper()
+		if _, err := tx.Exec(q, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const btc, eth = "BTC.TEST-PAIR", "ETH.TEST-PAIR"
+	t0 := testWindow.Since.UnixNano()
+	// from asset to RUNE, from RUNE to asset, and a double swap
+	exec(`INSERT INTO swap_events (tx, chain, from_addr, to_addr, from_asset, from_E8, memo, pool, to_E8_min, trade_slip_BP, liq_fee_E8, liq_fee_in_rune_E8, block_timestamp)
+VALUES ('PAIRSELL', 'BTC', 'bc1a', 'thor1a', $1, 1e8, '', $1, 0, 100, 0, 2e6, $3),
+	('PAIRBUY', 'THOR', 'thor1a', 'bc1a', 'THOR.RUNE', 30e8, '', $1, 0, 50, 0, 1e6, $3),
+	('PAIRDOUBLE', 'BTC', 'bc1a', '0xa', $1, 2e8, '', $1, 0, 200, 0, 4e6, $3),
+	('PAIRDOUBLE', 'BTC', 'bc1a', '0xa', 'THOR.RUNE', 50e8, '', $2, 0, 300, 0, 3e6, $3)`, btc, eth, t0)
+	exec(`INSERT INTO outbound_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, in_tx, block_timestamp)
+VALUES ('OUTPAIRSELL', 'THOR', '', 'thor1a', 'THOR.RUNE', 40e8, '', 'PAIRSELL', $2),
+	('OUTPAIRDOUBLE', 'ETH', '', '0xa', $1, 9e8, '', 'PAIRDOUBLE', $2)`, eth, t0+1)
+
+	got, err := SwapAggregateByPair(context.Background(), "BNB.RUNE-67C", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[[2]string]SwapPair{
+		{btc, "BNB.RUNE-67C"}: {btc, "BNB.RUNE-67C", 1, 40e8, 2e6, 100},
+		{"THOR.RUNE", btc}:    {"THOR.RUNE", btc, 1, 30e8, 1e6, 50},
+		{btc, eth}:            {btc, eth, 1, 100e8, 7e6, 500},
+	}
+	for _, p := range got {
+		w, ok := want[[2]string{p.FromAsset, p.ToAsset}]
+		if !ok {
+			continue
+		}
+		if p != w {
+			t.Errorf("got %+v, want %+v", p, w)
+		}
+		delete(want, [2]string{p.FromAsset, p.ToAsset})
+	}
+	for _, w := range want {
+		t.Errorf("pair %s to %s missing", w.FromAsset, w.ToAsset)
+	}
+}
+
+func TestSlippageHistogram(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := SlippageHistogram(context.Background(), "", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 10 {
+		t.Errorf("got %d buckets, want 10", len(got))
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestSwapSizeDistribution(t *testing.T) {
+	tx := sqltest.NewTx(t)
+	DBQuery = tx.QueryContext
+
+	// many small swaps and a single whale
+	const pool = "BTC.TEST-SIZE"
+	t0 := testWindow.Since.UnixNano()
+	for i, runeE8 := range []int64{10e8, 20e8, 30e8, 40e8, 50e8, 5000e8, 2e6 * 1e8} {
+		_, err := tx.Exec(`INSERT INTO swap_events (tx, chain, from_addr, to_addr, from_asset, from_E8, memo, pool, to_E8_min, trade_slip_BP, liq_fee_E8, liq_fee_in_rune_E8, block_timestamp)
+VALUES ($1, 'THOR', 'thor1a', 'bc1a', 'THOR.RUNE', $2, '', $3, 0, 0, 0, 0, $4)`, fmt.Sprintf("TX%d", i), runeE8, pool, t0+int64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// from asset, valued at the RUNE outbound, and one pending
+	_, err := tx.Exec(`INSERT INTO swap_events (tx, chain, from_addr, to_addr, from_asset, from_E8, memo, pool, to_E8_min, trade_slip_BP, liq_fee_E8, liq_fee_in_rune_E8, block_timestamp)
+VALUES ('TXSELL', 'BTC', 'bc1a', 'thor1a', $1, 1e6, '', $1, 0, 0, 0, 0, $2),
+	('TXPENDING', 'BTC', 'bc1a', 'thor1a', $1, 1e8, '', $1, 0, 0, 0, 0, $2)`, pool, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tx.Exec(`INSERT INTO outbound_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, in_tx, block_timestamp)
+VALUES ('OUTSELL', 'THOR', '', 'thor1a', 'THOR.RUNE', 7e8, '', 'TXSELL', $1)`, t0+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := SwapSizeDistribution(context.Background(), pool, testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 10 {
+		t.Fatalf("got %d buckets, want 10", len(got))
+	}
+	if got[1].Count != 1 || got[1].VolumeRuneE8 != 7e8 {
+		t.Errorf("got second bucket %+v, want 1 swap of 7 RUNE", got[1])
+	}
+	if got[2].Count != 5 || got[2].VolumeRuneE8 != 150e8 {
+		t.Errorf("got third bucket %+v, want 5 swaps of 150 RUNE", got[2])
+	}
+	if got[4].Count != 1 || got[7].Count != 1 || got[7].VolumeRuneE8 != 2e6*1e8 || got[9].MaxRuneE8 != 0 {
+		t.Errorf("got buckets %+v, want 1 swap of 5 k and 1 of 2 M RUNE", got)
+	}
+	// small swaps dominate the count, large swaps the volume
+	for i := range got {
+		if got[i].Count > got[2].Count {
+			t.Errorf("bucket %d has more swaps than the small ones", i)
+		}
+		if got[i].VolumeRuneE8 > got[7].VolumeRuneE8 {
+			t.Errorf("bucket %d has more volume than the whale", i)
+		}
+	}
+}
+
+func TestPoolLastSwapHeight(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolLastSwapHeight(context.Background(), "BNB.MATIC-416")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %d", got)
+}
+
+func TestPoolVWAP(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	w := Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()}
+	got, err := PoolVWAP(context.Background(), "BNB.BNB", w, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Errorf("got %d buckets, want 31", len(got))
+	}
+	t.Logf("got %+v", got)
+}
@@ -0,0 +1,90 @@
+package stat
+
+import (
+	"context"
+	"time"
+)
+
+// SyntheticSupplyLookup gets the amount of synthetic asset in existence, which
+// is the mints minus the burns.
+func SyntheticSupplyLookup(ctx context.Context, asset string) (int64, error) {
+	const q = `SELECT
+	(SELECT COALESCE(SUM(E8), 0) FROM synthetic_mint_events WHERE asset = $1)
+	- (SELECT COALESCE(SUM(E8), 0) FROM synthetic_burn_events WHERE asset = $1)`
+
+	rows, err := DBQuery(ctx, q, asset)
+	if err != nil {
+		return 0, noTableErr(err)
+	}
+	defer rows.Close()
+
+	var n int64
+	if rows.Next() {
+		if err := rows.Scan(&n); err != nil {
+			return 0, err
+		}
+	}
+	return n, rows.Err()
+}
+
+// SynthEvent is a mint or a burn of a synthetic asset. The events come without
+// the address involved.
+type SynthEvent struct {
+	Type   string // "mint" or "burn"
+	E8     int64
+	Reason string
+	Time   time.Time
+}
+
+// SynthEventsLookup gets the mints and burns of asset, most recent first.
+func SynthEventsLookup(ctx context.Context, asset string, w Window, limit, offset int) ([]SynthEvent, error) {
+	const q = `SELECT type, E8, reason, block_timestamp FROM (
+	SELECT 'mint' AS type, E8, reason, block_timestamp
+	FROM synthetic_mint_events
+	WHERE asset = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+	UNION ALL
+	SELECT 'burn', E8, reason, block_timestamp
+	FROM synthetic_burn_events
+	WHERE asset = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+) e
+ORDER BY block_timestamp DESC
+LIMIT $4 OFFSET $5`
+
+	rows, err := DBQuery(ctx, q, asset, w.Since.UnixNano(), w.Until.UnixNano(), limit, offset)
+	if err != nil {
+		return nil, noTableErr(err)
+	}
+	defer rows.Close()
+
+	var a []SynthEvent
+	for rows.Next() {
+		var e SynthEvent
+		var timestamp int64
+		if err := rows.Scan(&e.Type, &e.E8, &e.Reason, &timestamp); err != nil {
+			return a, err
+		}
+		e.Time = time.Unix(0, timestamp)
+		a = append(a, e)
+	}
+	return a, rows.Err()
+}
+
+// SynthVolumesLookup gets the amounts of asset minted and burned.
+func SynthVolumesLookup(ctx context.Context, asset string, w Window) (mintE8, burnE8 int64, err error) {
+	const q = `SELECT
+	(SELECT COALESCE(SUM(E8), 0) FROM synthetic_mint_events WHERE asset = $1 AND block_timestamp >= $2 AND block_timestamp < $3),
+	(SELECT COALESCE(SUM(E8), 0) FROM synthetic_burn_events WHERE asset = $1 AND block_timestamp >= $2 AND block_timestamp < $3)`
+
+	rows, err := DBQuery(ctx, q, asset, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return 0, 0, noTableErr(err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&mintE8, &burnE8); err != nil {
+			return 0, 0, err
+		}
+	}
+	return mintE8, burnE8, rows.Err()
+}
@@ -0,0 +1,35 @@
+package stat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pascaldekloe/sqltest"
+)
+
+func TestSyntheticSupplyLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := SyntheticSupplyLookup(context.Background(), "BTC/BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %d", got)
+}
+
+func TestSynthEventsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := SynthEventsLookup(context.Background(), "BTC/BTC", testWindow, 50, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestSynthVolumesLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	mintE8, burnE8, err := SynthVolumesLookup(context.Background(), "BTC/BTC", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %d minted and %d burned", mintE8, burnE8)
+}
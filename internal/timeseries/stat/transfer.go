@@ -1,6 +1,10 @@
 package stat
 
-import "context"
+import (
+	"context"
+	"strings"
+	"time"
+)
 
 type PoolAdds struct {
 	AssetE8Total int64
@@ -106,6 +110,56 @@ WHERE asset = $1 AND block_timestamp >= $2 AND block_timestamp < $3`
 	return &r, rows.Err()
 }
 
+// PoolGasBucket is the gas reimbursed to a pool within a time bucket.
+type PoolGasBucket struct {
+	Time    time.Time // bucket start
+	AssetE8 int64     // gas spent
+	RuneE8  int64     // reimbursement to the pool
+	TxCount int64
+}
+
+// PoolGasBucketsLookup gets the gas events of pool per bucket. Only the gas
+// asset pools have gas events, as THORNode reimburses the gas in RUNE to the
+// pool of the gas asset which paid for the outbound transactions.
+func PoolGasBucketsLookup(ctx context.Context, pool string, bucketSize time.Duration, w Window) ([]PoolGasBucket, error) {
+	n, err := bucketsFor(bucketSize, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(bucketSize) * int64(bucketSize)
+
+	const q = `SELECT time_bucket($4, block_timestamp) AS bucket, SUM(asset_E8)::BIGINT, SUM(rune_E8)::BIGINT, SUM(tx_count)::BIGINT
+FROM gas_events
+WHERE asset = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+GROUP BY bucket
+ORDER BY bucket`
+
+	rows, err := DBQuery(ctx, q, pool, first, first+n*int64(bucketSize), int64(bucketSize))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]PoolGasBucket, n)
+	for i := range a {
+		a[i].Time = time.Unix(0, first+int64(i)*int64(bucketSize))
+	}
+	for rows.Next() {
+		var bucket int64
+		var b PoolGasBucket
+		if err := rows.Scan(&bucket, &b.AssetE8, &b.RuneE8, &b.TxCount); err != nil {
+			return nil, err
+		}
+		i := (bucket - first) / int64(bucketSize)
+		if i < 0 || i >= n {
+			continue
+		}
+		b.Time = a[i].Time
+		a[i] = b
+	}
+	return a, rows.Err()
+}
+
 type PoolSlashes struct {
 	AssetE8Total int64
 }
@@ -130,3 +184,169 @@ WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3`
 	}
 	return &r, rows.Err()
 }
+
+// SlashBucket are the slashes within a time bucket.
+type SlashBucket struct {
+	Time         time.Time // bucket start
+	SlashCount   int64
+	RuneE8       int64
+	CountPerPool map[string]int64
+}
+
+// NetworkSlashHistory gets the slashes of all pools per bucket. A slash event
+// has the amounts of a pool in one block. The events name neither the nodes
+// nor a reason.
+func NetworkSlashHistory(ctx context.Context, w Window, interval time.Duration) ([]SlashBucket, error) {
+	n, err := bucketsFor(interval, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(interval) * int64(interval)
+
+	const q = `SELECT time_bucket($3, block_timestamp) AS bucket, pool, COUNT(DISTINCT block_timestamp),
+	COALESCE(SUM(CASE WHEN asset IN ('THOR.RUNE', 'BNB.RUNE-67C', 'BNB.RUNE-B1A') THEN asset_E8 ELSE 0 END), 0)::BIGINT
+FROM slash_amounts
+WHERE block_timestamp >= $1 AND block_timestamp < $2
+GROUP BY bucket, pool
+ORDER BY bucket`
+
+	rows, err := DBQuery(ctx, q, first, first+n*int64(interval), int64(interval))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]SlashBucket, n)
+	for i := range a {
+		a[i].Time = time.Unix(0, first+int64(i)*int64(interval))
+		a[i].CountPerPool = make(map[string]int64)
+	}
+	for rows.Next() {
+		var bucket, count, runeE8 int64
+		var pool string
+		if err := rows.Scan(&bucket, &pool, &count, &runeE8); err != nil {
+			return nil, err
+		}
+		i := (bucket - first) / int64(interval)
+		if i < 0 || i >= n {
+			continue
+		}
+		a[i].SlashCount += count
+		a[i].RuneE8 += runeE8
+		a[i].CountPerPool[pool] = count
+	}
+	return a, rows.Err()
+}
+
+// PoolFeeBucket is the fee collection of a pool within a time bucket.
+//
+// THORChain charges two kinds of fees on a swap. The liquidity fee follows
+// from the slip, as x² × Y ÷ (x + X)² with input x, input depth X and output
+// depth Y. It stays in the pool, i.e., it goes to the liquidity providers,
+// and it is denominated in the output asset. The system fee is a fixed charge
+// on the outbound transaction. It goes to the reserve, with pool deduct as the
+// RUNE equivalent taken from the pool.
+type PoolFeeBucket struct {
+	Time          time.Time // bucket start
+	LiqFeeRuneE8  int64     // from swaps to RUNE
+	LiqFeeAssetE8 int64     // from swaps from RUNE
+	SysFeeRuneE8  int64     // pool deduct
+	SysFeeAssetE8 int64     // outbound fees in the pool asset
+}
+
+// PoolFeeBreakdown gets the fees of pool per time bucket. System fees on RUNE
+// outbounds have no pool, and they are thus not included.
+func PoolFeeBreakdown(ctx context.Context, pool string, w Window, interval time.Duration) ([]PoolFeeBucket, error) {
+	n, err := bucketsFor(interval, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(interval) * int64(interval)
+
+	const q = `SELECT b.t, COALESCE(s.liq_rune, 0), COALESCE(s.liq_asset, 0), COALESCE(f.sys_rune, 0), COALESCE(f.sys_asset, 0)
+FROM generate_series($2::BIGINT, $3::BIGINT - $4::BIGINT, $4::BIGINT) AS b(t)
+LEFT JOIN (
+	SELECT time_bucket($4, block_timestamp) AS t,
+		SUM(CASE WHEN from_asset = $1 THEN liq_fee_E8 ELSE 0 END)::BIGINT AS liq_rune,
+		SUM(CASE WHEN from_asset <> $1 THEN liq_fee_E8 ELSE 0 END)::BIGINT AS liq_asset
+	FROM swap_events
+	WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+	GROUP BY 1
+) s ON s.t = b.t
+LEFT JOIN (
+	SELECT time_bucket($4, block_timestamp) AS t,
+		SUM(pool_deduct)::BIGINT AS sys_rune,
+		SUM(asset_E8)::BIGINT AS sys_asset
+	FROM fee_events
+	WHERE asset = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+	GROUP BY 1
+) f ON f.t = b.t
+ORDER BY b.t`
+
+	rows, err := DBQuery(ctx, q, pool, first, first+n*int64(interval), int64(interval))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]PoolFeeBucket, 0, n)
+	for rows.Next() {
+		var b PoolFeeBucket
+		var ns int64
+		if err := rows.Scan(&ns, &b.LiqFeeRuneE8, &b.LiqFeeAssetE8, &b.SysFeeRuneE8, &b.SysFeeAssetE8); err != nil {
+			return nil, err
+		}
+		b.Time = time.Unix(0, ns)
+		a = append(a, b)
+	}
+	return a, rows.Err()
+}
+
+// GasSpending is the outbound gas of a chain which the network paid within a
+// time bucket.
+type GasSpending struct {
+	Time     time.Time // bucket start
+	Chain    string
+	GasAsset string
+	AssetE8  int64 // gas spent
+	RuneE8   int64 // reimbursement to the gas pool
+	TxCount  int64
+}
+
+// GasSpendingLookup gets the gas spending per time bucket and per gas asset,
+// with buckets in chronological order. The gas comes from the gas events,
+// which account the reimbursements to the pools out of the reserve. Fee events
+// instead hold what the users paid. Buckets without spending are omitted.
+func GasSpendingLookup(ctx context.Context, w Window, interval time.Duration) ([]GasSpending, error) {
+	if _, err := bucketsFor(interval, w); err != nil {
+		return nil, err
+	}
+
+	const q = `SELECT time_bucket($3, block_timestamp), asset, SUM(asset_E8)::BIGINT, SUM(rune_E8)::BIGINT, SUM(tx_count)::BIGINT
+FROM gas_events
+WHERE block_timestamp >= $1 AND block_timestamp < $2
+GROUP BY 1, asset
+ORDER BY 1, asset`
+
+	rows, err := DBQuery(ctx, q, w.Since.UnixNano(), w.Until.UnixNano(), int64(interval))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var a []GasSpending
+	for rows.Next() {
+		var g GasSpending
+		var ns int64
+		if err := rows.Scan(&ns, &g.GasAsset, &g.AssetE8, &g.RuneE8, &g.TxCount); err != nil {
+			return a, err
+		}
+		g.Time = time.Unix(0, ns)
+		g.Chain = g.GasAsset
+		if i := strings.IndexByte(g.GasAsset, '.'); i >= 0 {
+			g.Chain = g.GasAsset[:i]
+		}
+		a = append(a, g)
+	}
+	return a, rows.Err()
+}
@@ -3,6 +3,7 @@ package stat
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/pascaldekloe/sqltest"
 )
@@ -34,6 +35,19 @@ func TestPoolFeesLookup(t *testing.T) {
 	t.Logf("got %+v", got)
 }
 
+func TestPoolFeeBreakdown(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	w := Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()}
+	got, err := PoolFeeBreakdown(context.Background(), "BNB.BNB", w, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Errorf("got %d buckets, want 31", len(got))
+	}
+	t.Logf("got %+v", got)
+}
+
 func TestPoolGasLookup(t *testing.T) {
 	DBQuery = sqltest.NewTx(t).QueryContext
 	got, err := PoolGasLookup(context.Background(), "BNB.MATIC-416", Window{})
@@ -43,6 +57,18 @@ func TestPoolGasLookup(t *testing.T) {
 	t.Logf("got %+v", got)
 }
 
+func TestPoolGasBucketsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolGasBucketsLookup(context.Background(), "BNB.BNB", 24*time.Hour, Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Errorf("got %d buckets, want 31", len(got))
+	}
+	t.Logf("got %+v", got)
+}
+
 func TestPoolSlashesLookup(t *testing.T) {
 	DBQuery = sqltest.NewTx(t).QueryContext
 	got, err := PoolSlashesLookup(context.Background(), "BNB.MATIC-416", Window{})
@@ -51,3 +77,48 @@ func TestPoolSlashesLookup(t *testing.T) {
 	}
 	t.Logf("got %+v", got)
 }
+
+func TestNetworkSlashHistory(t *testing.T) {
+	tx := sqltest.NewTx(t)
+	DBQuery = tx.QueryContext
+
+	const btc, eth = "BTC.TEST-SLASH", "ETH.TEST-SLASH"
+	t0 := testWindow.Since.UnixNano()
+	day := int64(24 * time.Hour)
+	// a slash event is the amounts of a pool in one block
+	_, err := tx.Exec(`INSERT INTO slash_amounts (pool, asset, asset_E8, block_timestamp)
+VALUES ($1, 'THOR.RUNE', 5e8, $3), ($1, $1, 1e8, $3),
+	($2, 'THOR.RUNE', 2e8, $4),
+	($1, 'THOR.RUNE', 1e8, $5),
+	($1, 'BNB.RUNE-67C', 4e8, $6)`, btc, eth, t0, t0+1, t0+2, t0+2*day)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NetworkSlashHistory(context.Background(), Window{Since: testWindow.Since, Until: time.Unix(0, t0+3*day)}, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d buckets, want 3", len(got))
+	}
+	if got[0].SlashCount != 3 || got[0].RuneE8 != 8e8 || got[0].CountPerPool[btc] != 2 || got[0].CountPerPool[eth] != 1 {
+		t.Errorf("got first bucket %+v, want 2 slashes on %s and 1 on %s, with 8 RUNE", got[0], btc, eth)
+	}
+	if got[1].SlashCount != 0 || got[1].RuneE8 != 0 || len(got[1].CountPerPool) != 0 {
+		t.Errorf("got second bucket %+v, want none", got[1])
+	}
+	if got[2].SlashCount != 1 || got[2].RuneE8 != 4e8 || got[2].CountPerPool[btc] != 1 {
+		t.Errorf("got third bucket %+v, want 1 slash on %s of 4 RUNE", got[2], btc)
+	}
+}
+
+func TestGasSpendingLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	w := Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()}
+	got, err := GasSpendingLookup(context.Background(), w, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
@@ -3,6 +3,7 @@ package stat
 import (
 	"context"
 	"log"
+	"time"
 
 	"gitlab.com/thorchain/midgard/event"
 )
@@ -14,6 +15,17 @@ type Unstakes struct {
 	RuneE8Total   int64
 }
 
+// UnstakeOutboundsJoin gets the amounts paid out for each unstake u, as
+// paid.asset_E8 and paid.rune_E8. Unstake events hold the “donation” of the
+// request only. The withdrawal follows with outbounds which refer to the
+// unstake transaction.
+const unstakeOutboundsJoin = `LEFT JOIN LATERAL (
+	SELECT COALESCE(SUM(o.asset_E8) FILTER (WHERE o.asset = u.pool), 0)::BIGINT AS asset_E8,
+		COALESCE(SUM(o.asset_E8) FILTER (WHERE o.asset IN ('THOR.RUNE', 'BNB.RUNE-67C', 'BNB.RUNE-B1A')), 0)::BIGINT AS rune_E8
+	FROM outbound_events o
+	WHERE o.in_tx = u.tx
+) AS paid ON true`
+
 func UnstakesLookup(ctx context.Context, w Window) (*Unstakes, error) {
 	// BUG(pascaldekloe): No way for asset declarations in unstake events to detect RUNE.
 	const q = `SELECT COALESCE(COUNT(*), 0), COALESCE(COUNT(DISTINCT(to_addr)), 0), COALESCE(SUM(asset_e8), 0)
@@ -51,7 +63,50 @@ FROM unstake_events
 WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3
 GROUP BY asset`
 
-	rows, err := DBQuery(ctx, q, pool, w.Since.UnixNano(), w.Until.UnixNano())
+	return queryPoolUnstakes(ctx, pool, q, pool, w.Since.UnixNano(), w.Until.UnixNano())
+}
+
+// PoolUnstakesAddrLookup gets the unstakes of addr from pool.
+func PoolUnstakesAddrLookup(ctx context.Context, pool, addr string, w Window) (*PoolUnstakes, error) {
+	const q = `SELECT asset, COALESCE(COUNT(*), 0), COALESCE(SUM(asset_e8), 0), COALESCE(SUM(stake_units), 0), COALESCE(SUM(basis_points), 0)
+FROM unstake_events
+WHERE pool = $1 AND from_addr = $2 AND block_timestamp >= $3 AND block_timestamp < $4
+GROUP BY asset`
+
+	return queryPoolUnstakes(ctx, pool, q, pool, addr, w.Since.UnixNano(), w.Until.UnixNano())
+}
+
+// PoolWithdrawals are the amounts paid out for unstakes from a pool.
+type PoolWithdrawals struct {
+	AssetE8Total int64
+	RuneE8Total  int64
+}
+
+// PoolWithdrawalsAddrLookup gets the outbounds of the unstakes of addr from
+// pool.
+func PoolWithdrawalsAddrLookup(ctx context.Context, pool, addr string, w Window) (*PoolWithdrawals, error) {
+	q := `SELECT COALESCE(SUM(paid.asset_E8), 0)::BIGINT, COALESCE(SUM(paid.rune_E8), 0)::BIGINT
+FROM unstake_events u
+` + unstakeOutboundsJoin + `
+WHERE u.pool = $1 AND u.from_addr = $2 AND u.block_timestamp >= $3 AND u.block_timestamp < $4`
+
+	rows, err := DBQuery(ctx, q, pool, addr, w.Since.UnixNano(), w.Until.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var withdrawals PoolWithdrawals
+	if rows.Next() {
+		if err := rows.Scan(&withdrawals.AssetE8Total, &withdrawals.RuneE8Total); err != nil {
+			return nil, err
+		}
+	}
+	return &withdrawals, rows.Err()
+}
+
+func queryPoolUnstakes(ctx context.Context, pool, q string, args ...interface{}) (*PoolUnstakes, error) {
+	rows, err := DBQuery(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -84,3 +139,45 @@ GROUP BY asset`
 
 	return &unstakes, rows.Err()
 }
+
+// UnstakeEvent is a single unstake from a pool. The amounts are those of the
+// unstake request, i.e., RUNE or the pool asset.
+type UnstakeEvent struct {
+	TxID        string
+	FromAddr    string
+	AssetE8     int64
+	RuneE8      int64
+	Units       int64
+	BasisPoints int64
+	Time        time.Time
+}
+
+// PoolUnstakeEventList gets the unstakes of pool within the window, most
+// recent first.
+func PoolUnstakeEventList(ctx context.Context, pool string, w Window, limit, offset int) ([]UnstakeEvent, error) {
+	const q = `SELECT tx, from_addr,
+	CASE WHEN asset = pool THEN asset_E8 ELSE 0 END,
+	CASE WHEN asset IN ('THOR.RUNE', 'BNB.RUNE-67C', 'BNB.RUNE-B1A') THEN asset_E8 ELSE 0 END,
+	stake_units, basis_points, block_timestamp
+FROM unstake_events
+WHERE pool = $1 AND block_timestamp >= $2 AND block_timestamp < $3
+ORDER BY block_timestamp DESC
+LIMIT $4 OFFSET $5`
+	rows, err := DBQuery(ctx, q, pool, w.Since.UnixNano(), w.Until.UnixNano(), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]UnstakeEvent, 0, limit)
+	for rows.Next() {
+		var e UnstakeEvent
+		var timestamp int64
+		if err := rows.Scan(&e.TxID, &e.FromAddr, &e.AssetE8, &e.RuneE8, &e.Units, &e.BasisPoints, &timestamp); err != nil {
+			return a, err
+		}
+		e.Time = time.Unix(0, timestamp)
+		a = append(a, e)
+	}
+	return a, rows.Err()
+}
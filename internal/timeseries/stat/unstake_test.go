@@ -24,3 +24,47 @@ func TestPoolAssetUnstakesLookup(t *testing.T) {
 	}
 	t.Logf("got %+v", got)
 }
+
+func TestPoolUnstakesAddrLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolUnstakesAddrLookup(context.Background(), "BNB.DOS-120", "tbnb1uhkhl8ctdqal2rnx3n9k4hrf4yfqcz4wzuqc43", testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestPoolWithdrawalsAddrLookup(t *testing.T) {
+	tx := sqltest.NewTx(t)
+	DBQuery = tx.QueryContext
+
+	const addr, pool = "thor1withdrawals", "BTC.TEST-WITHDRAWALS"
+	t0 := testWindow.Since.UnixNano()
+	exec := func(q string, args ...interface{}) {
+		if _, err := tx.Exec(q, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// request with a donation of 1 RUNE
+	exec(`INSERT INTO unstake_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, pool, stake_units, basis_points, asymmetry, block_timestamp)
+VALUES ('UNSTAKE1', 'THOR', $1, '', 'THOR.RUNE', 1, '', $2, 50, 10000, 0, $3)`, addr, pool, t0)
+	exec(`INSERT INTO outbound_events (tx, chain, from_addr, to_addr, asset, asset_E8, memo, in_tx, block_timestamp)
+VALUES ('OUT1', 'BTC', '', 'bc1a', $1, 60, '', 'UNSTAKE1', $2), ('OUT2', 'THOR', '', $3, 'THOR.RUNE', 55, '', 'UNSTAKE1', $2)`, pool, t0+1, addr)
+
+	got, err := PoolWithdrawalsAddrLookup(context.Background(), pool, addr, testWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AssetE8Total != 60 || got.RuneE8Total != 55 {
+		t.Errorf("got %+v, want 60 asset and 55 RUNE", got)
+	}
+}
+
+func TestPoolUnstakeEventList(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	got, err := PoolUnstakeEventList(context.Background(), "BNB.DOS-120", testWindow, 50, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got %+v", got)
+}
@@ -0,0 +1,145 @@
+package stat
+
+import (
+	"context"
+	"time"
+)
+
+// ActiveUsers are the distinct addresses which interacted within a bucket.
+type ActiveUsers struct {
+	Time      time.Time // bucket start
+	Swappers  int64
+	Stakers   int64
+	Unstakers int64
+	Total     int64 // distinct over all three
+}
+
+// ActiveUsersBucketsLookup gets the exact (not approximated) distinct address
+// counts per bucket.
+func ActiveUsersBucketsLookup(ctx context.Context, bucketSize time.Duration, w Window) ([]ActiveUsers, error) {
+	n, err := bucketsFor(bucketSize, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(bucketSize) * int64(bucketSize)
+
+	const q = `SELECT time_bucket($1, block_timestamp) AS bucket,
+	COUNT(DISTINCT addr) FILTER (WHERE kind = 'swap'),
+	COUNT(DISTINCT addr) FILTER (WHERE kind = 'stake'),
+	COUNT(DISTINCT addr) FILTER (WHERE kind = 'unstake'),
+	COUNT(DISTINCT addr)
+FROM (
+	SELECT from_addr AS addr, 'swap' AS kind, block_timestamp FROM swap_events
+	WHERE block_timestamp >= $2 AND block_timestamp < $3
+	UNION ALL
+	SELECT rune_addr, 'stake', block_timestamp FROM stake_events
+	WHERE block_timestamp >= $2 AND block_timestamp < $3
+	UNION ALL
+	SELECT from_addr, 'unstake', block_timestamp FROM unstake_events
+	WHERE block_timestamp >= $2 AND block_timestamp < $3
+) AS users
+GROUP BY bucket
+ORDER BY bucket`
+
+	rows, err := DBQuery(ctx, q, bucketSize.Nanoseconds(), first, first+n*int64(bucketSize))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]ActiveUsers, n)
+	for i := range a {
+		a[i].Time = time.Unix(0, first+int64(i)*int64(bucketSize))
+	}
+	for rows.Next() {
+		var bucket int64
+		var u ActiveUsers
+		if err := rows.Scan(&bucket, &u.Swappers, &u.Stakers, &u.Unstakers, &u.Total); err != nil {
+			return nil, err
+		}
+		i := (bucket - first) / int64(bucketSize)
+		if i < 0 || i >= n {
+			continue
+		}
+		u.Time = a[i].Time
+		a[i] = u
+	}
+	return a, rows.Err()
+}
+
+// PoolTraders are the swap addresses of a pool within a bucket.
+type PoolTraders struct {
+	Time      time.Time // bucket start
+	New       int64     // first swap in pool
+	Returning int64     // swapped in pool before
+	Total     int64     // distinct addresses which ever swapped in pool
+}
+
+// PoolTradersBucketsLookup gets the new and the returning traders of pool per
+// bucket. The total up to the window comes from the pool_first_swaps view,
+// which lags behind until its next refresh.
+func PoolTradersBucketsLookup(ctx context.Context, pool string, bucketSize time.Duration, w Window) ([]PoolTraders, error) {
+	n, err := bucketsFor(bucketSize, w)
+	if err != nil {
+		return nil, err
+	}
+	first := w.Since.UnixNano() / int64(bucketSize) * int64(bucketSize)
+
+	const q = `WITH active AS (
+	SELECT DISTINCT time_bucket($2, block_timestamp) AS bucket, from_addr AS addr
+	FROM swap_events
+	WHERE pool = $1 AND block_timestamp >= $3 AND block_timestamp < $4
+), firsts AS (
+	SELECT addr, (SELECT MIN(block_timestamp) FROM swap_events s WHERE s.pool = $1 AND s.from_addr = a.addr) AS first_swap
+	FROM (SELECT DISTINCT addr FROM active) a
+)
+SELECT active.bucket,
+	COUNT(*) FILTER (WHERE firsts.first_swap >= active.bucket),
+	COUNT(*) FILTER (WHERE firsts.first_swap < active.bucket)
+FROM active JOIN firsts ON firsts.addr = active.addr
+GROUP BY active.bucket
+ORDER BY active.bucket`
+
+	rows, err := DBQuery(ctx, q, pool, bucketSize.Nanoseconds(), first, first+n*int64(bucketSize))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := make([]PoolTraders, n)
+	for i := range a {
+		a[i].Time = time.Unix(0, first+int64(i)*int64(bucketSize))
+	}
+	for rows.Next() {
+		var bucket, newCount, returning int64
+		if err := rows.Scan(&bucket, &newCount, &returning); err != nil {
+			return nil, err
+		}
+		i := (bucket - first) / int64(bucketSize)
+		if i < 0 || i >= n {
+			continue
+		}
+		a[i].New, a[i].Returning = newCount, returning
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const totalQ = "SELECT COUNT(*) FROM pool_first_swaps WHERE pool = $1 AND first_swap < $2"
+	rows, err = DBQuery(ctx, totalQ, pool, first)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var total int64
+	if rows.Next() {
+		if err := rows.Scan(&total); err != nil {
+			return nil, err
+		}
+	}
+	for i := range a {
+		total += a[i].New
+		a[i].Total = total
+	}
+	return a, rows.Err()
+}
@@ -0,0 +1,45 @@
+package stat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/sqltest"
+)
+
+func TestActiveUsersBucketsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	w := Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()}
+	got, err := ActiveUsersBucketsLookup(context.Background(), 24*time.Hour, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Errorf("got %d buckets, want 31", len(got))
+	}
+	for _, u := range got {
+		if u.Total < u.Swappers || u.Total < u.Stakers || u.Total < u.Unstakers {
+			t.Errorf("bucket %s total %d below a part", u.Time, u.Total)
+		}
+	}
+	t.Logf("got %+v", got)
+}
+
+func TestPoolTradersBucketsLookup(t *testing.T) {
+	DBQuery = sqltest.NewTx(t).QueryContext
+	w := Window{Since: time.Now().Add(-30 * 24 * time.Hour), Until: time.Now()}
+	got, err := PoolTradersBucketsLookup(context.Background(), "BNB.BNB", 24*time.Hour, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Errorf("got %d buckets, want 31", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Total < got[i-1].Total {
+			t.Errorf("bucket %s total %d below previous %d", got[i].Time, got[i].Total, got[i-1].Total)
+		}
+	}
+	t.Logf("got %+v", got)
+}
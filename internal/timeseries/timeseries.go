@@ -82,6 +82,10 @@ func Setup() (lastBlockHeight int64, lastBlockTimestamp time.Time, lastBlockHash
 		return 0, time.Time{}, nil, err
 	}
 
+	if err := validatePoolIdConsistency(); err != nil {
+		return 0, time.Time{}, nil, err
+	}
+
 	// sync in-memory tracker
 	lastBlockTrack.Store(track)
 
@@ -132,6 +136,12 @@ func CommitBlock(height int64, timestamp time.Time, hash []byte) error {
 		log.Printf("block height %d already committed", height)
 	}
 
+	// Sparse depth history in aggregate_states. Failure only causes gaps
+	// in the history, which should not stop the block feed.
+	if err := depthSnapshot.update(height, track.AssetE8DepthPerPool, track.RuneE8DepthPerPool); err != nil {
+		log.Printf("depth snapshot at block height %d omitted from persistence: %s", height, err)
+	}
+
 	// calculate & reset
 	recorder.linkedEvents.ApplyOutboundQ(&recorder.runningTotals, height, timestamp)
 	recorder.linkedEvents.ApplyFeeQ(&recorder.runningTotals, height, timestamp)
@@ -166,3 +176,21 @@ func AssetAndRuneDepthsAtHeight(height int64) (assetE8PerPool, runeE8PerPool map
 	log.Print("track at hight: ", *track)
 	return track.aggTrack.AssetE8DepthPerPool, track.aggTrack.RuneE8DepthPerPool, track.Timestamp
 }
+
+// RefreshPoolMemberCounts updates the (materialized) view from the latest.
+func RefreshPoolMemberCounts() error {
+	_, err := DBExec("REFRESH MATERIALIZED VIEW CONCURRENTLY pool_member_counts")
+	return err
+}
+
+// RefreshPoolFirstSwaps updates the (materialized) view from the latest.
+func RefreshPoolFirstSwaps() error {
+	_, err := DBExec("REFRESH MATERIALIZED VIEW CONCURRENTLY pool_first_swaps")
+	return err
+}
+
+// RefreshStakerCount updates the (materialized) view from the latest.
+func RefreshStakerCount() error {
+	_, err := DBExec("REFRESH MATERIALIZED VIEW staker_count")
+	return err
+}
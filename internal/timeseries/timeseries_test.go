@@ -2,6 +2,7 @@ package timeseries
 
 import (
 	"bytes"
+	"context"
 	"testing"
 	"time"
 
@@ -64,3 +65,94 @@ func TestCommitBlock(t *testing.T) {
 		t.Errorf("cold start got [%d, %s, %q], want [%d, %s, %q]", gotHeight, gotTimestamp, gotHash, height, timestamp, hash)
 	}
 }
+
+func TestPoolIdConsistency(t *testing.T) {
+	mustSetup(t)
+
+	for _, q := range []string{
+		"DELETE FROM pool_id_map",
+		"DELETE FROM aggregate_id_states",
+		"INSERT INTO pool_id_map (pool_id, pool) VALUES (0, 'BTC.TEST-ID')",
+		"INSERT INTO aggregate_states (height, pool, asset_e8, rune_e8) VALUES (1000001, 'BTC.TEST-ID', 1, 2)",
+		"INSERT INTO aggregate_id_states (height, pool_id, asset_e8, rune_e8) VALUES (1000001, 0, 1, 2)",
+	} {
+		if _, err := DBExec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := validatePoolIdConsistency(); err != nil {
+		t.Fatal("consistent state got error:", err)
+	}
+	if got := poolIdMapperr["BTC.TEST-ID"]; got != 0 {
+		t.Errorf("got pool ID %d restored, want 0", got)
+	}
+
+	if _, err := DBExec("UPDATE aggregate_id_states SET rune_e8 = 3 WHERE pool_id = 0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := validatePoolIdConsistency(); err == nil {
+		t.Error("depth mismatch got no error")
+	}
+}
+
+func TestSnapshotFlushAtomic(t *testing.T) {
+	mustSetup(t)
+
+	// conflicts with the flush below
+	const q = "INSERT INTO aggregate_states (height, pool, asset_e8, rune_e8) VALUES (1000001, 'BTC.TEST-ATOMIC', 1, 1)"
+	if _, err := DBExec(q); err != nil {
+		t.Fatal(err)
+	}
+
+	sm := snapshotManager{pending: []depthRow{
+		{1000001, "BTC.TEST-NEW", 2, 2},
+		{1000001, "BTC.TEST-ATOMIC", 2, 2},
+	}}
+	// the failure aborts the test transaction, and with it any partial write
+	if err := sm.flush(1000001); err == nil {
+		t.Fatal("conflict got no error")
+	}
+	if _, ok := poolIdMapperr["BTC.TEST-NEW"]; ok {
+		t.Error("pool ID assigned without write")
+	}
+}
+
+func TestSnapshotInterval(t *testing.T) {
+	mustSetup(t)
+	defer func(n int64) { SnapshotInterval = n }(SnapshotInterval)
+	SnapshotInterval = 10
+
+	var sm snapshotManager
+	countRows := func() int {
+		rows, err := DBQuery(context.Background(), "SELECT COUNT(*) FROM aggregate_states WHERE pool = 'BTC.TEST-INTERVAL'")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var n int
+		if rows.Next() {
+			if err := rows.Scan(&n); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return n
+	}
+
+	for height := int64(1000001); height < 1000010; height++ {
+		depths := map[string]int64{"BTC.TEST-INTERVAL": height}
+		if err := sm.update(height, depths, depths); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := countRows(); n != 0 {
+		t.Errorf("got %d rows before the interval, want 0", n)
+	}
+
+	depths := map[string]int64{"BTC.TEST-INTERVAL": 1000010}
+	if err := sm.update(1000010, depths, depths); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(); n != 10 {
+		t.Errorf("got %d rows at the interval, want 10", n)
+	}
+}